@@ -0,0 +1,382 @@
+package hmux
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxBindBytes is the request body limit used by a Binder with no
+// MaxBytes set.
+const DefaultMaxBindBytes = 1 << 20
+
+// Validator is implemented by bind destinations that check their own
+// contents after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// Binder decodes request bodies into structs. The zero value is ready to
+// use; Bind uses DefaultBinder.
+type Binder struct {
+	// MaxBytes limits the size of the request body. Zero means
+	// DefaultMaxBindBytes.
+	MaxBytes int64
+
+	// DisallowUnknownFields makes Bind reject JSON objects and forms with
+	// keys that do not map to a field of the destination.
+	DisallowUnknownFields bool
+}
+
+// DefaultBinder is the Binder used by Bind and MustBind.
+var DefaultBinder = &Binder{}
+
+// Bind decodes the body of r into dst with DefaultBinder; see
+// Binder.Bind.
+func Bind(r *http.Request, dst any) error {
+	return DefaultBinder.Bind(r, dst)
+}
+
+// MustBind is like Bind but aborts the route on error, handing the error
+// to the Mux's error handler. It must only be called from handlers
+// registered with hmux.
+//
+// Example:
+//
+//	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+//	    var dto CreateUser
+//	    hmux.MustBind(r, &dto) // 400, 413 or 415 via the error handler
+//	    ...
+//	})
+func MustBind(r *http.Request, dst any) {
+	if err := Bind(r, dst); err != nil {
+		raise(err)
+	}
+}
+
+// Bind decodes the body of r into dst based on the request Content-Type
+// and then, if dst implements Validator, calls its Validate method.
+//
+// JSON bodies (application/json and types with a +json suffix) are
+// decoded with encoding/json into any dst. URL-encoded and multipart
+// forms are decoded into a struct by its "form" tags, following the
+// rules of BindQuery.
+//
+// Errors are *Error values: 415 Unsupported Media Type for other content
+// types, 413 Request Entity Too Large for bodies over the limit, and 400
+// Bad Request for malformed bodies. Errors returned by Validate are
+// reported with status 400 unless they already wrap an *Error.
+func (b *Binder) Bind(r *http.Request, dst any) error {
+	limit := b.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBindBytes
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var err error
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		err = b.bindJSON(r, dst, limit)
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		err = b.bindForm(r, dst, mediaType, limit)
+	default:
+		return NewError(http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type %q", mediaType))
+	}
+	if err != nil {
+		return err
+	}
+
+	return validate(dst)
+}
+
+// validate calls the Validate method of dst, if it implements Validator,
+// and reports its error with status 400 unless it wraps an *Error.
+func validate(dst any) error {
+	if v, ok := dst.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			var e *Error
+			if errors.As(err, &e) {
+				return err
+			}
+
+			return NewError(http.StatusBadRequest, err)
+		}
+	}
+
+	return nil
+}
+
+func (b *Binder) bindJSON(r *http.Request, dst any, limit int64) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, limit))
+	if b.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		return bodyError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return Errorf(http.StatusBadRequest, "invalid JSON body: unexpected data after value")
+	}
+
+	return nil
+}
+
+func (b *Binder) bindForm(r *http.Request, dst any, mediaType string, limit int64) error {
+	r.Body = http.MaxBytesReader(nil, r.Body, limit)
+
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(limit)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return bodyError(err)
+	}
+
+	if err := bindValues(r.PostForm, "form", dst); err != nil {
+		return err
+	}
+
+	if b.DisallowUnknownFields {
+		known := make(map[string]bool)
+		formKeys(reflect.TypeOf(dst).Elem(), "form", known)
+		for key := range r.PostForm {
+			if !known[key] {
+				return Errorf(http.StatusBadRequest, "form %q: unknown field", key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// bodyError classifies a body decoding error.
+func bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return Errorf(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", maxErr.Limit)
+	}
+	if errors.Is(err, io.EOF) {
+		return Errorf(http.StatusBadRequest, "empty request body")
+	}
+
+	return Errorf(http.StatusBadRequest, "invalid request body: %w", err)
+}
+
+// formKeys records the keys bound by the struct type t under tag.
+func formKeys(t reflect.Type, tag string, keys map[string]bool) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup(tag)
+		if !ok || key == "-" {
+			if field.Anonymous {
+				formKeys(field.Type, tag, keys)
+			}
+
+			continue
+		}
+		keys[key] = true
+	}
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// BindQuery decodes the URL query of r into the struct pointed to by dst.
+// Fields are bound by their "query" tag; untagged fields are left alone:
+//
+//	type Filter struct {
+//	    Status []string  `query:"status"`
+//	    Limit  int       `query:"limit" default:"20"`
+//	    Since  time.Time `query:"since" layout:"2006-01-02"`
+//	}
+//
+//	var f Filter
+//	if err := hmux.BindQuery(r, &f); err != nil { ... }
+//
+// Supported field types are strings, booleans, integers, floats,
+// time.Duration, time.Time, types implementing encoding.TextUnmarshaler,
+// and pointers and slices of these. Slices collect repeated keys, as in
+// "?status=open&status=closed". Times are parsed with the layout in the
+// "layout" tag, or time.RFC3339 if there is none. A "default" tag
+// supplies the value used when the key is absent. Embedded structs are
+// bound as if their fields were declared on the outer struct.
+//
+// Conversion errors are *Error values with status 400 Bad Request that
+// name the offending key. BindQuery panics if dst is not a non-nil
+// pointer to a struct or if a bound field has an unsupported type.
+func BindQuery(r *http.Request, dst any) error {
+	return bindValues(r.URL.Query(), "query", dst)
+}
+
+// bindValues decodes values into the struct pointed to by dst, using the
+// given struct tag for key names.
+func bindValues(values url.Values, tag string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("hmux: bind destination must be a non-nil pointer to a struct")
+	}
+
+	return bindStruct(values, tag, v.Elem())
+}
+
+func bindStruct(values url.Values, tag string, v reflect.Value) error {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		key, ok := field.Tag.Lookup(tag)
+		if !ok || key == "-" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindStruct(values, tag, fv); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		raw, present := values[key]
+		if !present || len(raw) == 0 {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			raw = []string{def}
+		}
+
+		layout := field.Tag.Get("layout")
+		if err := setField(fv, raw, layout); err != nil {
+			return Errorf(http.StatusBadRequest, "%s %q: %w", tag, key, err)
+		}
+	}
+
+	return nil
+}
+
+// setField stores raw into v, converting according to v's type. Slices
+// receive every value; other types receive the first.
+func setField(v reflect.Value, raw []string, layout string) error {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textUnmarshalerType) && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, r := range raw {
+			if err := setValue(s.Index(i), r, layout); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+		return nil
+	}
+
+	return setValue(v, raw[0], layout)
+}
+
+// setValue converts s and stores it into v.
+func setValue(v reflect.Value, s, layout string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return fmt.Errorf("invalid time %q, expected layout %q", s, layout)
+		}
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q: %w", s, numError(err))
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q: %w", s, numError(err))
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", s, numError(err))
+		}
+		v.SetFloat(f)
+	default:
+		panic("hmux: unsupported bind field type " + v.Type().String())
+	}
+
+	return nil
+}
+
+// numError unwraps the cause of a strconv.NumError, which otherwise
+// repeats the input in its message.
+func numError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+
+	return err
+}
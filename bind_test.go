@@ -0,0 +1,182 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pageQuery struct {
+	Limit  int `query:"limit" default:"20"`
+	Offset int `query:"offset"`
+}
+
+type filterQuery struct {
+	pageQuery
+
+	Status  []string      `query:"status"`
+	Since   time.Time     `query:"since" layout:"2006-01-02"`
+	Until   *time.Time    `query:"until"`
+	Active  bool          `query:"active" default:"true"`
+	Score   float64       `query:"score"`
+	Window  time.Duration `query:"window"`
+	Addr    netip.Addr    `query:"addr"`
+	IDs     []uint16      `query:"id"`
+	Ignored string
+}
+
+func TestBindQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?status=open&status=closed&since=2024-03-01"+
+		"&until=2024-03-02T10:00:00Z&score=0.5&window=90s&addr=10.0.0.1&id=1&id=2&offset=40&Ignored=x", nil)
+
+	var f filterQuery
+	if err := BindQuery(r, &f); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(f.Status, []string{"open", "closed"}) {
+		t.Errorf("expected status slice, got %v", f.Status)
+	}
+	if f.Limit != 20 || f.Offset != 40 {
+		t.Errorf("expected limit 20 offset 40, got %d %d", f.Limit, f.Offset)
+	}
+	if !f.Since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected since %v", f.Since)
+	}
+	if f.Until == nil || f.Until.Hour() != 10 {
+		t.Errorf("unexpected until %v", f.Until)
+	}
+	if !f.Active || f.Score != 0.5 || f.Window != 90*time.Second {
+		t.Errorf("unexpected scalars %v %v %v", f.Active, f.Score, f.Window)
+	}
+	if f.Addr != netip.MustParseAddr("10.0.0.1") {
+		t.Errorf("unexpected addr %v", f.Addr)
+	}
+	if !slices.Equal(f.IDs, []uint16{1, 2}) {
+		t.Errorf("unexpected ids %v", f.IDs)
+	}
+	if f.Ignored != "" {
+		t.Errorf("expected untagged field to be ignored, got %q", f.Ignored)
+	}
+}
+
+func TestBindQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		msg   string
+	}{
+		{"limit=ten", `query "limit": invalid integer "ten": invalid syntax`},
+		{"id=70000", `query "id": invalid unsigned integer "70000": value out of range`},
+		{"since=yesterday", `query "since": invalid time "yesterday", expected layout "2006-01-02"`},
+		{"active=maybe", `query "active": invalid boolean "maybe"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var f filterQuery
+			err := BindQuery(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil), &f)
+
+			if StatusCode(err) != http.StatusBadRequest {
+				t.Errorf("expected 400 error, got %v", err)
+			}
+			if err != nil && err.Error() != tt.msg {
+				t.Errorf("expected %q, got %q", tt.msg, err.Error())
+			}
+		})
+	}
+}
+
+func TestBindQuery_InvalidDestination_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	var f filterQuery
+	BindQuery(httptest.NewRequest(http.MethodGet, "/", nil), f)
+}
+
+type createUser struct {
+	Name  string `json:"name" form:"name"`
+	Email string `json:"email" form:"email"`
+	Age   int    `json:"age" form:"age"`
+}
+
+func (c *createUser) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.Age < 0 {
+		return Errorf(http.StatusUnprocessableEntity, "age must not be negative")
+	}
+
+	return nil
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name        string
+		binder      *Binder
+		contentType string
+		body        string
+		status      int
+		expected    createUser
+	}{
+		{"json", DefaultBinder, "application/json", `{"name":"ann","age":30}`, 0, createUser{Name: "ann", Age: 30}},
+		{"json suffix", DefaultBinder, "application/vnd.api+json; charset=utf-8", `{"name":"ann"}`, 0, createUser{Name: "ann"}},
+		{"form", DefaultBinder, "application/x-www-form-urlencoded", "name=bob&age=41&extra=1", 0, createUser{Name: "bob", Age: 41}},
+		{"unsupported type", DefaultBinder, "text/plain", "name=bob", http.StatusUnsupportedMediaType, createUser{}},
+		{"malformed json", DefaultBinder, "application/json", `{"name":`, http.StatusBadRequest, createUser{}},
+		{"empty json", DefaultBinder, "application/json", ``, http.StatusBadRequest, createUser{}},
+		{"trailing data", DefaultBinder, "application/json", `{"name":"ann"} {}`, http.StatusBadRequest, createUser{Name: "ann"}},
+		{"too large", &Binder{MaxBytes: 8}, "application/json", `{"name":"ann"}`, http.StatusRequestEntityTooLarge, createUser{}},
+		{"unknown json field", &Binder{DisallowUnknownFields: true}, "application/json", `{"name":"ann","admin":true}`, http.StatusBadRequest, createUser{Name: "ann"}},
+		{"unknown form field", &Binder{DisallowUnknownFields: true}, "application/x-www-form-urlencoded", "name=bob&admin=1", http.StatusBadRequest, createUser{Name: "bob"}},
+		{"validation", DefaultBinder, "application/json", `{"age":3}`, http.StatusBadRequest, createUser{Age: 3}},
+		{"validation status", DefaultBinder, "application/json", `{"name":"ann","age":-1}`, http.StatusUnprocessableEntity, createUser{Name: "ann", Age: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			var got createUser
+			err := tt.binder.Bind(r, &got)
+
+			if tt.status == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.status != 0 && StatusCode(err) != tt.status {
+				t.Errorf("expected status %d, got %v", tt.status, err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMustBind(t *testing.T) {
+	m := New()
+	m.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var dto createUser
+		MustBind(r, &dto)
+		w.Write([]byte(dto.Name))
+	})
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	m.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusBadRequest || rec.Body.String() != "name is required\n" {
+		t.Errorf("expected 400 %q, got %d %q", "name is required\n", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// Clone returns a deep copy of the Mux: its routes, middleware, named
+// middleware, outer middleware, options, error and panic handlers, and
+// Host and Version routers. Routes and middleware added to either Mux
+// afterwards, and named middleware replaced on either, do not affect the
+// other, so variants of a server can be derived from a common base:
+//
+//	public := hmux.New()
+//	public.Use(middleware.Logger)
+//	public.HandleFunc("GET /users", listUsers)
+//
+//	internal := public.Clone()
+//	internal.HandleFunc("GET /debug/vars", expvar.Handler().ServeHTTP)
+//
+// Cloned routes keep the middleware they were registered with; on a Mux
+// created with Deferred, that is the middleware in effect at the time of
+// the call. Errors raised by cloned routes are rendered by the error
+// handlers in effect on the original.
+//
+// Clone panics on a Mux created with Wrap.
+func (m *Mux) Clone() *Mux {
+	if m.wrapped {
+		panic("hmux: Clone is not supported on a Mux created with Wrap")
+	}
+
+	return m.clone(nil, make(map[*namedMiddleware]*namedMiddleware))
+}
+
+// Merge registers the routes of other on the Mux, for modular monoliths
+// whose modules each build their own Mux:
+//
+//	mux := hmux.New()
+//	mux.Use(middleware.Logger)
+//	if err := mux.Merge(billing.Routes()); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Each route keeps the middleware, metadata and matchers it has on other
+// and is wrapped with the Mux's current middleware, which runs first.
+// Routes of other's Host and Version routers and its outer middleware
+// are not merged.
+//
+// Merge registers every route it can and returns the errors of those it
+// cannot, such as patterns conflicting with routes of the Mux, as
+// *RouteError values joined with errors.Join.
+//
+// Merge panics if other is nil or the Mux itself.
+func (m *Mux) Merge(other *Mux) error {
+	if other == nil || other == m {
+		panic("hmux: Merge needs another Mux")
+	}
+
+	unlock := other.lock()
+	routes := slices.Clone(other.routes)
+	unlock()
+
+	var errs []error
+	for _, rt := range routes {
+		err := tryRegister(rt.pattern, func() {
+			m.handle(rt.pattern, rt.handler, m.stack, rt.meta, m.errorHandlerFunc, rt.matchers)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// clone copies m for Clone as a child of parent. slots maps the named
+// middleware of the original to their copies.
+func (m *Mux) clone(parent *Mux, slots map[*namedMiddleware]*namedMiddleware) *Mux {
+	defer m.lock()()
+
+	c := &Mux{
+		trailingSlash:  m.trailingSlash,
+		caseFold:       m.caseFold,
+		strict:         m.strict,
+		notFound:       m.notFound,
+		outer:          slices.Clone(m.outer),
+		deferred:       m.deferred,
+		parent:         parent,
+		errorHandler:   m.errorHandler,
+		panicHandler:   m.panicHandler,
+		defaultVersion: m.defaultVersion,
+		cacheChains:    m.cacheChains,
+		dynamic:        m.dynamic,
+	}
+	c.dispatch = wrap(http.HandlerFunc(c.serve), c.outer)
+
+	for name, slot := range m.named {
+		s := &namedMiddleware{
+			name:   slot.name,
+			mw:     slot.mw,
+			before: slices.Clone(slot.before),
+			after:  slices.Clone(slot.after),
+		}
+		if c.named == nil {
+			c.named = make(map[string]*namedMiddleware)
+		}
+		c.named[name] = s
+		slots[slot] = s
+	}
+
+	stacks := make(map[chainKey][]layer)
+	remap := func(stack []layer) []layer {
+		if len(stack) == 0 {
+			return nil
+		}
+
+		key := chainKey{first: &stack[0], n: len(stack)}
+		if s, ok := stacks[key]; ok {
+			return s
+		}
+
+		s := slices.Clone(stack)
+		for i, l := range s {
+			if copied, ok := slots[l.slot]; ok {
+				s[i] = layer{fn: c.resolveNamed(copied), slot: copied}
+			}
+		}
+		stacks[key] = s
+
+		return s
+	}
+
+	c.middleware = remap(m.middleware)
+
+	routes := make([]*route, len(m.routes))
+	for i, rt := range m.routes {
+		s := remap(rt.stack())
+		routes[i] = &route{
+			pattern:  rt.pattern,
+			base:     rt.base,
+			stack:    func() []layer { return s },
+			meta:     rt.meta,
+			matchers: rt.matchers,
+		}
+		routes[i].handler = c.routeHandler(routes[i], c.chain(routes[i], rt.base, s))
+	}
+	c.swap(routes)
+
+	for _, h := range m.hosts {
+		c.hosts = append(c.hosts, &hostRoute{
+			pattern: h.pattern,
+			labels:  h.labels,
+			mux:     h.mux.clone(c, slots),
+		})
+	}
+
+	for _, v := range m.versions {
+		cv := *v
+		cv.mux = v.mux.clone(c, slots)
+		c.versions = append(c.versions, &cv)
+	}
+
+	return c
+}
@@ -0,0 +1,91 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	var record []string
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "handler")
+	}
+
+	m := New()
+	m.UseNamed("auth", recordingMiddleware("auth", &record))
+	m.Use(recordingMiddleware("log", &record))
+	m.HandleFunc("GET /users", ok)
+	m.Host("admin.example.com").HandleFunc("GET /", ok)
+
+	c := m.Clone()
+	c.ReplaceNamed("auth", recordingMiddleware("internal", &record))
+	c.Use(recordingMiddleware("debug", &record))
+	c.HandleFunc("GET /debug", ok)
+	m.HandleFunc("GET /public", ok)
+
+	tests := []struct {
+		mux    *Mux
+		host   string
+		path   string
+		code   int
+		record []string
+	}{
+		{m, "example.com", "/users", http.StatusOK, []string{"auth:enter", "log:enter", "handler", "log:exit", "auth:exit"}},
+		{c, "example.com", "/users", http.StatusOK, []string{"internal:enter", "log:enter", "handler", "log:exit", "internal:exit"}},
+		{c, "example.com", "/debug", http.StatusOK, []string{"internal:enter", "log:enter", "debug:enter", "handler", "debug:exit", "log:exit", "internal:exit"}},
+		{m, "example.com", "/debug", http.StatusNotFound, nil},
+		{c, "example.com", "/public", http.StatusNotFound, nil},
+		{c, "admin.example.com", "/", http.StatusOK, []string{"internal:enter", "log:enter", "handler", "log:exit", "internal:exit"}},
+	}
+	for _, tt := range tests {
+		record = nil
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		tt.mux.ServeHTTP(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("%s%s: expected status %d, got %d", tt.host, tt.path, tt.code, rec.Code)
+		}
+		if !slices.Equal(record, tt.record) {
+			t.Errorf("%s%s: expected %v, got %v", tt.host, tt.path, tt.record, record)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var record []string
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "handler")
+	}
+
+	billing := New()
+	billing.Use(recordingMiddleware("billing", &record))
+	billing.Meta(M{"tag": "billing"}).HandleFunc("GET /invoices", ok)
+	billing.HandleFunc("GET /users", ok)
+
+	m := New()
+	m.Use(recordingMiddleware("log", &record))
+	m.HandleFunc("GET /users", ok)
+
+	err := m.Merge(billing)
+
+	var routeErr *RouteError
+	if !errors.As(err, &routeErr) || routeErr.Pattern != "GET /users" {
+		t.Errorf("expected conflict for GET /users, got %v", err)
+	}
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	expected := []string{"log:enter", "billing:enter", "handler", "billing:exit", "log:exit"}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+
+	info, found := m.Match(http.MethodGet, "", "/invoices")
+	if !found || info.Meta["tag"] != "billing" {
+		t.Errorf("expected merged metadata, got %+v", info)
+	}
+}
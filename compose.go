@@ -0,0 +1,183 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Skip returns middleware that applies mw to every request except those
+// whose URL path matches one of the given path patterns. Skipped requests
+// go straight to the next handler.
+//
+// Patterns use the path syntax of http.ServeMux without a method or host:
+// "/healthz" matches that path only, a pattern ending in "/" such as
+// "/debug/" matches the whole subtree, "{name}" matches a single segment
+// and a trailing "{name...}" matches the remainder of the path.
+//
+// Skip is useful for keeping health checks and metrics endpoints out of
+// globally attached middleware:
+//
+//	mux.Use(hmux.Skip(authMiddleware, "/healthz", "/metrics"))
+//
+// Skip panics if mw is nil or a pattern does not start with "/".
+func Skip(mw func(http.Handler) http.Handler, patterns ...string) func(http.Handler) http.Handler {
+	if mw == nil {
+		panic("hmux: nil middleware passed to Skip")
+	}
+
+	for _, p := range patterns {
+		if !strings.HasPrefix(p, "/") {
+			panic("hmux: skip pattern must start with /")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, p := range patterns {
+				if matchPath(p, r.URL.Path) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// When returns middleware that applies mw only to requests for which
+// predicate returns true. Other requests go straight to the next handler.
+// The predicate runs once per request, before mw:
+//
+//	external := func(r *http.Request) bool { return r.Header.Get("X-Internal") == "" }
+//	mux.Use(hmux.When(external, authMiddleware))
+//
+// When panics if predicate or mw is nil.
+func When(predicate func(*http.Request) bool, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if predicate == nil || mw == nil {
+		panic("hmux: nil predicate or middleware passed to When")
+	}
+
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if predicate(r) {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Middleware is implemented by stateful middleware objects, such as rate
+// limiters or caches, that keep their state across requests.
+type Middleware interface {
+	Middleware(next http.Handler) http.Handler
+}
+
+// FromMiddleware adapts a Middleware object to a
+// func(http.Handler) http.Handler, so it can be passed to Use, With,
+// Chain and everything else accepting middleware functions:
+//
+//	limiter := newLimiter(100)
+//	mux.Use(hmux.FromMiddleware(limiter))
+//	mux.With(hmux.FromMiddleware(limiter), auth).HandleFunc("POST /login", login)
+//
+// FromMiddleware panics if mw is nil.
+func FromMiddleware(mw Middleware) func(http.Handler) http.Handler {
+	if mw == nil {
+		panic("hmux: nil middleware passed to FromMiddleware")
+	}
+
+	return mw.Middleware
+}
+
+// FromNegroni adapts negroni-style middleware, which receives the next
+// handler as a third argument, to a func(http.Handler) http.Handler:
+//
+//	mux.Use(hmux.FromNegroni(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+//	    w.Header().Set("X-Frame-Options", "DENY")
+//	    next(w, r)
+//	}))
+//
+// Middleware of types that implement a ServeHTTP method with this
+// signature, such as negroni.Handler, can pass the method value.
+//
+// FromNegroni panics if fn is nil.
+func FromNegroni(fn func(http.ResponseWriter, *http.Request, http.HandlerFunc)) func(http.Handler) http.Handler {
+	if fn == nil {
+		panic("hmux: nil middleware passed to FromNegroni")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fn(w, r, next.ServeHTTP)
+		})
+	}
+}
+
+// FromHandlerFunc adapts middleware written against http.HandlerFunc,
+// func(http.HandlerFunc) http.HandlerFunc, to a
+// func(http.Handler) http.Handler:
+//
+//	mux.Use(hmux.FromHandlerFunc(legacyAuth))
+//
+// FromHandlerFunc panics if mw is nil.
+func FromHandlerFunc(mw func(http.HandlerFunc) http.HandlerFunc) func(http.Handler) http.Handler {
+	if mw == nil {
+		panic("hmux: nil middleware passed to FromHandlerFunc")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return mw(next.ServeHTTP)
+	}
+}
+
+// matchPath reports whether path matches the ServeMux-style path pattern.
+func matchPath(pattern, path string) bool {
+	for {
+		if pattern == "" || pattern == "/" && path == "/" {
+			return pattern == path
+		}
+		if pattern == "/" {
+			// Trailing slash: match the rest of the subtree.
+			return strings.HasPrefix(path, "/")
+		}
+		if !strings.HasPrefix(path, "/") {
+			return false
+		}
+
+		pseg, prest := nextSegment(pattern[1:])
+		seg, rest := nextSegment(path[1:])
+
+		switch {
+		case strings.HasPrefix(pseg, "{") && strings.HasSuffix(pseg, "...}"):
+			return true
+		case pseg == "{$}":
+			return path == "/"
+		case strings.HasPrefix(pseg, "{") && strings.HasSuffix(pseg, "}"):
+			if seg == "" {
+				return false
+			}
+		case pseg != seg:
+			return false
+		}
+
+		pattern, path = prest, rest
+	}
+}
+
+// nextSegment splits s at the first "/" and returns the segment before it
+// and the remainder starting with the slash.
+func nextSegment(s string) (seg, rest string) {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[:i], s[i:]
+	}
+
+	return s, ""
+}
@@ -0,0 +1,185 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestSkip(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(Skip(recordingMiddleware("auth", &record), "/healthz", "/debug/"))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path    string
+		applied bool
+	}{
+		{"/healthz", false},
+		{"/healthz/extra", true},
+		{"/debug/", false},
+		{"/debug/pprof", false},
+		{"/users", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record = nil
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if applied := len(record) > 0; applied != tt.applied {
+				t.Errorf("expected applied=%v, got %v", tt.applied, applied)
+			}
+		})
+	}
+}
+
+func TestSkip_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"nil middleware", func() { Skip(nil, "/a") }},
+		{"relative pattern", func() { Skip(Chain(), "a") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestWhen(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(When(func(r *http.Request) bool {
+		return r.Header.Get("X-Internal") == ""
+	}, recordingMiddleware("auth", &record)))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "handler")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if len(record) != 3 {
+		t.Errorf("expected middleware applied, got %v", record)
+	}
+
+	record = nil
+	req.Header.Set("X-Internal", "1")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	if len(record) != 1 || record[0] != "handler" {
+		t.Errorf("expected middleware skipped, got %v", record)
+	}
+}
+
+func TestWhen_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	When(nil, Chain())
+}
+
+type countingMiddleware struct {
+	n int
+}
+
+func (c *countingMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.n++
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestFromMiddleware(t *testing.T) {
+	c := &countingMiddleware{}
+	m := New()
+	m.Use(FromMiddleware(c))
+	m.With(FromMiddleware(c)).HandleFunc("GET /with", func(w http.ResponseWriter, r *http.Request) {})
+	m.With(Chain(FromMiddleware(c))).HandleFunc("GET /chain", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/with", "/chain"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if c.n != 4 {
+		t.Errorf("expected 4 calls, got %d", c.n)
+	}
+}
+
+func TestFromMiddleware_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	FromMiddleware(nil)
+}
+
+func TestAdapters(t *testing.T) {
+	var record []string
+	negroni := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		record = append(record, "negroni")
+		next(w, r)
+	}
+	handlerFunc := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			record = append(record, "handlerfunc")
+			next(w, r)
+		}
+	}
+
+	m := New()
+	m.Use(FromNegroni(negroni), FromHandlerFunc(handlerFunc))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "handler")
+	})
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := []string{"negroni", "handlerfunc", "handler"}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"/", "/", true},
+		{"/", "/anything/at/all", true},
+		{"/{$}", "/", true},
+		{"/{$}", "/x", false},
+		{"/healthz", "/healthz", true},
+		{"/healthz", "/healthz/", false},
+		{"/static/", "/static", false},
+		{"/static/", "/static/", true},
+		{"/static/", "/static/css/app.css", true},
+		{"/users/{id}", "/users/42", true},
+		{"/users/{id}", "/users/", false},
+		{"/users/{id}", "/users/42/posts", false},
+		{"/users/{id}/posts", "/users/42/posts", true},
+		{"/files/{path...}", "/files/a/b/c", true},
+		{"/api/{$}", "/api/", true},
+		{"/api/{$}", "/api/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := matchPath(tt.pattern, tt.path); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
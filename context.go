@@ -0,0 +1,177 @@
+package hmux
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// contextKey is the type of context keys defined by hmux. Using an
+// unexported type prevents collisions with keys defined in other packages.
+type contextKey struct {
+	name string
+}
+
+var requestIDKey = &contextKey{"request-id"}
+
+// ContextWithRequestID returns a copy of ctx carrying the given request
+// ID. It is used by middleware.RequestID and is exported so that other
+// middleware or tests can seed a request ID of their own.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty
+// string if none is present.
+//
+// Example:
+//
+//	mux.Use(middleware.RequestID)
+//	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+//	    log.Printf("[%s] listing users", hmux.RequestIDFromContext(r.Context()))
+//	})
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+var claimsKey = &contextKey{"claims"}
+
+// Claims holds the claims of a verified token, keyed by claim name.
+// Values are decoded from JSON, so numbers are float64, arrays are []any,
+// and objects are map[string]any.
+type Claims map[string]any
+
+// String returns the named claim if it is a string, or an empty string
+// otherwise.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Scopes returns the scopes granted by the "scope" claim, a
+// space-separated list as used by OAuth 2.0 (RFC 8693, RFC 7662).
+func (c Claims) Scopes() []string {
+	return strings.Fields(c.String("scope"))
+}
+
+// HasScope reports whether the "scope" claim grants scope.
+func (c Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// ContextWithClaims returns a copy of ctx carrying the given claims. It is
+// used by authentication middleware such as middleware.JWTAuth.
+func ContextWithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the verified token claims stored in ctx, or
+// nil if the request was not authenticated.
+//
+// Example:
+//
+//	api.Use(auth.Handler)
+//	api.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+//	    fmt.Fprintf(w, "hello %s", hmux.ClaimsFromContext(r.Context()).Subject())
+//	})
+func ClaimsFromContext(ctx context.Context) Claims {
+	c, _ := ctx.Value(claimsKey).(Claims)
+	return c
+}
+
+var tenantKey = &contextKey{"tenant"}
+
+// ContextWithTenant returns a copy of ctx carrying the given tenant ID. It
+// is used by Tenants and is exported so that tests and custom resolution
+// middleware can set a tenant of their own.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext returns the ID of the tenant the request belongs to,
+// as resolved by Tenants, or an empty string if there is none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+// originalPathKey holds the escaped request path before a
+// case-insensitive Mux lowercased it.
+var originalPathKey = &contextKey{"original-path"}
+
+var hostParamsKey = &contextKey{"host-params"}
+
+// HostParamFromContext returns the value of the named host wildcard
+// matched by a Router created with Mux.Host, or an empty string if the
+// request was not routed by host or has no such wildcard.
+//
+// Example:
+//
+//	tenants := mux.Host("{tenant}.example.com")
+//	tenants.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+//	    tenant := hmux.HostParamFromContext(r.Context(), "tenant")
+//	    ...
+//	})
+func HostParamFromContext(ctx context.Context, name string) string {
+	params, _ := ctx.Value(hostParamsKey).(map[string]string)
+	return params[name]
+}
+
+// routeKey holds the *route serving the request.
+var routeKey = &contextKey{"route"}
+
+// RoutePattern returns the pattern of the route serving r, as registered
+// on the http.ServeMux, for example "GET /api/v1/users/{id}" for a route
+// registered as "GET /users/{id}" on a group with prefix "/api/v1". The
+// pattern is attached to the request context before the route's
+// middleware runs, so logging, metrics and tracing middleware can use it
+// as a low-cardinality label even when it replaces the request.
+//
+// Outside a route, for example in outer middleware after the inner
+// dispatch returns, RoutePattern falls back to r.Pattern. It returns ""
+// for requests that matched no route.
+//
+// Example:
+//
+//	func metrics(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        next.ServeHTTP(w, r)
+//	        requests.WithLabelValues(hmux.RoutePattern(r)).Inc()
+//	    })
+//	}
+func RoutePattern(r *http.Request) string {
+	if rt, ok := r.Context().Value(routeKey).(*route); ok {
+		return rt.pattern
+	}
+
+	return r.Pattern
+}
+
+// MetaFromContext returns the metadata of the route serving the request,
+// or nil if the route has none. It is available to the route's
+// middleware and handler, but not to outer middleware, which runs before
+// a route is matched. The returned M must not be modified.
+//
+// Example:
+//
+//	func requireRole(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        role, _ := hmux.MetaFromContext(r.Context())["auth"].(string)
+//	        ...
+//	    })
+//	}
+func MetaFromContext(ctx context.Context) M {
+	rt, ok := ctx.Value(routeKey).(*route)
+	if !ok {
+		return nil
+	}
+
+	return rt.meta
+}
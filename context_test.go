@@ -0,0 +1,89 @@
+package hmux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	ctx := context.Background()
+	if id := RequestIDFromContext(ctx); id != "" {
+		t.Errorf("expected empty request ID, got %q", id)
+	}
+
+	ctx = ContextWithRequestID(ctx, "abc123")
+	if id := RequestIDFromContext(ctx); id != "abc123" {
+		t.Errorf("expected %q, got %q", "abc123", id)
+	}
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	ctx := context.Background()
+	if c := ClaimsFromContext(ctx); c != nil {
+		t.Errorf("expected nil claims, got %v", c)
+	}
+
+	ctx = ContextWithClaims(ctx, Claims{"sub": "user-1", "n": 1.0})
+	c := ClaimsFromContext(ctx)
+	if c.Subject() != "user-1" {
+		t.Errorf("expected subject %q, got %q", "user-1", c.Subject())
+	}
+	if c.String("n") != "" {
+		t.Errorf("expected empty string for non-string claim, got %q", c.String("n"))
+	}
+}
+
+func TestClaims_Scopes(t *testing.T) {
+	c := Claims{"scope": "read  write"}
+	if got := c.Scopes(); !slices.Equal(got, []string{"read", "write"}) {
+		t.Errorf("expected [read write], got %v", got)
+	}
+	if !c.HasScope("write") || c.HasScope("admin") {
+		t.Errorf("expected write granted and admin not, got %v", c.Scopes())
+	}
+	if got := (Claims{}).Scopes(); len(got) != 0 {
+		t.Errorf("expected no scopes, got %v", got)
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	var inner, outer string
+	m := New()
+	m.UseOuter(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			outer = RoutePattern(r)
+		})
+	})
+	api := m.Group("/api/v1")
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inner = RoutePattern(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+	api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path    string
+		pattern string
+	}{
+		{"/api/v1/users/7", "GET /api/v1/users/{id}"},
+		{"/missing", ""},
+	}
+
+	for _, tt := range tests {
+		inner, outer = "", ""
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if inner != tt.pattern {
+			t.Errorf("%s: expected inner pattern %q, got %q", tt.path, tt.pattern, inner)
+		}
+		if outer != tt.pattern {
+			t.Errorf("%s: expected outer pattern %q, got %q", tt.path, tt.pattern, outer)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Error is an error carrying the HTTP status code it should be reported
+// with. Errors that do not wrap an *Error are reported as 500 Internal
+// Server Error.
+type Error struct {
+	Code int   // HTTP status code
+	Err  error // underlying error, may be nil
+}
+
+// NewError returns an *Error with the given status code wrapping err.
+func NewError(code int, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf returns an *Error with the given status code wrapping an error
+// formatted as by fmt.Errorf.
+func Errorf(code int, format string, args ...any) *Error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Error returns the message of the underlying error, or the status text
+// if there is none.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status code of the first *Error in err's chain,
+// or 500 if there is none.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// ErrorHandlerFunc renders an error that occurred while serving a route.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// DefaultErrorHandler is the error handler used when none is configured.
+// It responds with the status code reported by StatusCode. The error
+// message is sent as plain text for 4xx errors; for 5xx errors only the
+// status text is sent, so internal details are not leaked to clients.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	code := StatusCode(err)
+
+	msg := http.StatusText(code)
+	if code < http.StatusInternalServerError {
+		msg = err.Error()
+	}
+
+	http.Error(w, msg, code)
+}
+
+// ErrorHandler sets the central error handler of the Mux. It renders
+// errors raised by hmux helpers while a route is served, such as a
+// MustParamInt call on a malformed path parameter, and applies to routes
+// registered before and after the call.
+//
+// ErrorHandler panics if fn is nil.
+func (m *Mux) ErrorHandler(fn ErrorHandlerFunc) {
+	if fn == nil {
+		panic("hmux: nil error handler")
+	}
+
+	m.errorHandler = fn
+}
+
+// ErrorHandler sets the error handler for the routes of the group and of
+// groups nested in it, overriding the handler of the Mux or enclosing
+// group. This lets HTML pages and JSON APIs on the same Mux render
+// errors differently:
+//
+//	api := mux.Group("/api").(hmux.ExtendedRouter)
+//	api.ErrorHandler(jsonErrors)
+//
+// Like Mux.ErrorHandler, it applies to routes registered before and
+// after the call.
+//
+// ErrorHandler panics if fn is nil.
+func (g *Group) ErrorHandler(fn ErrorHandlerFunc) {
+	if fn == nil {
+		panic("hmux: nil error handler")
+	}
+
+	g.errorHandler = fn
+}
+
+// errorHandlerFunc returns the error handler in effect for the group.
+func (g *Group) errorHandlerFunc() ErrorHandlerFunc {
+	if g.errorHandler != nil {
+		return g.errorHandler
+	}
+	if g.outerErrors != nil {
+		return g.outerErrors()
+	}
+
+	return g.mux.errorHandlerFunc()
+}
+
+// errorHandlerFunc returns the error handler in effect for the Mux.
+func (m *Mux) errorHandlerFunc() ErrorHandlerFunc {
+	for ; m != nil; m = m.parent {
+		if m.errorHandler != nil {
+			return m.errorHandler
+		}
+	}
+
+	return DefaultErrorHandler
+}
+
+// abort carries an error from a Must helper to the route's error boundary.
+type abort struct {
+	err error
+}
+
+// raise aborts the current route with err. The panic is recovered by the
+// error boundary hmux installs around every registered handler.
+func raise(err error) {
+	panic(abort{err})
+}
+
+// errorBoundary wraps a registered handler so that errors raised with
+// raise are rendered by the error handler returned by errorHandler.
+// Other panics propagate unchanged.
+func errorBoundary(h http.Handler, errorHandler func() ErrorHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				a, ok := v.(abort)
+				if !ok {
+					panic(v)
+				}
+
+				errorHandler()(w, r, a.err)
+			}
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// PanicHandlerFunc handles a panic recovered while serving a route. It
+// receives the value passed to panic and the stack trace of the
+// panicking goroutine.
+type PanicHandlerFunc func(w http.ResponseWriter, r *http.Request, recovered any, stack []byte)
+
+// PanicHandler sets a handler for panics in the routes of the Mux,
+// including panics raised by their middleware, so applications render
+// and report crashes in one place without writing recovery middleware:
+//
+//	mux.PanicHandler(func(w http.ResponseWriter, r *http.Request, recovered any, stack []byte) {
+//	    log.Printf("panic serving %s: %v\n%s", hmux.RoutePattern(r), recovered, stack)
+//	    http.Error(w, "internal error", http.StatusInternalServerError)
+//	})
+//
+// Without a panic handler, panics propagate to net/http, which logs them
+// and closes the connection. Panics with http.ErrAbortHandler always
+// propagate. Like ErrorHandler, PanicHandler applies to routes
+// registered before and after the call, and to Host and Version routers.
+//
+// PanicHandler panics if fn is nil.
+func (m *Mux) PanicHandler(fn PanicHandlerFunc) {
+	if fn == nil {
+		panic("hmux: nil panic handler")
+	}
+
+	m.panicHandler = fn
+}
+
+// panicHandlerFunc returns the panic handler in effect for the Mux, or
+// nil if there is none.
+func (m *Mux) panicHandlerFunc() PanicHandlerFunc {
+	for ; m != nil; m = m.parent {
+		if m.panicHandler != nil {
+			return m.panicHandler
+		}
+	}
+
+	return nil
+}
+
+// recoverPanics wraps a route so that panics are passed to the handler
+// returned by panicHandler. Panics propagate if it returns nil.
+func recoverPanics(h http.Handler, panicHandler func() PanicHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			fn := panicHandler()
+			if fn == nil {
+				panic(v)
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			fn(w, r, v, debug.Stack())
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
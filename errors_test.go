@@ -0,0 +1,231 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"plain", errors.New("boom"), http.StatusInternalServerError},
+		{"error", NewError(http.StatusNotFound, nil), http.StatusNotFound},
+		{"wrapped", fmt.Errorf("lookup: %w", Errorf(http.StatusConflict, "taken")), http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusCode(tt.err); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"client error", Errorf(http.StatusBadRequest, "bad id"), http.StatusBadRequest, "bad id\n"},
+		{"server error", errors.New("db password is hunter2"), http.StatusInternalServerError, "Internal Server Error\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			DefaultErrorHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMux_ErrorHandler(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		raise(Errorf(http.StatusTeapot, "short and stout"))
+	})
+	m.Host("{tenant}.example.com").HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		raise(Errorf(http.StatusTeapot, "tenant"))
+	})
+	m.ErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(StatusCode(err))
+		w.Write([]byte("custom: " + err.Error()))
+	})
+
+	tests := []struct {
+		url  string
+		body string
+	}{
+		{"/fail", "custom: short and stout"},
+		{"http://a.example.com/fail", "custom: tenant"},
+	}
+
+	for _, tt := range tests {
+		record = nil
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+		if rec.Code != http.StatusTeapot || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected 418 %q, got %d %q", tt.url, tt.body, rec.Code, rec.Body.String())
+		}
+		if len(record) != 2 {
+			t.Errorf("%s: expected middleware to complete, got %v", tt.url, record)
+		}
+	}
+}
+
+func TestErrorBoundary_OtherPanicsPropagate(t *testing.T) {
+	m := New()
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("expected panic %q, got %v", "boom", v)
+		}
+	}()
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestGroup_ErrorHandler(t *testing.T) {
+	render := func(name string) ErrorHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, name+": "+err.Error(), StatusCode(err))
+		}
+	}
+	fail := func(w http.ResponseWriter, r *http.Request) {
+		MustParamInt(r, "id")
+	}
+
+	m := New()
+	m.ErrorHandler(render("html"))
+	m.HandleFunc("GET /pages/{id}", fail)
+
+	api := m.Group("/api").(ExtendedRouter)
+	api.HandleFunc("GET /users/{id}", fail) // registered before ErrorHandler
+	api.ErrorHandler(render("json"))
+
+	admin := api.Group("/admin")
+	admin.HandleFunc("GET /items/{id}", fail)
+
+	legacy := api.Group("/legacy").(ExtendedRouter)
+	legacy.ErrorHandler(render("xml"))
+	legacy.HandleFunc("GET /items/{id}", fail)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/pages/x", "html: "},
+		{"/api/users/x", "json: "},
+		{"/api/admin/items/x", "json: "},
+		{"/api/legacy/items/x", "xml: "},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Body.String(), tt.body) {
+			t.Errorf("%s: expected 400 %q..., got %d %q", tt.path, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMux_PanicHandler(t *testing.T) {
+	var (
+		recovered any
+		stack     []byte
+		pattern   string
+	)
+
+	m := New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("mw") {
+				panic("middleware boom")
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	m.HandleFunc("GET /crash", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	m.PanicHandler(func(w http.ResponseWriter, r *http.Request, v any, s []byte) {
+		recovered, stack, pattern = v, s, RoutePattern(r)
+		http.Error(w, "crashed", http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		target    string
+		recovered any
+	}{
+		{"/crash", "boom"},
+		{"/crash?mw", "middleware boom"},
+	}
+
+	for _, tt := range tests {
+		recovered, stack, pattern = nil, nil, ""
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != http.StatusInternalServerError || rec.Body.String() != "crashed\n" {
+			t.Errorf("%s: expected 500 crashed, got %d %q", tt.target, rec.Code, rec.Body.String())
+		}
+		if recovered != tt.recovered {
+			t.Errorf("%s: expected %v, got %v", tt.target, tt.recovered, recovered)
+		}
+		if !strings.Contains(string(stack), "errors_test.go") {
+			t.Errorf("%s: expected stack trace of the panic, got %s", tt.target, stack)
+		}
+		if pattern != "GET /crash" {
+			t.Errorf("%s: expected pattern GET /crash, got %q", tt.target, pattern)
+		}
+	}
+}
+
+func TestMux_PanicHandler_Propagates(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler PanicHandlerFunc
+		value   any
+	}{
+		{"no handler", nil, "boom"},
+		{"abort handler", func(http.ResponseWriter, *http.Request, any, []byte) {}, http.ErrAbortHandler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			if tt.handler != nil {
+				m.PanicHandler(tt.handler)
+			}
+			m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.value)
+			})
+
+			defer func() {
+				if v := recover(); v != tt.value {
+					t.Errorf("expected panic %v, got %v", tt.value, v)
+				}
+			}()
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package expvarhmux serves the variables published with expvar together
+// with Go runtime statistics as JSON on an hmux Router. It is a separate
+// package because importing expvar registers /debug/vars on
+// http.DefaultServeMux as a side effect.
+//
+//	expvarhmux.Mount(mux, "/debug/vars", middleware.AllowIP("127.0.0.1", "10.0.0.0/8"))
+package expvarhmux
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Mount registers Handler for GET requests to path on r, wrapped with mw,
+// which typically restricts access to operators.
+//
+// Mount panics if path does not start with "/".
+func Mount(r hmux.Router, path string, mw ...func(http.Handler) http.Handler) {
+	if !strings.HasPrefix(path, "/") {
+		panic("hmux: expvar path must start with /")
+	}
+
+	r.With(mw...).Handle("GET "+path, Handler())
+}
+
+// Handler returns a handler responding with a JSON object holding every
+// variable published with expvar, including the "cmdline" and "memstats"
+// variables expvar publishes itself, and a "runtime" object with current
+// Go runtime statistics:
+//
+//	{
+//	  "cmdline": ["/app"],
+//	  "memstats": {...},
+//	  "requests": 1042,
+//	  "runtime": {"goroutines": 12, "gomaxprocs": 8, "num_gc": 31, ...}
+//	}
+//
+// A variable published as "runtime" is replaced by the statistics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := make(map[string]json.RawMessage)
+		expvar.Do(func(kv expvar.KeyValue) {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		})
+
+		stats, err := json.Marshal(readStats())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		vars["runtime"] = stats
+
+		data, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(append(data, '\n'))
+	})
+}
+
+// stats is the "runtime" object of Handler.
+type stats struct {
+	GoVersion    string     `json:"go_version"`
+	Goroutines   int        `json:"goroutines"`
+	GOMAXPROCS   int        `json:"gomaxprocs"`
+	NumCPU       int        `json:"num_cpu"`
+	HeapAlloc    uint64     `json:"heap_alloc_bytes"`
+	HeapSys      uint64     `json:"heap_sys_bytes"`
+	HeapObjects  uint64     `json:"heap_objects"`
+	Sys          uint64     `json:"sys_bytes"`
+	NumGC        uint32     `json:"num_gc"`
+	PauseTotalNs uint64     `json:"gc_pause_total_ns"`
+	LastGC       *time.Time `json:"last_gc,omitempty"`
+}
+
+// readStats returns the current runtime statistics.
+func readStats() stats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s := stats{
+		GoVersion:    runtime.Version(),
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapSys:      m.HeapSys,
+		HeapObjects:  m.HeapObjects,
+		Sys:          m.Sys,
+		NumGC:        m.NumGC,
+		PauseTotalNs: m.PauseTotalNs,
+	}
+	if m.LastGC > 0 {
+		last := time.Unix(0, int64(m.LastGC)).UTC()
+		s.LastGC = &last
+	}
+
+	return s
+}
@@ -0,0 +1,53 @@
+package expvarhmux
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+	"github.com/nikita-shtimenko/hmux/middleware"
+)
+
+func TestMount(t *testing.T) {
+	expvar.NewInt("expvarhmux_test_requests").Set(42)
+
+	m := hmux.New()
+	Mount(m, "/debug/vars", middleware.AllowIP("127.0.0.1"))
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Requests int            `json:"expvarhmux_test_requests"`
+		Memstats map[string]any `json:"memstats"`
+		Runtime  map[string]any `json:"runtime"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Requests != 42 {
+		t.Errorf("expected published variable 42, got %d", body.Requests)
+	}
+	if body.Memstats == nil {
+		t.Error("expected memstats")
+	}
+	if n, _ := body.Runtime["goroutines"].(float64); n < 1 {
+		t.Errorf("expected runtime statistics, got %v", body.Runtime)
+	}
+
+	req.RemoteAddr = "203.0.113.5:1234"
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rec.Code)
+	}
+}
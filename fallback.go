@@ -0,0 +1,155 @@
+package hmux
+
+import (
+	"bufio"
+	"maps"
+	"net"
+	"net/http"
+)
+
+// Fallback returns a handler that tries handlers in order until one
+// claims the request, for setups such as serving static files and
+// proxying everything else to a legacy application:
+//
+//	mux.Handle("/", hmux.Fallback(
+//	    http.FileServerFS(public), // claims the files it finds
+//	    legacyProxy,               // everything else
+//	))
+//
+// A handler declines a request by responding with 404 Not Found, or by
+// calling Decline, and the next handler is tried. Anything else it
+// writes, including returning without writing, claims the request.
+// Headers set and bodies written by a declining handler are discarded.
+// The last handler is served as usual, so its 404 reaches the client.
+//
+// Handlers that may decline must not read the request body, as the next
+// handler needs it.
+//
+// Fallback panics if no handler is given or any is nil.
+func Fallback(handlers ...http.Handler) http.Handler {
+	if len(handlers) == 0 {
+		panic("hmux: Fallback needs at least one handler")
+	}
+	for _, h := range handlers {
+		if h == nil {
+			panic("hmux: nil handler passed to Fallback")
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last := len(handlers) - 1
+		for _, h := range handlers[:last] {
+			fw := &fallbackWriter{w: w, header: w.Header().Clone()}
+			h.ServeHTTP(fw, r)
+
+			if !fw.declined {
+				fw.claim(0)
+				return
+			}
+		}
+
+		handlers[last].ServeHTTP(w, r)
+	})
+}
+
+// Decline makes the handler trying r inside Fallback pass the request
+// on to the next handler. Outside Fallback, and in its last handler, it
+// responds with 404 Not Found. The handler must not write to w after
+// calling Decline.
+func Decline(w http.ResponseWriter, r *http.Request) {
+	for u := w; u != nil; {
+		if fw, ok := u.(*fallbackWriter); ok && !fw.claimed {
+			fw.declined = true
+			return
+		}
+
+		unwrapper, ok := u.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		u = unwrapper.Unwrap()
+	}
+
+	http.NotFound(w, r)
+}
+
+// fallbackWriter holds back the response of a handler tried by Fallback
+// until the handler claims or declines the request.
+type fallbackWriter struct {
+	w        http.ResponseWriter
+	header   http.Header
+	claimed  bool
+	declined bool
+}
+
+func (fw *fallbackWriter) Header() http.Header {
+	if fw.claimed {
+		return fw.w.Header()
+	}
+
+	return fw.header
+}
+
+// WriteHeader declines the request on 404 Not Found and claims it on any
+// other status.
+func (fw *fallbackWriter) WriteHeader(status int) {
+	switch {
+	case fw.claimed:
+		fw.w.WriteHeader(status)
+	case fw.declined:
+	case status == http.StatusNotFound:
+		fw.declined = true
+	default:
+		fw.claim(status)
+	}
+}
+
+func (fw *fallbackWriter) Write(p []byte) (int, error) {
+	if fw.declined {
+		return len(p), nil
+	}
+	if !fw.claimed {
+		fw.claim(http.StatusOK)
+	}
+
+	return fw.w.Write(p)
+}
+
+// Flush claims the request and flushes the underlying writer.
+func (fw *fallbackWriter) Flush() {
+	if fw.declined {
+		return
+	}
+	fw.claim(http.StatusOK)
+
+	_ = http.NewResponseController(fw.w).Flush()
+}
+
+// Hijack claims the request and lets the caller take over the
+// connection.
+func (fw *fallbackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	fw.claimed = true
+	return http.NewResponseController(fw.w).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (fw *fallbackWriter) Unwrap() http.ResponseWriter {
+	return fw.w
+}
+
+// claim copies the held headers to the underlying writer and writes
+// status, unless it is 0 or the request is already claimed.
+func (fw *fallbackWriter) claim(status int) {
+	if fw.claimed {
+		return
+	}
+	fw.claimed = true
+
+	h := fw.w.Header()
+	clear(h)
+	maps.Copy(h, fw.header)
+	if status != 0 {
+		fw.w.WriteHeader(status)
+	}
+}
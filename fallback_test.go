@@ -0,0 +1,78 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFallback(t *testing.T) {
+	static := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Source", "static")
+		if r.URL.Path != "/app.js" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("static"))
+	})
+	shim := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Source", "shim")
+		if r.URL.Path != "/migrated" {
+			Decline(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	legacy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("legacy"))
+	})
+
+	m := New()
+	var record []string
+	m.Use(recordingMiddleware("mw", &record))
+	m.Handle("/", Fallback(static, throughStatusWriter(shim), legacy))
+
+	tests := []struct {
+		path   string
+		code   int
+		body   string
+		source string
+	}{
+		{"/app.js", http.StatusOK, "static", "static"},
+		{"/migrated", http.StatusAccepted, "", "shim"},
+		{"/other", http.StatusOK, "legacy", ""},
+		{"/gone", http.StatusNotFound, "404 page not found\n", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Source"); got != tt.source {
+			t.Errorf("%s: expected X-Source %q, got %q", tt.path, tt.source, got)
+		}
+	}
+}
+
+func TestDecline_OutsideFallback(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Decline(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+// throughStatusWriter wraps h so that it writes through a
+// StatusWriter, as logging middleware would.
+func throughStatusWriter(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(NewStatusWriter(w), r)
+	})
+}
@@ -0,0 +1,47 @@
+package hmux
+
+import "net/http"
+
+// MatchFlag returns a Matcher accepting requests while enabled reports
+// true, so a route can be switched on and off at runtime, for example by
+// a feature flag, without registering it again:
+//
+//	mux.Matching(hmux.MatchFlag(flags.NewSearch)).HandleFunc("GET /search", newSearch)
+//	mux.HandleFunc("GET /search", oldSearch) // while the flag is off
+//
+// enabled is called for every request the route's pattern matches, so it
+// must be cheap and safe for concurrent use.
+//
+// MatchFlag panics if enabled is nil.
+func MatchFlag(enabled func() bool) Matcher {
+	if enabled == nil {
+		panic("hmux: nil flag passed to MatchFlag")
+	}
+
+	return func(*http.Request) bool {
+		return enabled()
+	}
+}
+
+// Flagged returns a router whose routes only match while enabled reports
+// true, as with Matching(MatchFlag(enabled)), so a feature spanning
+// several routes is gated by a single flag:
+//
+//	beta := mux.Flagged(flags.Beta).Group("/beta")
+//	beta.HandleFunc("GET /dashboard", dashboard)
+//
+// While the flag is off, requests for its routes get 404 Not Found, or
+// are served by an unflagged route sharing the pattern. Groups created
+// from the router carry the flag too.
+//
+// Flagged panics if enabled is nil.
+func (m *Mux) Flagged(enabled func() bool) ExtendedRouter {
+	return m.Matching(MatchFlag(enabled))
+}
+
+// Flagged returns a router with the group's prefix and middleware whose
+// routes only match while enabled reports true, in addition to any flag
+// or matcher of the group. See Mux.Flagged.
+func (g *Group) Flagged(enabled func() bool) ExtendedRouter {
+	return g.Matching(MatchFlag(enabled))
+}
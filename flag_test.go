@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFlagged(t *testing.T) {
+	var beta, search atomic.Bool
+	text := func(s string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(s)) }
+	}
+
+	m := New()
+	g := m.Flagged(beta.Load).Group("/beta")
+	g.HandleFunc("GET /dashboard", text("dashboard"))
+	g.(*Group).Flagged(search.Load).HandleFunc("GET /search", text("beta search"))
+	m.Matching(MatchFlag(search.Load)).HandleFunc("GET /search", text("new search"))
+	m.HandleFunc("GET /search", text("old search"))
+
+	tests := []struct {
+		beta, search bool
+		path         string
+		code         int
+		body         string
+	}{
+		{false, false, "/beta/dashboard", http.StatusNotFound, ""},
+		{true, false, "/beta/dashboard", http.StatusOK, "dashboard"},
+		{true, false, "/beta/search", http.StatusNotFound, ""},
+		{false, true, "/beta/search", http.StatusNotFound, ""},
+		{true, true, "/beta/search", http.StatusOK, "beta search"},
+		{false, false, "/search", http.StatusOK, "old search"},
+		{false, true, "/search", http.StatusOK, "new search"},
+	}
+	for _, tt := range tests {
+		beta.Store(tt.beta)
+		search.Store(tt.search)
+
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.code {
+			t.Errorf("%s (beta=%v, search=%v): expected %d, got %d", tt.path, tt.beta, tt.search, tt.code, rec.Code)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s (beta=%v, search=%v): expected %q, got %q", tt.path, tt.beta, tt.search, tt.body, rec.Body.String())
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package hmux
 
 import (
+	"io/fs"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 )
 
@@ -11,12 +14,33 @@ import (
 // Mux or sibling groups.
 type Group struct {
 	mux        *Mux
+	host       string
 	prefix     string
-	middleware []func(http.Handler) http.Handler
+	middleware []layer
+	meta       M
+
+	// matchers constrain every route of the group, set by Matching.
+	matchers []Matcher
+
+	// strip is the number of leading path segments removed before
+	// handlers run, set by GroupStripped.
+	strip int
+
+	// errorHandler overrides the error handler for the group's routes;
+	// outerErrors resolves the handler of the enclosing group, if any.
+	errorHandler ErrorHandlerFunc
+	outerErrors  func() ErrorHandlerFunc
+
+	// inherit returns the parent's middleware stack in deferred mode,
+	// where middleware holds only the group's own middleware.
+	inherit func() []layer
 }
 
-// Verify Group implements Router interface.
-var _ Router = (*Group)(nil)
+// Verify Group implements the Router and ExtendedRouter interfaces.
+var (
+	_ Router         = (*Group)(nil)
+	_ ExtendedRouter = (*Group)(nil)
+)
 
 // Handle registers the handler for the given pattern on this group.
 // The final pattern is formed by joining the group's prefix with the
@@ -27,8 +51,18 @@ var _ Router = (*Group)(nil)
 // of "/api" and pattern "GET /users", the handler is registered at
 // "GET /api/users".
 func (g *Group) Handle(pattern string, handler http.Handler) {
-	fullPattern := joinPattern(g.prefix, pattern)
-	g.mux.mux.Handle(fullPattern, wrap(handler, g.middleware))
+	g.mux.checkStrict(pattern)
+	g.handle(pattern, handler)
+}
+
+// handle registers handler for pattern joined with the group's host and
+// prefix.
+func (g *Group) handle(pattern string, handler http.Handler) {
+	fullPattern := withHost(g.host, joinPattern(g.prefix, pattern))
+	if g.strip > 0 && handler != nil {
+		handler = stripSegments(g.strip, handler)
+	}
+	g.mux.handle(fullPattern, handler, g.stack, g.meta, g.errorHandlerFunc, g.matchers)
 }
 
 // HandleFunc registers the handler function for the given pattern on
@@ -39,9 +73,69 @@ func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc) {
 	g.Handle(pattern, handler)
 }
 
+// HandleAbsolute registers the handler for pattern as is, ignoring the
+// group's prefix, but with the group's middleware, metadata, error
+// handler and host. It lets a module mounted under a prefix register
+// routes that must live at fixed paths:
+//
+//	billing := mux.Group("/billing").(*hmux.Group)
+//	billing.Use(auth)
+//	billing.HandleAbsolute("GET /.well-known/billing.json", manifest) // not /billing/.well-known/...
+//
+// Paths are not stripped for handlers registered on a group created with
+// GroupStripped.
+func (g *Group) HandleAbsolute(pattern string, handler http.Handler) {
+	g.mux.checkStrict(pattern)
+	g.mux.handle(withHost(g.host, pattern), handler, g.stack, g.meta, g.errorHandlerFunc, g.matchers)
+}
+
+// TryHandle is like Handle but returns a *RouteError instead of
+// panicking if the route cannot be registered. See Mux.TryHandle.
+func (g *Group) TryHandle(pattern string, handler http.Handler) error {
+	return tryRegister(pattern, func() { g.Handle(pattern, handler) })
+}
+
+// TryHandleFunc is like HandleFunc but returns an error instead of
+// panicking. See Mux.TryHandle.
+func (g *Group) TryHandleFunc(pattern string, handler http.HandlerFunc) error {
+	return g.TryHandle(pattern, handler)
+}
+
+// Static serves the files of fsys under the given path prefix, joined
+// with the group's prefix, and wraps the route with the group's
+// middleware. See Mux.Static.
+func (g *Group) Static(prefix string, fsys fs.FS, opts ...StaticOption) {
+	g.Handle(staticPattern(prefix), newStaticHandler(fsys, opts))
+}
+
+// NotFound registers a fallback handler for requests under the group's
+// prefix that match no more specific route. For a group with prefix
+// "/api" the handler is registered as the catch-all pattern "/api/" and
+// wrapped with the group's middleware:
+//
+//	api := mux.Group("/api").(hmux.ExtendedRouter)
+//	api.Use(jsonErrors)
+//	api.NotFound(jsonNotFound) // GET /api/missing → jsonNotFound
+//
+// As with any subtree pattern, a request for the bare prefix "/api" is
+// redirected to "/api/" unless it has a route of its own. Requests that
+// would otherwise get 405 Method Not Allowed are also sent to the
+// fallback. The handler is responsible for writing the status code.
+//
+// NotFound panics if handler is nil or if the catch-all pattern is
+// already registered.
+func (g *Group) NotFound(handler http.HandlerFunc) {
+	if handler == nil {
+		panic("hmux: nil handler passed to NotFound")
+	}
+
+	g.handle("/", handler)
+}
+
 // Use appends middleware to this group. Only handlers registered on this
-// group after this call will be wrapped with these middleware. Middleware
-// added here does not affect the parent Mux or sibling groups.
+// group after this call will be wrapped with these middleware, unless the
+// Mux was created with the Deferred option. Middleware added here does
+// not affect the parent Mux or sibling groups.
 //
 // If Use(A, B, C) is called, then for a subsequent handler H, requests
 // flow: A → B → C → H → C → B → A.
@@ -53,8 +147,9 @@ func (g *Group) Use(mw ...func(http.Handler) http.Handler) {
 			panic("hmux: nil middleware passed to Use")
 		}
 	}
+	g.mux.checkDeferredUse()
 
-	g.middleware = append(g.middleware, mw...)
+	g.middleware = append(g.middleware, layersOf(mw)...)
 }
 
 // Group creates a nested group with a concatenated prefix. The new group
@@ -70,16 +165,84 @@ func (g *Group) Group(prefix string) Router {
 		panic("hmux: group prefix must be empty or start with /")
 	}
 
-	mw := make([]func(http.Handler) http.Handler, len(g.middleware))
-	copy(mw, g.middleware)
+	if g.inherit != nil {
+		return &Group{
+			mux:      g.mux,
+			host:     g.host,
+			prefix:   joinPattern(g.prefix, prefix),
+			meta:     g.meta,
+			matchers: g.matchers,
+			strip:    g.strip,
+			inherit:  g.stack,
+
+			outerErrors: g.errorHandlerFunc,
+		}
+	}
 
 	return &Group{
 		mux:        g.mux,
+		host:       g.host,
 		prefix:     joinPattern(g.prefix, prefix),
-		middleware: mw,
+		middleware: slices.Clone(g.middleware),
+		meta:       g.meta,
+		matchers:   g.matchers,
+		strip:      g.strip,
+
+		outerErrors: g.errorHandlerFunc,
 	}
 }
 
+// GroupStripped creates a nested group like Group whose handlers see
+// request paths relative to the nested group's full prefix. See
+// Mux.GroupStripped.
+func (g *Group) GroupStripped(prefix string) ExtendedRouter {
+	newG := g.Group(prefix).(*Group)
+	newG.strip = countSegments(newG.prefix)
+
+	return newG
+}
+
+// countSegments returns the number of path segments in prefix.
+func countSegments(prefix string) int {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return 0
+	}
+
+	return strings.Count(prefix, "/") + 1
+}
+
+// stripSegments returns a handler that serves requests with the first n
+// segments removed from the URL path.
+func stripSegments(n int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = dropSegments(r.URL.Path, n)
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = dropSegments(r.URL.RawPath, n)
+		}
+
+		h.ServeHTTP(w, r2)
+	})
+}
+
+// dropSegments removes the first n segments of path. The result always
+// starts with "/".
+func dropSegments(path string, n int) string {
+	for ; n > 0; n-- {
+		i := strings.IndexByte(path[min(1, len(path)):], '/')
+		if i < 0 {
+			return "/"
+		}
+		path = path[i+1:]
+	}
+
+	return path
+}
+
 // With returns a new Router with the given middleware appended to
 // this group's middleware stack. The returned Router has the same
 // prefix as this group. This is useful for applying middleware to
@@ -95,3 +258,17 @@ func (g *Group) With(mw ...func(http.Handler) http.Handler) Router {
 
 	return newG
 }
+
+// stack returns the group's full middleware stack, including inherited
+// middleware.
+func (g *Group) stack() []layer {
+	if g.inherit == nil {
+		return g.middleware
+	}
+
+	parent := g.inherit()
+	mw := make([]layer, 0, len(parent)+len(g.middleware))
+	mw = append(mw, parent...)
+
+	return append(mw, g.middleware...)
+}
@@ -0,0 +1,238 @@
+// Package health serves liveness and readiness endpoints backed by named
+// dependency checks:
+//
+//	h := health.New(health.WithTimeout(2 * time.Second))
+//	h.Readiness("db", db.PingContext)
+//	h.Readiness("queue", func(ctx context.Context) error {
+//	    if depth := queue.Depth(); depth > 10000 {
+//	        return fmt.Errorf("queue depth %d", depth)
+//	    }
+//	    return nil
+//	})
+//	h.Mount(mux) // GET /livez and GET /readyz
+//
+// Checks run in parallel, each with the timeout, and the endpoints
+// respond with 200 OK if all pass or 503 Service Unavailable otherwise,
+// with a JSON report:
+//
+//	{
+//	  "status": "fail",
+//	  "checks": {
+//	    "db": {"status": "ok", "duration": "1.2ms"},
+//	    "queue": {"status": "fail", "duration": "40µs", "error": "queue depth 12000"}
+//	  }
+//	}
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// DefaultTimeout is the timeout of each check unless WithTimeout is
+// used.
+const DefaultTimeout = 5 * time.Second
+
+// ErrTimeout is reported for checks that do not finish within the
+// timeout.
+var ErrTimeout = errors.New("hmux: health check timed out")
+
+// Checker checks a dependency, returning an error if it is unhealthy. It
+// should return promptly once ctx is done.
+type Checker func(ctx context.Context) error
+
+// Option configures a Health.
+type Option func(*Health)
+
+// WithTimeout sets the time each check may take before it is reported as
+// failed with ErrTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+// Health holds the liveness and readiness checks of a service. Its
+// methods are safe for concurrent use.
+type Health struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  map[string]Checker
+	readiness map[string]Checker
+}
+
+// New returns a Health without checks, configured by the given options.
+func New(opts ...Option) *Health {
+	h := &Health{
+		timeout:   DefaultTimeout,
+		liveness:  make(map[string]Checker),
+		readiness: make(map[string]Checker),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Liveness registers a check reported by the liveness endpoint. Liveness
+// checks should only fail when the process cannot recover on its own,
+// such as a deadlock, because orchestrators restart services whose
+// liveness fails. Without liveness checks the endpoint always passes.
+//
+// Liveness panics if name is empty or already registered, or if check
+// is nil.
+func (h *Health) Liveness(name string, check Checker) {
+	h.register(h.liveness, name, check)
+}
+
+// Readiness registers a check reported by the readiness endpoint, such
+// as a database ping. Orchestrators stop routing traffic to services
+// whose readiness fails until it passes again.
+//
+// Readiness panics if name is empty or already registered, or if check
+// is nil.
+func (h *Health) Readiness(name string, check Checker) {
+	h.register(h.readiness, name, check)
+}
+
+func (h *Health) register(checks map[string]Checker, name string, check Checker) {
+	if name == "" || check == nil {
+		panic("hmux: empty name or nil check passed to health")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := checks[name]; ok {
+		panic("hmux: health check " + name + " already registered")
+	}
+	checks[name] = check
+}
+
+// Mount registers the liveness handler for GET /livez and the readiness
+// handler for GET /readyz on r.
+func (h *Health) Mount(r hmux.Router) {
+	r.Handle("GET /livez", h.LivenessHandler())
+	r.Handle("GET /readyz", h.ReadinessHandler())
+}
+
+// LivenessHandler returns a handler running the liveness checks.
+func (h *Health) LivenessHandler() http.Handler {
+	return h.handler(h.liveness)
+}
+
+// ReadinessHandler returns a handler running the readiness checks.
+func (h *Health) ReadinessHandler() http.Handler {
+	return h.handler(h.readiness)
+}
+
+// Report is the JSON response of the health endpoints.
+type Report struct {
+	Status string                 `json:"status"` // "ok" or "fail"
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status   string `json:"status"`   // "ok" or "fail"
+	Duration string `json:"duration"` // time the check took
+	Error    string `json:"error,omitempty"`
+}
+
+func (h *Health) handler(checks map[string]Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		snapshot := maps.Clone(checks)
+		h.mu.RUnlock()
+
+		report := h.run(r.Context(), snapshot)
+
+		code := http.StatusOK
+		if report.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// run runs checks in parallel and reports their results.
+func (h *Health) run(ctx context.Context, checks map[string]Checker) Report {
+	report := Report{Status: "ok"}
+	if len(checks) == 0 {
+		return report
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	report.Checks = make(map[string]CheckResult, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := h.check(ctx, check)
+			result := CheckResult{Status: "ok", Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// check runs check with the timeout. A check that ignores its context is
+// reported as timed out and left to finish in the background; one that
+// panics is reported as failed.
+func (h *Health) check(ctx context.Context, check Checker) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("hmux: health check panicked: %v", v)
+			}
+		}()
+
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrTimeout
+		}
+
+		return err
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
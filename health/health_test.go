@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestHealth(t *testing.T) {
+	h := New(WithTimeout(20 * time.Millisecond))
+	h.Readiness("db", func(ctx context.Context) error { return nil })
+	h.Readiness("queue", func(ctx context.Context) error { return errors.New("queue depth 12000") })
+	h.Readiness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h.Readiness("stuck", func(ctx context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	h.Readiness("broken", func(ctx context.Context) error { panic("boom") })
+
+	m := hmux.New()
+	h.Mount(m)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"status\":\"ok\"}\n" {
+		t.Errorf("expected passing liveness, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	start := time.Now()
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected checks to run in parallel with timeouts, took %v", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"db":     "",
+		"queue":  "queue depth 12000",
+		"slow":   ErrTimeout.Error(),
+		"stuck":  ErrTimeout.Error(),
+		"broken": "hmux: health check panicked: boom",
+	}
+	if report.Status != "fail" || len(report.Checks) != len(expected) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for name, msg := range expected {
+		if got := report.Checks[name].Error; got != msg {
+			t.Errorf("%s: expected error %q, got %q", name, msg, got)
+		}
+	}
+}
+
+func TestHealth_DuplicatePanics(t *testing.T) {
+	h := New()
+	h.Liveness("deadlock", func(ctx context.Context) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	h.Liveness("deadlock", func(ctx context.Context) error { return nil })
+}
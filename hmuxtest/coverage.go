@@ -0,0 +1,115 @@
+package hmuxtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// RouteCoverage records which routes of a Mux served requests.
+type RouteCoverage struct {
+	mux *hmux.Mux
+
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+// Coverage instruments m to record the route serving each request, so a
+// test suite can check that every endpoint of a large API is exercised.
+// It adds outer middleware to m, so it sees requests to all routes,
+// including those of Host and Version routers:
+//
+//	var coverage *hmuxtest.RouteCoverage
+//
+//	func TestMain(m *testing.M) {
+//	    mux = newMux()
+//	    coverage = hmuxtest.Coverage(mux)
+//	    code := m.Run()
+//	    coverage.Report(os.Stdout)
+//	    os.Exit(code)
+//	}
+//
+// Routes are told apart by pattern, so routes that share a pattern
+// through matchers, or on several hosts, are covered together.
+func Coverage(m *hmux.Mux) *RouteCoverage {
+	c := &RouteCoverage{mux: m, hits: make(map[string]int)}
+	m.UseOuter(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			if r.Pattern != "" {
+				c.mu.Lock()
+				c.hits[r.Pattern]++
+				c.mu.Unlock()
+			}
+		})
+	})
+
+	return c
+}
+
+// Hits returns the number of requests served by the routes registered
+// under pattern.
+func (c *RouteCoverage) Hits(pattern string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits[pattern]
+}
+
+// Uncovered returns the registered routes that served no request, in the
+// order reported by Mux.Routes.
+func (c *RouteCoverage) Uncovered() []hmux.RouteInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var uncovered []hmux.RouteInfo
+	for _, rt := range c.mux.Routes() {
+		if c.hits[rt.Pattern] == 0 {
+			uncovered = append(uncovered, rt)
+		}
+	}
+
+	return uncovered
+}
+
+// Expect reports an error listing the routes that served no request.
+func (c *RouteCoverage) Expect(t testing.TB) {
+	t.Helper()
+
+	if uncovered := c.Uncovered(); len(uncovered) > 0 {
+		t.Errorf("%d routes were not requested:\n%s", len(uncovered), describe(uncovered))
+	}
+}
+
+// Report writes the share of routes that served requests to w, followed
+// by the routes that did not.
+func (c *RouteCoverage) Report(w io.Writer) error {
+	total := len(c.mux.Routes())
+	uncovered := c.Uncovered()
+
+	if _, err := fmt.Fprintf(w, "route coverage: %d of %d routes requested\n", total-len(uncovered), total); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, describe(uncovered))
+
+	return err
+}
+
+// describe lists routes one per line, with their host if any.
+func describe(routes []hmux.RouteInfo) string {
+	var b strings.Builder
+	for _, rt := range routes {
+		b.WriteString("\t")
+		if rt.Host != "" {
+			b.WriteString("[" + rt.Host + "] ")
+		}
+		b.WriteString(rt.Pattern + "\n")
+	}
+
+	return b.String()
+}
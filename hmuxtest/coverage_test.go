@@ -0,0 +1,51 @@
+package hmuxtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestCoverage(t *testing.T) {
+	var tr Trace
+	m := hmux.New()
+	m.HandleFunc("GET /users", tr.Handler("list"))
+	m.HandleFunc("POST /users", tr.Handler("create"))
+	m.Host("admin.example.com").HandleFunc("GET /stats", tr.Handler("stats"))
+
+	coverage := Coverage(m)
+	c := New(t, m)
+	c.Get("/users")
+	c.Get("/users")
+	c.Get("/missing")
+	c.Get("/stats").ExpectStatus(http.StatusNotFound)
+
+	admin, _ := http.NewRequest(http.MethodGet, "http://admin.example.com/stats", nil)
+	c.Do(admin).ExpectStatus(http.StatusOK)
+
+	if n := coverage.Hits("GET /users"); n != 2 {
+		t.Errorf("expected 2 hits, got %d", n)
+	}
+
+	uncovered := coverage.Uncovered()
+	if len(uncovered) != 1 || uncovered[0].Pattern != "POST /users" {
+		t.Errorf("expected POST /users uncovered, got %v", uncovered)
+	}
+
+	rec := &recorder{}
+	coverage.Expect(rec)
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "POST /users") {
+		t.Errorf("expected a failure naming POST /users, got %q", rec.errors)
+	}
+
+	var b strings.Builder
+	if err := coverage.Report(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "route coverage: 2 of 3 routes requested\n\tPOST /users\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
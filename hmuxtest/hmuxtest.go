@@ -0,0 +1,294 @@
+// Package hmuxtest provides a fluent client for testing handlers built
+// with hmux, without the httptest boilerplate:
+//
+//	func TestUsers(t *testing.T) {
+//	    c := hmuxtest.New(t, newMux())
+//	    c.Get("/users/7").
+//	        ExpectStatus(http.StatusOK).
+//	        ExpectPattern("GET /users/{id}").
+//	        ExpectJSON(map[string]any{"id": 7, "name": "Ada"})
+//	}
+//
+// Requests are served in-process with httptest. Failed expectations are
+// reported with t.Errorf, so a chain reports every mismatch at once.
+//
+// A Trace records the order in which middleware and handlers run:
+//
+//	var tr hmuxtest.Trace
+//	mux.Use(tr.Middleware("auth"), tr.Middleware("log"))
+//	...
+//	tr.Expect(t, "auth", "log")
+package hmuxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Client sends requests to a handler and returns their responses for
+// inspection.
+type Client struct {
+	t      testing.TB
+	h      http.Handler
+	header http.Header
+}
+
+// New returns a Client serving requests with h and reporting failed
+// expectations to t.
+func New(t testing.TB, h http.Handler) *Client {
+	return &Client{t: t, h: h, header: make(http.Header)}
+}
+
+// Header sets a header sent with every subsequent request, such as an
+// Authorization header, and returns the Client.
+func (c *Client) Header(name, value string) *Client {
+	c.header.Set(name, value)
+	return c
+}
+
+// Get sends a GET request for target, a path with an optional query.
+func (c *Client) Get(target string) *Response {
+	return c.Request(http.MethodGet, target, nil)
+}
+
+// Post sends a POST request for target with the given body. See Request.
+func (c *Client) Post(target string, body any) *Response {
+	return c.Request(http.MethodPost, target, body)
+}
+
+// Put sends a PUT request for target with the given body. See Request.
+func (c *Client) Put(target string, body any) *Response {
+	return c.Request(http.MethodPut, target, body)
+}
+
+// Patch sends a PATCH request for target with the given body. See
+// Request.
+func (c *Client) Patch(target string, body any) *Response {
+	return c.Request(http.MethodPatch, target, body)
+}
+
+// Delete sends a DELETE request for target.
+func (c *Client) Delete(target string) *Response {
+	return c.Request(http.MethodDelete, target, nil)
+}
+
+// Request sends a request with the given method for target. A string,
+// []byte or io.Reader body is sent as is; any other non-nil body is
+// encoded as JSON and sent with Content-Type application/json.
+func (c *Client) Request(method, target string, body any) *Response {
+	c.t.Helper()
+
+	var r io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	case io.Reader:
+		r = b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.t.Fatalf("hmuxtest: encoding request body: %v", err)
+		}
+		r = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return c.Do(req)
+}
+
+// Do serves req, adding the Client's headers that req does not set.
+func (c *Client) Do(req *http.Request) *Response {
+	for name, values := range c.header {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.h.ServeHTTP(rec, req)
+
+	return &Response{ResponseRecorder: rec, Request: req, t: c.t}
+}
+
+// Response is the recorded response to a request sent by a Client.
+type Response struct {
+	*httptest.ResponseRecorder
+
+	// Request is the request as served. Its Pattern field holds the
+	// pattern of the matched route.
+	Request *http.Request
+
+	t testing.TB
+}
+
+// ExpectStatus reports an error if the status code is not code.
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.Helper()
+
+	if r.Code != code {
+		r.t.Errorf("%s: expected status %d, got %d", r.target(), code, r.Code)
+	}
+
+	return r
+}
+
+// ExpectHeader reports an error if the response header name does not
+// have the given value.
+func (r *Response) ExpectHeader(name, value string) *Response {
+	r.t.Helper()
+
+	if got := r.Header().Get(name); got != value {
+		r.t.Errorf("%s: expected header %s %q, got %q", r.target(), name, value, got)
+	}
+
+	return r
+}
+
+// ExpectBody reports an error if the body is not body.
+func (r *Response) ExpectBody(body string) *Response {
+	r.t.Helper()
+
+	if got := r.Body.String(); got != body {
+		r.t.Errorf("%s: expected body %q, got %q", r.target(), body, got)
+	}
+
+	return r
+}
+
+// ExpectJSON reports an error if the body is not JSON equal to v once
+// both are decoded, so formatting and key order do not matter. v may be
+// a value to encode or a JSON string or []byte.
+func (r *Response) ExpectJSON(v any) *Response {
+	r.t.Helper()
+
+	var expected []byte
+	switch b := v.(type) {
+	case string:
+		expected = []byte(b)
+	case []byte:
+		expected = b
+	default:
+		var err error
+		if expected, err = json.Marshal(v); err != nil {
+			r.t.Fatalf("hmuxtest: encoding expected JSON: %v", err)
+		}
+	}
+
+	var want, got any
+	if err := json.Unmarshal(expected, &want); err != nil {
+		r.t.Fatalf("hmuxtest: decoding expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(r.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("%s: expected JSON body, got %q: %v", r.target(), r.Body.String(), err)
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("%s: expected JSON %s, got %s", r.target(), expected, bytes.TrimSpace(r.Body.Bytes()))
+	}
+
+	return r
+}
+
+// ExpectPattern reports an error if the request was not served by the
+// route registered under pattern, including any group prefix. An empty
+// pattern expects that no route matched.
+func (r *Response) ExpectPattern(pattern string) *Response {
+	r.t.Helper()
+
+	if r.Request.Pattern != pattern {
+		r.t.Errorf("%s: expected route %q, got %q", r.target(), pattern, r.Request.Pattern)
+	}
+
+	return r
+}
+
+// DecodeJSON decodes the body into v, failing the test if it cannot.
+func (r *Response) DecodeJSON(v any) {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("%s: decoding JSON body: %v", r.target(), err)
+	}
+}
+
+// target describes the request in failure messages.
+func (r *Response) target() string {
+	return r.Request.Method + " " + r.Request.URL.RequestURI()
+}
+
+// Trace records the order in which middleware and handlers run. The zero
+// value is ready to use, and a Trace is safe for concurrent use.
+type Trace struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// Middleware returns middleware that records name when it runs.
+func (tr *Trace) Middleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tr.record(name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handler returns a handler that records name and responds with 200 OK.
+func (tr *Trace) Handler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tr.record(name)
+	}
+}
+
+// Names returns the names recorded since the Trace was created or last
+// reset.
+func (tr *Trace) Names() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	return slices.Clone(tr.names)
+}
+
+// Reset clears the recorded names.
+func (tr *Trace) Reset() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.names = nil
+}
+
+// Expect reports an error if the recorded names are not names, in order,
+// and resets the Trace for the next request.
+func (tr *Trace) Expect(t testing.TB, names ...string) {
+	t.Helper()
+
+	if got := tr.Names(); !slices.Equal(got, names) {
+		t.Errorf("expected %v to run, got %v", names, got)
+	}
+	tr.Reset()
+}
+
+func (tr *Trace) record(name string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.names = append(tr.names, name)
+}
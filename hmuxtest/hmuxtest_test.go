@@ -0,0 +1,80 @@
+package hmuxtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// recorder captures the failures reported by a Client.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func newMux(tr *Trace) *hmux.Mux {
+	m := hmux.New()
+	m.Use(tr.Middleware("log"))
+	api := m.Group("/api")
+	api.Use(tr.Middleware("auth"))
+	api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name": "Ada", "id": %s, "token": %q}`, r.PathValue("id"), r.Header.Get("Authorization"))
+	})
+	api.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		io.Copy(w, r.Body)
+	})
+
+	return m
+}
+
+func TestClient(t *testing.T) {
+	var tr Trace
+	c := New(t, newMux(&tr)).Header("Authorization", "secret")
+
+	c.Get("/api/users/7").
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "application/json").
+		ExpectPattern("GET /api/users/{id}").
+		ExpectJSON(map[string]any{"id": 7, "name": "Ada", "token": "secret"})
+	tr.Expect(t, "log", "auth")
+
+	c.Post("/api/echo", map[string]int{"n": 1}).
+		ExpectHeader("Content-Type", "application/json").
+		ExpectJSON(`{"n": 1}`)
+	c.Post("/api/echo", "plain").ExpectBody("plain")
+	tr.Reset()
+
+	c.Get("/missing").ExpectStatus(http.StatusNotFound).ExpectPattern("")
+	tr.Expect(t)
+}
+
+func TestClient_Failures(t *testing.T) {
+	var tr Trace
+	rec := &recorder{}
+	New(rec, newMux(&tr)).Get("/api/users/7").
+		ExpectStatus(http.StatusCreated).
+		ExpectHeader("Content-Type", "text/plain").
+		ExpectPattern("GET /users/{id}").
+		ExpectJSON(map[string]any{"id": 8}).
+		ExpectBody("")
+	tr.Expect(rec, "auth", "log")
+
+	if len(rec.errors) != 6 {
+		t.Errorf("expected 6 failures, got %d: %q", len(rec.errors), rec.errors)
+	}
+}
@@ -0,0 +1,142 @@
+package hmuxtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/nikita-shtimenko/hmux/middleware"
+)
+
+// LoadRecordings decodes the exchanges written by middleware.Recorder
+// from r, one JSON object per line. Blank lines are skipped.
+func LoadRecordings(r io.Reader) ([]middleware.Exchange, error) {
+	var exchanges []middleware.Exchange
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var e middleware.Exchange
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("hmuxtest: recording line %d: %w", line, err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("hmuxtest: reading recordings: %w", err)
+	}
+
+	return exchanges, nil
+}
+
+// Replay sends the recorded requests and reports an error for each
+// response whose status, Content-Type or body differs from the recorded
+// one, turning recorded traffic into a regression test:
+//
+//	f, _ := os.Open("testdata/recordings.jsonl")
+//	exchanges, err := hmuxtest.LoadRecordings(f)
+//	...
+//	hmuxtest.New(t, newMux()).Header("Authorization", "Bearer test").Replay(exchanges)
+//
+// JSON bodies are compared as by ExpectJSON. Values recorded as
+// middleware.Redacted match any value in responses, and headers recorded
+// as such are not sent, so the Client's headers, such as test
+// credentials, stand in for them. Requests are sent in order.
+func (c *Client) Replay(exchanges []middleware.Exchange) {
+	c.t.Helper()
+
+	for _, e := range exchanges {
+		req := httptest.NewRequest(e.Method, e.URL, bytes.NewReader(e.Body))
+		if e.Host != "" {
+			req.Host = e.Host
+		}
+		for name, values := range e.Header {
+			for _, v := range values {
+				if v != middleware.Redacted {
+					req.Header.Add(name, v)
+				}
+			}
+		}
+
+		res := c.Do(req).ExpectStatus(e.Response.Status)
+
+		contentType := e.Response.Header.Get("Content-Type")
+		if contentType != "" {
+			res.ExpectHeader("Content-Type", contentType)
+		}
+		if !bodyMatches(contentType, e.Response.Body, res.Body.Bytes()) {
+			c.t.Errorf("%s: expected body %q, got %q", res.target(), e.Response.Body, res.Body.String())
+		}
+	}
+}
+
+// bodyMatches reports whether got matches the recorded body want, where
+// values recorded as middleware.Redacted match anything. JSON bodies are
+// compared once decoded.
+func bodyMatches(contentType string, want, got []byte) bool {
+	if isJSON(contentType) && len(want) > 0 {
+		var w, g any
+		if json.Unmarshal(want, &w) == nil && json.Unmarshal(got, &g) == nil {
+			return jsonMatches(w, g)
+		}
+	}
+
+	parts := strings.Split(string(want), middleware.Redacted)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.MustCompile("(?s)^" + strings.Join(parts, ".*?") + "$").Match(got)
+}
+
+// jsonMatches reports whether the decoded JSON value got equals want,
+// where strings in want equal to middleware.Redacted match any value.
+func jsonMatches(want, got any) bool {
+	switch w := want.(type) {
+	case string:
+		if w == middleware.Redacted {
+			return true
+		}
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for k, v := range w {
+			if gv, ok := g[k]; !ok || !jsonMatches(v, gv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !jsonMatches(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(want, got)
+}
+
+// isJSON reports whether contentType is a JSON media type.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,61 @@
+package hmuxtest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+	"github.com/nikita-shtimenko/hmux/middleware"
+)
+
+func TestReplay(t *testing.T) {
+	var (
+		tr  Trace
+		buf bytes.Buffer
+	)
+	recording := hmux.New()
+	recording.Use(middleware.NewRecorder(&buf, 1<<10).Handler)
+	recording.Handle("/", newMux(&tr))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/users/7", nil),
+		httptest.NewRequest(http.MethodPost, "/api/echo", strings.NewReader("hello")),
+		httptest.NewRequest(http.MethodGet, "/missing", nil),
+	} {
+		req.Header.Set("Authorization", "prod-token")
+		recording.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	exchanges, err := LoadRecordings(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exchanges) != 3 {
+		t.Fatalf("expected 3 exchanges, got %d", len(exchanges))
+	}
+	if got := exchanges[0].Header.Get("Authorization"); got != middleware.Redacted {
+		t.Errorf("expected the Authorization header to be redacted, got %q", got)
+	}
+
+	New(t, newMux(&tr)).Header("Authorization", "prod-token").Replay(exchanges)
+
+	rec := &recorder{TB: t}
+	changed := hmux.New()
+	changed.HandleFunc("GET /api/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "Grace"}`))
+	})
+	New(rec, changed).Replay(exchanges)
+
+	// The user body differs and the echo route is gone.
+	if len(rec.errors) != 3 {
+		t.Errorf("expected 3 failures, got %q", rec.errors)
+	}
+
+	if _, err := LoadRecordings(strings.NewReader("{\n")); err == nil {
+		t.Error("expected an error for a malformed recording")
+	}
+}
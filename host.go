@@ -0,0 +1,156 @@
+package hmux
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostRoute is a host pattern with the Mux serving the requests it matches.
+type hostRoute struct {
+	pattern string
+	labels  []string
+	mux     *Mux
+}
+
+// Host returns a router whose routes only serve requests whose host
+// matches pattern. The pattern is a dot-separated host name in which a
+// label of the form "{name}" matches any single label, for example
+// "{tenant}.example.com". Handlers read matched labels with
+// HostParamFromContext:
+//
+//	tenants := mux.Host("{tenant}.example.com")
+//	tenants.HandleFunc("GET /dashboard", dashboard)
+//
+// Host matching runs before the regular routes: a request whose host
+// matches a pattern is dispatched to that router's routes only, and gets
+// a 404 or 405 from them if none matches its path. Patterns are tried in
+// registration order and compared case-insensitively, ignoring any port.
+// Requests for other hosts fall through to the routes registered on the
+// Mux. The router inherits a copy of the Mux's current middleware, like
+// Group.
+//
+// Host panics if pattern is empty, contains "/" or an empty label, has an
+// invalid or repeated wildcard, or is already registered.
+func (m *Mux) Host(pattern string) ExtendedRouter {
+	labels := parseHostPattern(pattern)
+
+	for _, hr := range m.hosts {
+		if hr.pattern == pattern {
+			panic("hmux: host " + pattern + " already registered")
+		}
+	}
+
+	child := m.newChild()
+	m.hosts = append(m.hosts, &hostRoute{pattern: pattern, labels: labels, mux: child})
+
+	g := m.Group("").(*Group)
+	g.mux = child
+
+	return g
+}
+
+// parseHostPattern splits a host pattern into lowercase labels, keeping
+// wildcard labels as written. It panics if the pattern is invalid.
+func parseHostPattern(pattern string) []string {
+	if pattern == "" || strings.Contains(pattern, "/") {
+		panic("hmux: host pattern must be non-empty and contain no /")
+	}
+
+	labels := strings.Split(pattern, ".")
+	seen := make(map[string]bool)
+
+	for i, label := range labels {
+		switch {
+		case label == "":
+			panic("hmux: empty label in host pattern " + pattern)
+		case strings.HasPrefix(label, "{") || strings.HasSuffix(label, "}"):
+			name, ok := strings.CutPrefix(label, "{")
+			name, ok2 := strings.CutSuffix(name, "}")
+			if !ok || !ok2 || !validParamName(name) {
+				panic("hmux: invalid wildcard " + label + " in host pattern " + pattern)
+			}
+			if seen[name] {
+				panic("hmux: duplicate wildcard " + label + " in host pattern " + pattern)
+			}
+			seen[name] = true
+		default:
+			labels[i] = strings.ToLower(label)
+		}
+	}
+
+	return labels
+}
+
+// validParamName reports whether name is a valid wildcard name: a
+// non-empty Go identifier.
+func validParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && (i == 0 || !(c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchHost returns the Mux registered for the request host, along with
+// the request carrying the matched wildcards in its context. It returns a
+// nil Mux if no host pattern matches.
+func (m *Mux) matchHost(r *http.Request) (*Mux, *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	labels := strings.Split(host, ".")
+
+	for _, hr := range m.hosts {
+		params, ok := matchHostLabels(hr.labels, labels)
+		if !ok {
+			continue
+		}
+
+		if len(params) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), hostParamsKey, params))
+		}
+
+		return hr.mux, r
+	}
+
+	return nil, r
+}
+
+// matchHostLabels matches host labels against pattern labels and returns
+// the values of wildcard labels.
+func matchHostLabels(pattern, labels []string) (map[string]string, bool) {
+	if len(pattern) != len(labels) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") {
+			if labels[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[p[1:len(p)-1]] = labels[i]
+
+			continue
+		}
+
+		if p != labels[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,98 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMux_Host(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("main"))
+	})
+
+	tenants := m.Host("{tenant}.example.com")
+	tenants.HandleFunc("GET /dashboard", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("dashboard " + HostParamFromContext(r.Context(), "tenant")))
+	})
+
+	m.Host("{region}.{tenant}.example.com").HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Write([]byte(HostParamFromContext(ctx, "tenant") + "@" + HostParamFromContext(ctx, "region")))
+	})
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"http://acme.example.com/dashboard", http.StatusOK, "dashboard acme"},
+		{"http://ACME.example.com:8080/dashboard", http.StatusOK, "dashboard acme"},
+		{"http://acme.example.com/other", http.StatusNotFound, "404 page not found\n"},
+		{"http://eu.acme.example.com/", http.StatusOK, "acme@eu"},
+		{"http://example.com/dashboard", http.StatusOK, "main"},
+		{"http://acme.example.org/dashboard", http.StatusOK, "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			record = nil
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && len(record) != 2 {
+				t.Errorf("expected inherited middleware, got %v", record)
+			}
+		})
+	}
+}
+
+func TestMux_Host_PatternVisibleToOuter(t *testing.T) {
+	var pattern string
+	m := New()
+	m.UseOuter(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			pattern = r.Pattern
+		})
+	})
+	m.Host("{tenant}.example.com").HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://a.example.com/users", nil))
+
+	if pattern != "GET /users" {
+		t.Errorf("expected %q, got %q", "GET /users", pattern)
+	}
+}
+
+func TestMux_Host_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"empty", func(m *Mux) { m.Host("") }},
+		{"slash", func(m *Mux) { m.Host("example.com/x") }},
+		{"empty label", func(m *Mux) { m.Host("a..example.com") }},
+		{"bad wildcard", func(m *Mux) { m.Host("{a-b}.example.com") }},
+		{"unclosed wildcard", func(m *Mux) { m.Host("{a.example.com") }},
+		{"repeated wildcard", func(m *Mux) { m.Host("{a}.{a}.example.com") }},
+		{"duplicate", func(m *Mux) { m.Host("{a}.example.com"); m.Host("{a}.example.com") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package hmux
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Inspector returns a handler rendering the live route table of m as an
+// HTML page, for development environments:
+//
+//	if debug {
+//	    mux.Handle("GET /_routes", hmux.Inspector(mux))
+//	}
+//
+// The page lists each route with its host, version, middleware and
+// metadata, and how many requests it has served and when it last did,
+// counted from the call to Inspector. To count requests, Inspector adds
+// outer middleware to m. Do not expose the page in production: it
+// reveals the structure of the application.
+func Inspector(m *Mux) http.Handler {
+	in := &inspector{mux: m, started: time.Now(), stats: make(map[string]*matchStats)}
+	m.UseOuter(in.record)
+
+	return in
+}
+
+// inspector serves the page of Inspector.
+type inspector struct {
+	mux     *Mux
+	started time.Time
+
+	mu        sync.Mutex
+	stats     map[string]*matchStats
+	unmatched int
+}
+
+// matchStats counts the requests served by the routes of a pattern.
+type matchStats struct {
+	hits int
+	last time.Time
+}
+
+// record is the outer middleware counting matches.
+func (in *inspector) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		in.mu.Lock()
+		defer in.mu.Unlock()
+
+		if r.Pattern == "" {
+			in.unmatched++
+			return
+		}
+
+		s, ok := in.stats[r.Pattern]
+		if !ok {
+			s = &matchStats{}
+			in.stats[r.Pattern] = s
+		}
+		s.hits++
+		s.last = time.Now()
+	})
+}
+
+// inspectorRow is a route as rendered by the inspector.
+type inspectorRow struct {
+	RouteInfo
+	Hits int
+	Last string
+}
+
+func (in *inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	routes := in.mux.Routes()
+	now := time.Now()
+
+	in.mu.Lock()
+	rows := make([]inspectorRow, len(routes))
+	for i, rt := range routes {
+		rows[i] = inspectorRow{RouteInfo: rt, Last: "never"}
+		if s, ok := in.stats[rt.Pattern]; ok {
+			rows[i].Hits = s.hits
+			rows[i].Last = now.Sub(s.last).Round(time.Second).String() + " ago"
+		}
+	}
+	unmatched := in.unmatched
+	in.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	inspectorTemplate.Execute(w, map[string]any{
+		"Routes":    rows,
+		"Unmatched": unmatched,
+		"Since":     in.started.Format(time.RFC3339),
+	})
+}
+
+var inspectorTemplate = template.Must(template.New("inspector").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Routes</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3em 1em; border-bottom: 1px solid #ddd; }
+code { font-size: 1.1em; }
+</style>
+</head>
+<body>
+<h1>Routes</h1>
+<p>{{len .Routes}} routes. Requests counted since {{.Since}}; {{.Unmatched}} matched no route.</p>
+<table>
+<tr><th>Pattern</th><th>Host</th><th>Version</th><th>Middleware</th><th>Metadata</th><th>Requests</th><th>Last request</th></tr>
+{{range .Routes}}<tr>
+<td><code>{{.Pattern}}</code></td>
+<td>{{.Host}}</td>
+<td>{{.Version}}</td>
+<td>{{join .Middleware ", "}}</td>
+<td>{{range $k, $v := .Meta}}{{$k}}={{$v}} {{end}}</td>
+<td>{{.Hits}}</td>
+<td>{{.Last}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
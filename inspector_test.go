@@ -0,0 +1,42 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInspector(t *testing.T) {
+	m := New()
+	m.UseNamed("auth", func(next http.Handler) http.Handler { return next })
+	m.Meta(M{"tag": "users"}).HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	m.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+	m.Handle("GET /_routes", Inspector(m))
+
+	for _, path := range []string{"/users", "/users", "/missing"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_routes", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("expected HTML, got %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, s := range []string{
+		"3 routes",
+		"1 matched no route",
+		"<td><code>GET /users</code></td>",
+		"<td>auth</td>",
+		"tag=users",
+		"<td>2</td>",
+		"<td>never</td>",
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected page to contain %q", s)
+		}
+	}
+}
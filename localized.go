@@ -0,0 +1,76 @@
+package hmux
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// LocaleMeta is the route metadata key holding the locale of a route
+// registered with HandleLocalized.
+const LocaleMeta = "locale"
+
+// HandleLocalized registers handler on r under a translated path per
+// locale, so a page has a native URL in each language:
+//
+//	about := mux.Meta(hmux.M{hmux.RouteName: "about"})
+//	hmux.HandleLocalized(about, http.MethodGet, map[string]string{
+//	    "en": "/en/about",
+//	    "de": "/de/ueber-uns",
+//	    "fr": "/fr/a-propos",
+//	}, aboutPage)
+//
+// Each route carries its locale in its metadata under LocaleMeta, which
+// the route's middleware and handler read with LocaleFromContext. Naming
+// the routes, as above, lets Mux.LocalizedURL link to the page in a given
+// language, for example for a language switcher. Paths may contain
+// wildcards, which should be named alike in every locale. An empty method
+// registers the routes for all methods.
+//
+// Routes are registered in the order of their locales.
+//
+// HandleLocalized panics if paths is empty.
+func HandleLocalized(r ExtendedRouter, method string, paths map[string]string, handler http.Handler) {
+	if len(paths) == 0 {
+		panic("hmux: HandleLocalized needs at least one path")
+	}
+
+	for _, locale := range slices.Sorted(maps.Keys(paths)) {
+		pattern := paths[locale]
+		if method != "" {
+			pattern = method + " " + pattern
+		}
+
+		r.Meta(M{LocaleMeta: locale}).Handle(pattern, handler)
+	}
+}
+
+// LocaleFromContext returns the locale of the route serving the request,
+// as registered with HandleLocalized, or an empty string if the route
+// has none.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := MetaFromContext(ctx)[LocaleMeta].(string)
+	return locale
+}
+
+// LocalizedURL is like URL for routes registered with HandleLocalized:
+// it returns the path of the route named name for the given locale.
+//
+//	u, err := mux.LocalizedURL("about", "de") // "/de/ueber-uns"
+func (m *Mux) LocalizedURL(name, locale string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("hmux: odd number of params for route %q", name)
+	}
+
+	for _, rt := range m.Routes() {
+		n, _ := rt.Meta[RouteName].(string)
+		l, _ := rt.Meta[LocaleMeta].(string)
+		if n == name && l == locale {
+			return buildPath(rt.Pattern, params)
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q in locale %q", ErrRouteNotFound, name, locale)
+}
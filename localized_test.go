@@ -0,0 +1,55 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleLocalized(t *testing.T) {
+	m := New()
+	var record []string
+	m.Use(recordingMiddleware("mw", &record))
+
+	product := m.Meta(M{RouteName: "product"})
+	HandleLocalized(product, http.MethodGet, map[string]string{
+		"en": "/en/products/{id}",
+		"de": "/de/produkte/{id}",
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alt := "en"
+		if LocaleFromContext(r.Context()) == "en" {
+			alt = "de"
+		}
+		u, err := m.LocalizedURL("product", alt, "id", r.PathValue("id"))
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(LocaleFromContext(r.Context()) + " " + u))
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/en/products/7", http.StatusOK, "en /de/produkte/7"},
+		{"/de/produkte/7", http.StatusOK, "de /en/products/7"},
+		{"/de/products/7", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	if len(record) != 4 {
+		t.Errorf("expected middleware on every localized route, got %v", record)
+	}
+	if _, err := m.LocalizedURL("product", "fr", "id", "1"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound for a missing locale, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package hmux
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Maintenance is a runtime switch that takes the application offline.
+// While enabled, its middleware answers every request with 503 Service
+// Unavailable and a Retry-After header, except for requests whose path is
+// on the allowlist (health checks, status pages).
+//
+// Enable and Disable are safe to call at any time, including while
+// requests are being served, for example from an admin endpoint or a
+// signal handler.
+//
+// Example:
+//
+//	m := hmux.NewMaintenance("/healthz")
+//	mux.Use(m.Middleware)
+//	// later
+//	m.Enable(10 * time.Minute)
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter atomic.Int64 // seconds
+	allow      map[string]struct{}
+}
+
+// NewMaintenance returns a disabled Maintenance switch. Requests whose
+// URL path exactly matches one of allow are always served.
+func NewMaintenance(allow ...string) *Maintenance {
+	m := &Maintenance{allow: make(map[string]struct{}, len(allow))}
+	for _, p := range allow {
+		m.allow[p] = struct{}{}
+	}
+
+	return m
+}
+
+// Enable turns maintenance mode on. Clients are told to retry after the
+// given duration, rounded up to whole seconds; a non-positive duration
+// omits the Retry-After header.
+func (m *Maintenance) Enable(retryAfter time.Duration) {
+	secs := int64(0)
+	if retryAfter > 0 {
+		secs = int64((retryAfter + time.Second - 1) / time.Second)
+	}
+
+	m.retryAfter.Store(secs)
+	m.enabled.Store(true)
+}
+
+// Disable turns maintenance mode off.
+func (m *Maintenance) Disable() {
+	m.enabled.Store(false)
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Middleware rejects requests with 503 while maintenance mode is on.
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.enabled.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := m.allow[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if secs := m.retryAfter.Load(); secs > 0 {
+			w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+		}
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	})
+}
@@ -0,0 +1,70 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	maint := NewMaintenance("/healthz")
+	m := New()
+	m.Use(maint.Middleware)
+	m.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	m.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := serve("/users"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 while disabled, got %d", rec.Code)
+	}
+
+	maint.Enable(90 * time.Second)
+	if !maint.Enabled() {
+		t.Error("expected Enabled to report true")
+	}
+
+	rec := serve("/users")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while enabled, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "90" {
+		t.Errorf("expected Retry-After 90, got %q", rec.Header().Get("Retry-After"))
+	}
+	if rec := serve("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("expected allowlisted path to be served, got %d", rec.Code)
+	}
+
+	maint.Disable()
+	if rec := serve("/users"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after disable, got %d", rec.Code)
+	}
+}
+
+func TestMaintenance_ConcurrentToggle(t *testing.T) {
+	maint := NewMaintenance()
+	h := maint.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if i%2 == 0 {
+					maint.Enable(time.Second)
+				} else {
+					maint.Disable()
+				}
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+	}
+	wg.Wait()
+}
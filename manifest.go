@@ -0,0 +1,154 @@
+package hmux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Manifest describes routes declaratively, for example in a JSON or YAML
+// configuration file:
+//
+//	{
+//	  "routes": [
+//	    {"pattern": "GET /users", "handler": "listUsers", "middleware": ["auth"]},
+//	    {"pattern": "GET /healthz", "handler": "health", "metadata": {"public": true}}
+//	  ]
+//	}
+type Manifest struct {
+	Routes []RouteSpec `json:"routes" yaml:"routes"`
+}
+
+// RouteSpec describes a single route of a Manifest.
+type RouteSpec struct {
+	Pattern    string         `json:"pattern" yaml:"pattern"`       // route pattern, as for Handle
+	Handler    string         `json:"handler" yaml:"handler"`       // name of a handler in the Registry
+	Middleware []string       `json:"middleware" yaml:"middleware"` // names of middleware, outermost first
+	Metadata   map[string]any `json:"metadata" yaml:"metadata"`     // free-form data for tooling
+}
+
+// LoadManifest decodes a Manifest from r. The unmarshal function decodes
+// the raw bytes; nil means JSON. Field names are lower case, so YAML
+// decoders with default field naming work as well:
+//
+//	m, err := hmux.LoadManifest(f, yaml.Unmarshal)
+func LoadManifest(r io.Reader, unmarshal func([]byte, any) error) (*Manifest, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("hmux: decoding manifest: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Registry maps names used in a Manifest to handlers and middleware.
+// Register everything during initialization; a Registry is not safe for
+// concurrent modification.
+type Registry struct {
+	handlers   map[string]http.Handler
+	middleware map[string]func(http.Handler) http.Handler
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers:   make(map[string]http.Handler),
+		middleware: make(map[string]func(http.Handler) http.Handler),
+	}
+}
+
+// Handler registers h under name.
+//
+// Handler panics if name is empty or already registered, or if h is nil.
+func (reg *Registry) Handler(name string, h http.Handler) {
+	if name == "" || h == nil {
+		panic("hmux: empty name or nil handler passed to Registry.Handler")
+	}
+	if _, ok := reg.handlers[name]; ok {
+		panic("hmux: handler " + name + " already registered")
+	}
+
+	reg.handlers[name] = h
+}
+
+// HandlerFunc registers fn under name.
+func (reg *Registry) HandlerFunc(name string, fn http.HandlerFunc) {
+	reg.Handler(name, fn)
+}
+
+// Middleware registers mw under name.
+//
+// Middleware panics if name is empty or already registered, or if mw is
+// nil.
+func (reg *Registry) Middleware(name string, mw func(http.Handler) http.Handler) {
+	if name == "" || mw == nil {
+		panic("hmux: empty name or nil middleware passed to Registry.Middleware")
+	}
+	if _, ok := reg.middleware[name]; ok {
+		panic("hmux: middleware " + name + " already registered")
+	}
+
+	reg.middleware[name] = mw
+}
+
+// Apply registers the routes of m on router. Each route's handler is
+// wrapped with its named middleware in order, inside the router's own
+// middleware, and carries its metadata as with ExtendedRouter.Meta.
+//
+// Apply checks the whole manifest before registering anything and
+// returns an error naming the first route with an empty pattern or an
+// unknown handler or middleware. Errors raised while registering, such
+// as a conflicting pattern, are returned as well; routes before the
+// failing one remain registered.
+func (reg *Registry) Apply(router ExtendedRouter, m *Manifest) error {
+	type route struct {
+		pattern string
+		handler http.Handler
+		mw      []func(http.Handler) http.Handler
+		meta    M
+	}
+
+	routes := make([]route, 0, len(m.Routes))
+	for i, spec := range m.Routes {
+		if spec.Pattern == "" {
+			return fmt.Errorf("hmux: manifest route %d: empty pattern", i)
+		}
+
+		h, ok := reg.handlers[spec.Handler]
+		if !ok {
+			return fmt.Errorf("hmux: manifest route %q: unknown handler %q", spec.Pattern, spec.Handler)
+		}
+
+		mw := make([]func(http.Handler) http.Handler, len(spec.Middleware))
+		for j, name := range spec.Middleware {
+			if mw[j], ok = reg.middleware[name]; !ok {
+				return fmt.Errorf("hmux: manifest route %q: unknown middleware %q", spec.Pattern, name)
+			}
+		}
+
+		routes = append(routes, route{spec.Pattern, h, mw, spec.Metadata})
+	}
+
+	for _, rt := range routes {
+		r := router
+		if len(rt.meta) > 0 {
+			r = router.Meta(rt.meta)
+		}
+		if err := r.TryHandle(rt.pattern, wrap(rt.handler, rt.mw)); err != nil {
+			return fmt.Errorf("hmux: manifest route %q: %w", rt.pattern, errors.Unwrap(err))
+		}
+	}
+
+	return nil
+}
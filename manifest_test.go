@@ -0,0 +1,111 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{
+  "routes": [
+    {"pattern": "GET /users", "handler": "users", "middleware": ["auth", "audit"]},
+    {"pattern": "GET /healthz", "handler": "health", "metadata": {"public": true}}
+  ]
+}`
+
+func TestRegistry_Apply(t *testing.T) {
+	var record []string
+	reg := NewRegistry()
+	reg.HandlerFunc("users", func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "users")
+	})
+	reg.HandlerFunc("health", func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "health")
+	})
+	reg.Middleware("auth", recordingMiddleware("auth", &record))
+	reg.Middleware("audit", recordingMiddleware("audit", &record))
+
+	m, err := LoadManifest(strings.NewReader(testManifest), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Routes[1].Metadata["public"] != true {
+		t.Errorf("expected metadata to be decoded, got %v", m.Routes[1].Metadata)
+	}
+
+	mux := New()
+	mux.Use(recordingMiddleware("global", &record))
+	if err := reg.Apply(mux, m); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/users", []string{"global:enter", "auth:enter", "audit:enter", "users", "audit:exit", "auth:exit", "global:exit"}},
+		{"/healthz", []string{"global:enter", "health", "global:exit"}},
+	}
+
+	for _, tt := range tests {
+		record = nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if !slices.Equal(record, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, record)
+		}
+	}
+
+	if info, _ := mux.Match(http.MethodGet, "", "/healthz"); info.Meta["public"] != true {
+		t.Errorf("expected route metadata, got %v", info.Meta)
+	}
+}
+
+func TestRegistry_Apply_Errors(t *testing.T) {
+	reg := NewRegistry()
+	reg.HandlerFunc("h", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		msg      string
+	}{
+		{
+			"unknown handler",
+			&Manifest{Routes: []RouteSpec{{Pattern: "/a", Handler: "missing"}}},
+			`unknown handler "missing"`,
+		},
+		{
+			"unknown middleware",
+			&Manifest{Routes: []RouteSpec{{Pattern: "/a", Handler: "h", Middleware: []string{"nope"}}}},
+			`unknown middleware "nope"`,
+		},
+		{
+			"empty pattern",
+			&Manifest{Routes: []RouteSpec{{Handler: "h"}}},
+			"empty pattern",
+		},
+		{
+			"conflict",
+			&Manifest{Routes: []RouteSpec{{Pattern: "/a", Handler: "h"}, {Pattern: "/a", Handler: "h"}}},
+			`manifest route "/a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := reg.Apply(New(), tt.manifest)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("expected error containing %q, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+func TestLoadManifest_InvalidJSON(t *testing.T) {
+	if _, err := LoadManifest(strings.NewReader("{"), nil); err == nil {
+		t.Error("expected decode error")
+	}
+}
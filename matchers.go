@@ -0,0 +1,211 @@
+package hmux
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Matcher reports whether a route accepts a request beyond its pattern.
+// Applied to routes with Matching, matchers let several handlers share a
+// pattern, for example a webhook endpoint dispatching on an event header:
+//
+//	mux.Matching(hmux.MatchHeader("X-Github-Event", "push")).HandleFunc("POST /hook", onPush)
+//	mux.Matching(hmux.MatchHeader("X-Github-Event", "issues")).HandleFunc("POST /hook", onIssue)
+//	mux.HandleFunc("POST /hook", onOther) // any other event
+//
+// Routes sharing a pattern are tried in registration order, and the
+// first whose matchers all accept the request serves it. A route
+// registered without matchers serves the requests no other route
+// accepts; at most one such route may share a pattern. Without it, such
+// requests get 404 Not Found, or the handler set with NotFoundHandler.
+//
+// Matchers run before the route's middleware, and r.PathValue returns
+// the values of the pattern's wildcards.
+type Matcher func(r *http.Request) bool
+
+// Matching returns a router without prefix whose routes are constrained
+// by matchers: they only serve the requests all matchers accept. Routes
+// registered on other routers may share their patterns. See Matcher.
+//
+// The returned router has the Mux's middleware, like With, and groups
+// created from it carry the matchers too.
+//
+// Matching panics if any matcher is nil.
+func (m *Mux) Matching(matchers ...Matcher) ExtendedRouter {
+	return m.Group("").(*Group).Matching(matchers...)
+}
+
+// Matching returns a router with the group's prefix and middleware whose
+// routes are constrained by matchers in addition to the group's. See
+// Mux.Matching.
+func (g *Group) Matching(matchers ...Matcher) ExtendedRouter {
+	if slices.ContainsFunc(matchers, func(fn Matcher) bool { return fn == nil }) {
+		panic("hmux: nil matcher passed to Matching")
+	}
+
+	newG := g.Group("").(*Group)
+	newG.matchers = slices.Concat(g.matchers, matchers)
+
+	return newG
+}
+
+// MatchHeader returns a Matcher accepting requests with a header named
+// name that has the given value.
+func MatchHeader(name, value string) Matcher {
+	name = http.CanonicalHeaderKey(name)
+
+	return func(r *http.Request) bool {
+		return slices.Contains(r.Header[name], value)
+	}
+}
+
+// MatchQuery returns a Matcher accepting requests whose query string has
+// a parameter named name with the given value, for example to route
+// "GET /report?format=csv" to its own handler:
+//
+//	mux.Matching(hmux.MatchQuery("format", "csv")).HandleFunc("GET /report", reportCSV)
+//	mux.HandleFunc("GET /report", reportHTML)
+func MatchQuery(name, value string) Matcher {
+	return func(r *http.Request) bool {
+		return slices.Contains(r.URL.Query()[name], value)
+	}
+}
+
+// Constrain returns a Matcher accepting requests whose path wildcard
+// named name matches re, so malformed values get 404 Not Found before
+// the handler runs:
+//
+//	id := regexp.MustCompile(`^[0-9]+$`)
+//	mux.Matching(hmux.Constrain("id", id)).HandleFunc("GET /users/{id}", getUser)
+//
+// The value is matched as by re.MatchString; anchor re to match it in
+// full. Patterns can also declare constraints inline, as in
+// "GET /users/{id:[0-9]+}", which are anchored implicitly.
+func Constrain(name string, re *regexp.Regexp) Matcher {
+	return func(r *http.Request) bool {
+		return re.MatchString(r.PathValue(name))
+	}
+}
+
+// parseConstraints strips the inline constraints of the wildcards of
+// pattern, as in "{id:[0-9]+}", and returns the plain pattern and a
+// Matcher per constraint. Constraints may contain balanced braces, as in
+// "{code:[a-z]{2}}".
+func parseConstraints(pattern string) (string, []Matcher) {
+	if !strings.Contains(pattern, ":") {
+		return pattern, nil
+	}
+
+	var (
+		b        strings.Builder
+		matchers []Matcher
+	)
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+
+		end, depth := -1, 0
+		for i := start; i < len(pattern) && end < 0; i++ {
+			switch pattern[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			// Unbalanced; leave it to http.ServeMux to report.
+			break
+		}
+
+		name, expr, ok := strings.Cut(pattern[start+1:end], ":")
+		b.WriteString(pattern[:start+1])
+		b.WriteString(name)
+		b.WriteByte('}')
+		pattern = pattern[end+1:]
+
+		if ok {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				panic("hmux: invalid constraint for wildcard " + name + ": " + err.Error())
+			}
+			matchers = append(matchers, Constrain(strings.TrimSuffix(name, "..."), re))
+		}
+	}
+	b.WriteString(pattern)
+
+	return b.String(), matchers
+}
+
+// matchSet serves the routes sharing a pattern.
+type matchSet struct {
+	routes   []*route // routes with matchers, in registration order
+	fallback *route   // route without matchers, if any
+	notFound func() http.Handler
+}
+
+// newMatchSet returns an empty match set for the Mux.
+func (m *Mux) newMatchSet() *matchSet {
+	return &matchSet{notFound: m.notFoundHandler}
+}
+
+// setHandler returns the handler serving s under pattern. On a
+// case-insensitive Mux it restores the case of the path values first,
+// so that matchers such as inline constraints see them as sent.
+func (m *Mux) setHandler(pattern string, s *matchSet) http.Handler {
+	if m.caseFold {
+		return restorePathValues(pattern, s)
+	}
+
+	return s
+}
+
+// add adds rt to the set.
+func (s *matchSet) add(rt *route) {
+	if len(rt.matchers) > 0 {
+		s.routes = append(s.routes, rt)
+		return
+	}
+
+	if s.fallback != nil {
+		panic("hmux: pattern " + rt.pattern + " is already registered without matchers")
+	}
+
+	s.fallback = rt
+}
+
+// ServeHTTP serves r with the first route accepting it.
+func (s *matchSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, rt := range s.routes {
+		if matchAll(rt.matchers, r) {
+			rt.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	switch {
+	case s.fallback != nil:
+		s.fallback.handler.ServeHTTP(w, r)
+	case s.notFound() != nil:
+		s.notFound().ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// matchAll reports whether all matchers accept r.
+func matchAll(matchers []Matcher, r *http.Request) bool {
+	for _, match := range matchers {
+		if !match(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestMatchHeader(t *testing.T) {
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dynamic  bool
+		fallback string // registration of the fallback: "", "first" or "last"
+		event    string
+		code     int
+		body     string
+	}{
+		{"push", false, "last", "push", http.StatusOK, "push"},
+		{"issues", false, "last", "issues", http.StatusOK, "issues"},
+		{"other", false, "last", "ping", http.StatusOK, "other"},
+		{"fallback first", false, "first", "push", http.StatusOK, "push"},
+		{"fallback first other", false, "first", "ping", http.StatusOK, "other"},
+		{"no fallback", false, "", "ping", http.StatusNotFound, "404 page not found\n"},
+		{"dynamic", true, "first", "issues", http.StatusOK, "issues"},
+		{"dynamic other", true, "first", "ping", http.StatusOK, "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.dynamic {
+				opts = append(opts, Dynamic())
+			}
+			m := New(opts...)
+			if tt.fallback == "first" {
+				m.HandleFunc("POST /hook", respond("other"))
+			}
+			m.Matching(MatchHeader("X-Github-Event", "push")).HandleFunc("POST /hook", respond("push"))
+			m.Group("").(ExtendedRouter).Matching(MatchHeader("x-github-event", "issues")).HandleFunc("POST /hook", respond("issues"))
+			if tt.fallback == "last" {
+				m.HandleFunc("POST /hook", respond("other"))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/hook", nil)
+			req.Header.Set("X-Github-Event", tt.event)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	m := New()
+	m.Matching(MatchQuery("format", "csv")).HandleFunc("GET /report", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("csv"))
+	})
+	m.HandleFunc("GET /report", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("html"))
+	})
+
+	tests := []struct {
+		target string
+		body   string
+	}{
+		{"/report?format=csv", "csv"},
+		{"/report?format=pdf&format=csv", "csv"},
+		{"/report?format=pdf", "html"},
+		{"/report", "html"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.target, tt.body, rec.Body.String())
+		}
+	}
+}
+
+func TestConstrain(t *testing.T) {
+	echo := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.PathValue(name)))
+		}
+	}
+
+	m := New()
+	m.HandleFunc("GET /users/{id:[0-9]+}", echo("id"))
+	m.Matching(MatchQuery("any", "1")).HandleFunc("GET /users/{id}", echo("id"))
+	m.HandleFunc("GET /langs/{code:[a-z]{2}}", echo("code"))
+	m.Matching(Constrain("id", regexp.MustCompile(`^ord_`))).HandleFunc("GET /orders/{id}", echo("id"))
+	m.Group("/files").HandleFunc("GET /{path...:.*\\.txt}", echo("path"))
+
+	tests := []struct {
+		target string
+		code   int
+		body   string
+	}{
+		{"/users/42", http.StatusOK, "42"},
+		{"/users/abc", http.StatusNotFound, "404 page not found\n"},
+		{"/users/abc?any=1", http.StatusOK, "abc"},
+		{"/langs/en", http.StatusOK, "en"},
+		{"/langs/eng", http.StatusNotFound, "404 page not found\n"},
+		{"/orders/ord_1", http.StatusOK, "ord_1"},
+		{"/orders/1", http.StatusNotFound, "404 page not found\n"},
+		{"/files/a/b.txt", http.StatusOK, "a/b.txt"},
+		{"/files/a/b.pdf", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.target, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	for _, info := range m.Routes() {
+		if info.Pattern == "GET /langs/{code}" {
+			return
+		}
+	}
+	t.Error("expected GET /langs/{code} in Routes")
+}
+
+func TestParseConstraints_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for an invalid constraint")
+		}
+	}()
+	New().HandleFunc("GET /users/{id:[0-9}", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestMatchHeader_TwoFallbacks(t *testing.T) {
+	m := New()
+	m.Matching(MatchHeader("X-Event", "push")).HandleFunc("POST /hook", func(w http.ResponseWriter, r *http.Request) {})
+	m.HandleFunc("POST /hook", func(w http.ResponseWriter, r *http.Request) {})
+
+	err := m.TryHandleFunc("POST /hook", func(w http.ResponseWriter, r *http.Request) {})
+	if err == nil {
+		t.Error("expected error for a second route without matchers")
+	}
+}
+
+func TestMatching_NilMatcher_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a nil matcher")
+		}
+	}()
+	New().Matching(MatchQuery("a", "1"), nil)
+}
@@ -0,0 +1,49 @@
+package hmux
+
+import "maps"
+
+// M is a set of route metadata, such as the authorization a route needs
+// or the tags it is documented under.
+type M map[string]any
+
+// Meta returns a router without prefix whose routes carry the given
+// metadata. Metadata is free-form: hmux does not interpret it, but makes
+// it available to middleware through MetaFromContext and to tooling such
+// as documentation generators through Routes:
+//
+//	mux.Meta(hmux.M{"auth": "admin", "tag": "billing"}).
+//	    HandleFunc("POST /invoices", createInvoice)
+//
+// The returned router has the Mux's middleware, like With.
+func (m *Mux) Meta(meta M) ExtendedRouter {
+	g := m.Group("").(*Group)
+	g.meta = mergeMeta(nil, meta)
+
+	return g
+}
+
+// Meta returns a router with the group's prefix and middleware whose
+// routes carry the given metadata in addition to the group's. Keys in
+// meta override keys set by enclosing calls to Meta. See Mux.Meta.
+func (g *Group) Meta(meta M) ExtendedRouter {
+	newG := g.Group("").(*Group)
+	newG.meta = mergeMeta(g.meta, meta)
+
+	return newG
+}
+
+// mergeMeta returns a new M holding the keys of base overridden by those
+// of meta, or nil if both are empty.
+func mergeMeta(base, meta M) M {
+	if len(base) == 0 && len(meta) == 0 {
+		return nil
+	}
+
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(M, len(meta))
+	}
+	maps.Copy(merged, meta)
+
+	return merged
+}
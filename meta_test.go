@@ -0,0 +1,65 @@
+package hmux
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMeta(t *testing.T) {
+	var seen M
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = MetaFromContext(r.Context())
+			next.ServeHTTP(w, r)
+		})
+	}
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.Use(capture)
+	m.Meta(M{"auth": "admin"}).HandleFunc("GET /admin", noop)
+	billing := m.Group("/billing").(ExtendedRouter).Meta(M{"tag": "billing", "auth": "user"})
+	billing.HandleFunc("GET /invoices", noop)
+	billing.Meta(M{"auth": "admin"}).HandleFunc("POST /invoices", noop)
+	m.HandleFunc("GET /plain", noop)
+
+	tests := []struct {
+		method, path string
+		expected     M
+	}{
+		{"GET", "/admin", M{"auth": "admin"}},
+		{"GET", "/billing/invoices", M{"tag": "billing", "auth": "user"}},
+		{"POST", "/billing/invoices", M{"tag": "billing", "auth": "admin"}},
+		{"GET", "/plain", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			seen = nil
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if !maps.Equal(seen, tt.expected) {
+				t.Errorf("context: expected %v, got %v", tt.expected, seen)
+			}
+
+			info, ok := m.Match(tt.method, "", tt.path)
+			if !ok || !maps.Equal(info.Meta, tt.expected) {
+				t.Errorf("routes: expected %v, got %v", tt.expected, info.Meta)
+			}
+		})
+	}
+}
+
+func TestMeta_Deferred(t *testing.T) {
+	var seen M
+	m := New(Deferred())
+	m.Meta(M{"auth": "admin"}).HandleFunc("GET /admin", func(w http.ResponseWriter, r *http.Request) {
+		seen = MetaFromContext(r.Context())
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if seen["auth"] != "admin" {
+		t.Errorf("expected admin, got %v", seen["auth"])
+	}
+}
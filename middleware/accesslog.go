@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// clfTimeLayout is the timestamp layout of the Common Log Format.
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// LogEntry describes a completed request.
+type LogEntry struct {
+	Time       time.Time     // when the request started
+	RemoteAddr string        // client address without port
+	User       string        // basic auth or URL user, if any
+	Method     string        // request method
+	URI        string        // request URI as sent by the client
+	Proto      string        // protocol, e.g. "HTTP/1.1"
+	Host       string        // Host header
+	Route      string        // matched pattern, empty if unmatched
+	Status     int           // response status code
+	Bytes      int64         // response body bytes written
+	Latency    time.Duration // time spent serving the request
+	Referer    string        // Referer header
+	UserAgent  string        // User-Agent header
+	RequestID  string        // ID assigned by the RequestID middleware
+}
+
+// LogFormatter renders a LogEntry as a single log line without the
+// trailing newline.
+type LogFormatter interface {
+	Format(e *LogEntry) []byte
+}
+
+// LogFormatterFunc adapts a function to the LogFormatter interface.
+type LogFormatterFunc func(e *LogEntry) []byte
+
+// Format implements LogFormatter.
+func (f LogFormatterFunc) Format(e *LogEntry) []byte {
+	return f(e)
+}
+
+// Built-in log formats.
+var (
+	// CommonLogFormat renders the NCSA Common Log Format:
+	//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326
+	// The user and request line are escaped as Apache does.
+	CommonLogFormat LogFormatter = LogFormatterFunc(formatCommon)
+
+	// CombinedLogFormat renders the NCSA Combined Log Format, which
+	// appends the quoted Referer and User-Agent to the Common Log Format.
+	CombinedLogFormat LogFormatter = LogFormatterFunc(formatCombined)
+
+	// JSONLogFormat renders one JSON object per request, including the
+	// latency, matched route and request ID.
+	JSONLogFormat LogFormatter = LogFormatterFunc(formatJSON)
+)
+
+// AccessLog returns middleware that writes one line per request to w,
+// rendered by f. Each line is written with a single Write call, and calls
+// are serialized, so w may be a file, os.Stdout, or the writer of a
+// *log.Logger:
+//
+//	mux.Use(middleware.RequestID)
+//	mux.Use(middleware.AccessLog(os.Stdout, middleware.JSONLogFormat))
+//
+// Status, byte count and latency are observed through hmux.StatusWriter.
+// Requests that write nothing are logged with status 200. The URI,
+// Referer and user of requests for routes with a Redactor, set in their
+// metadata under RedactorMeta, are redacted.
+//
+// AccessLog panics if w or f is nil.
+func AccessLog(w io.Writer, f LogFormatter) func(http.Handler) http.Handler {
+	if w == nil || f == nil {
+		panic("hmux: nil writer or formatter passed to AccessLog")
+	}
+
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := hmux.NewStatusWriter(rw)
+
+			next.ServeHTTP(sw, r)
+
+			e := newLogEntry(r, start)
+			e.Status = sw.Status()
+			if e.Status == 0 {
+				e.Status = http.StatusOK
+			}
+			e.Bytes = sw.BytesWritten()
+			e.Latency = time.Since(start)
+
+			line := append(f.Format(e), '\n')
+
+			mu.Lock()
+			w.Write(line)
+			mu.Unlock()
+		})
+	}
+}
+
+// newLogEntry captures the request fields of a LogEntry, redacted by the
+// route's Redactor. It runs after the handler so that the route matched by
+// the mux is known. Context values added by inner middleware are not
+// visible, as they are set on a copy of the request.
+func newLogEntry(r *http.Request, start time.Time) *LogEntry {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	user := ""
+	if u, _, ok := r.BasicAuth(); ok {
+		user = u
+	} else if r.URL.User != nil {
+		user = r.URL.User.Username()
+	}
+
+	rd := RedactorFromContext(r.Context())
+
+	return &LogEntry{
+		Time:       start,
+		RemoteAddr: remote,
+		User:       rd.String(user),
+		Method:     r.Method,
+		URI:        rd.URL(r.RequestURI),
+		Proto:      r.Proto,
+		Host:       r.Host,
+		Route:      r.Pattern,
+		Referer:    rd.URL(r.Referer()),
+		UserAgent:  r.UserAgent(),
+		RequestID:  hmux.RequestIDFromContext(r.Context()),
+	}
+}
+
+func formatCommon(e *LogEntry) []byte {
+	var b strings.Builder
+	b.WriteString(dash(e.RemoteAddr))
+	b.WriteString(" - ")
+	writeEscaped(&b, dash(e.User))
+	b.WriteString(" [")
+	b.WriteString(e.Time.Format(clfTimeLayout))
+	b.WriteString(`] "`)
+	writeEscaped(&b, e.Method)
+	b.WriteByte(' ')
+	writeEscaped(&b, e.URI)
+	b.WriteByte(' ')
+	writeEscaped(&b, e.Proto)
+	b.WriteString(`" `)
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteByte(' ')
+	if e.Bytes == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	}
+
+	return []byte(b.String())
+}
+
+func formatCombined(e *LogEntry) []byte {
+	line := formatCommon(e)
+	line = append(line, ' ')
+	line = strconv.AppendQuote(line, e.Referer)
+	line = append(line, ' ')
+	line = strconv.AppendQuote(line, e.UserAgent)
+
+	return line
+}
+
+func formatJSON(e *LogEntry) []byte {
+	line, _ := json.Marshal(struct {
+		Time       string  `json:"time"`
+		RemoteAddr string  `json:"remote_addr"`
+		User       string  `json:"user,omitempty"`
+		Method     string  `json:"method"`
+		URI        string  `json:"uri"`
+		Proto      string  `json:"proto"`
+		Host       string  `json:"host"`
+		Route      string  `json:"route,omitempty"`
+		Status     int     `json:"status"`
+		Bytes      int64   `json:"bytes"`
+		LatencyMS  float64 `json:"latency_ms"`
+		Referer    string  `json:"referer,omitempty"`
+		UserAgent  string  `json:"user_agent,omitempty"`
+		RequestID  string  `json:"request_id,omitempty"`
+	}{
+		Time:       e.Time.Format(time.RFC3339Nano),
+		RemoteAddr: e.RemoteAddr,
+		User:       e.User,
+		Method:     e.Method,
+		URI:        e.URI,
+		Proto:      e.Proto,
+		Host:       e.Host,
+		Route:      e.Route,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		LatencyMS:  float64(e.Latency.Microseconds()) / 1000,
+		Referer:    e.Referer,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+	})
+
+	return line
+}
+
+// dash returns "-" for empty CLF fields.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// writeEscaped writes s to b escaped the way Apache escapes request
+// fields, so that client-controlled values cannot break out of their
+// field or forge log lines: '"' and '\\' are backslash-escaped, and
+// control and non-ASCII bytes are written as \xhh.
+func writeEscaped(b *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			b.WriteString(`\x`)
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
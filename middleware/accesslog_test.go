@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestAccessLog_Formats(t *testing.T) {
+	e := &LogEntry{
+		Time:       time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600)),
+		RemoteAddr: "127.0.0.1",
+		User:       "frank",
+		Method:     "GET",
+		URI:        "/apache_pb.gif",
+		Proto:      "HTTP/1.0",
+		Status:     200,
+		Bytes:      2326,
+		Referer:    "http://www.example.com/start.html",
+		UserAgent:  "Mozilla/4.08",
+	}
+
+	clf := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+	if got := string(CommonLogFormat.Format(e)); got != clf {
+		t.Errorf("common:\nexpected %s\ngot      %s", clf, got)
+	}
+
+	combined := clf + ` "http://www.example.com/start.html" "Mozilla/4.08"`
+	if got := string(CombinedLogFormat.Format(e)); got != combined {
+		t.Errorf("combined:\nexpected %s\ngot      %s", combined, got)
+	}
+
+	e.User, e.Bytes = "", 0
+	if got := string(CommonLogFormat.Format(e)); !strings.Contains(got, "- - [") || !strings.HasSuffix(got, " 200 -") {
+		t.Errorf("expected dashes for empty fields, got %s", got)
+	}
+
+	e.User, e.Method, e.URI = "a b\\", "GET", "/x\" 200 1\n127.0.0.1 - admin\x7f\u00e9"
+	expected := `127.0.0.1 - a b\\ [10/Oct/2000:13:55:36 -0700] "GET /x\" 200 1\x0a127.0.0.1 - admin\x7f\xc3\xa9 HTTP/1.0" 200 -`
+	if got := string(CommonLogFormat.Format(e)); got != expected {
+		t.Errorf("escaped:\nexpected %s\ngot      %s", expected, got)
+	}
+}
+
+func TestAccessLog_JSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := hmux.New()
+	m.Use(RequestID, AccessLog(&buf, JSONLogFormat))
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7?x=1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.RemoteAddr = "10.1.2.3:5555"
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.HasSuffix(buf.String(), "\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("expected a single line, got %q", buf.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"remote_addr": "10.1.2.3",
+		"method":      "GET",
+		"uri":         "/users/7?x=1",
+		"route":       "GET /users/{id}",
+		"status":      202.0,
+		"bytes":       5.0,
+		"request_id":  "req-1",
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, got[k])
+		}
+	}
+	if _, ok := got["latency_ms"]; !ok {
+		t.Error("expected latency_ms field")
+	}
+}
+
+func TestAccessLog_CustomFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := LogFormatterFunc(func(e *LogEntry) []byte {
+		return []byte(e.Method + " " + e.URI)
+	})
+
+	h := AccessLog(&buf, f)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/x", nil))
+
+	if buf.String() != "DELETE /x\n" {
+		t.Errorf("unexpected line %q", buf.String())
+	}
+}
+
+func TestAccessLog_Redacted(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := hmux.New()
+	m.Use(AccessLog(&buf, CombinedLogFormat))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	m.Meta(hmux.M{RedactorMeta: NewRedactor()}).HandleFunc("GET /reset", ok)
+	m.HandleFunc("GET /plain", ok)
+
+	for _, target := range []string{"/reset?token=abc&email=ada%40example.com", "/plain?token=abc"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Referer", "https://example.com/login?password=hunter2")
+		m.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	if !strings.Contains(lines[0], `"GET /reset?token=[REDACTED]&email=[REDACTED] HTTP/1.1"`) ||
+		!strings.Contains(lines[0], `"https://example.com/login?password=[REDACTED]"`) {
+		t.Errorf("expected a redacted line, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "/plain?token=abc") {
+		t.Errorf("expected routes without a Redactor to be logged as is, got %s", lines[1])
+	}
+}
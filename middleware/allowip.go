@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// AllowIP returns middleware that rejects requests with 403 Forbidden
+// unless the client address, taken from r.RemoteAddr, belongs to one of
+// the allowed networks. It guards internal endpoints such as metrics and
+// profiling:
+//
+//	mux.With(middleware.AllowIP("127.0.0.1", "10.0.0.0/8")).
+//	    Handle("GET /debug/vars", expvar.Handler())
+//
+// Each entry in allowed is a CIDR prefix or a single address, as for
+// RealIP. Behind a reverse proxy, use RealIP first so that the client's
+// address is checked rather than the proxy's.
+//
+// AllowIP panics if any entry in allowed cannot be parsed.
+func AllowIP(allowed ...string) func(http.Handler) http.Handler {
+	prefixes := make([]netip.Prefix, 0, len(allowed))
+	for _, s := range allowed {
+		p, err := parseTrusted(s)
+		if err != nil {
+			panic("hmux: invalid allowed address " + s + ": " + err.Error())
+		}
+
+		prefixes = append(prefixes, p)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := parseRemoteAddr(r.RemoteAddr); ok {
+				for _, p := range prefixes {
+					if p.Contains(addr) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+}
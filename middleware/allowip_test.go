@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowIP(t *testing.T) {
+	h := AllowIP("127.0.0.1", "10.0.0.0/8", "::1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		remoteAddr string
+		expected   int
+	}{
+		{"127.0.0.1:1234", http.StatusOK},
+		{"10.1.2.3:1234", http.StatusOK},
+		{"[::1]:1234", http.StatusOK},
+		{"[::ffff:10.0.0.1]:1234", http.StatusOK},
+		{"10.0.0.1", http.StatusOK},
+		{"192.168.1.1:1234", http.StatusForbidden},
+		{"garbage", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.remoteAddr, tt.expected, rec.Code)
+		}
+	}
+}
+
+func TestAllowIP_InvalidPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	AllowIP("not-an-ip")
+}
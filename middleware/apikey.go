@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Errors returned when validating an API key.
+var (
+	ErrAPIKeyMissing = errors.New("hmux: API key missing")
+	ErrAPIKeyInvalid = errors.New("hmux: API key invalid")
+)
+
+// KeyValidator validates an API key and returns the claims of its
+// principal, which should include a "sub" claim identifying it. Unknown
+// or revoked keys are reported with ErrAPIKeyInvalid; other errors mean
+// the key could not be checked, for example because a database is down.
+type KeyValidator interface {
+	Validate(ctx context.Context, key string) (hmux.Claims, error)
+}
+
+// KeyValidatorFunc adapts a function to the KeyValidator interface.
+type KeyValidatorFunc func(ctx context.Context, key string) (hmux.Claims, error)
+
+// Validate implements KeyValidator.
+func (f KeyValidatorFunc) Validate(ctx context.Context, key string) (hmux.Claims, error) {
+	return f(ctx, key)
+}
+
+// StaticKeys returns a KeyValidator accepting the keys of the given map,
+// each naming its principal, which becomes the "sub" claim. Keys are
+// compared in constant time.
+func StaticKeys(keys map[string]string) KeyValidator {
+	type entry struct {
+		hash    [sha256.Size]byte
+		subject string
+	}
+
+	entries := make([]entry, 0, len(keys))
+	for key, subject := range keys {
+		entries = append(entries, entry{sha256.Sum256([]byte(key)), subject})
+	}
+
+	return KeyValidatorFunc(func(_ context.Context, key string) (hmux.Claims, error) {
+		hash := sha256.Sum256([]byte(key))
+
+		subject, found := "", false
+		for _, e := range entries {
+			if subtle.ConstantTimeCompare(hash[:], e.hash[:]) == 1 {
+				subject, found = e.subject, true
+			}
+		}
+		if !found {
+			return nil, ErrAPIKeyInvalid
+		}
+
+		return hmux.Claims{"sub": subject}, nil
+	})
+}
+
+// APIKeyAuth authenticates requests by API key, read from the X-API-Key
+// header by default, and stores the claims of the key's principal in the
+// request context, where hmux.ClaimsFromContext returns them. Requests
+// with a missing or invalid key are rejected with 401 Unauthorized, and
+// requests whose key cannot be checked with 503 Service Unavailable.
+//
+// Combined with KeyBySubject, the rate limiter applies limits per
+// principal:
+//
+//	auth := middleware.NewAPIKeyAuth(middleware.StaticKeys(keys))
+//	api.Use(auth.Handler, middleware.RateLimit(100, time.Minute, middleware.KeyBySubject))
+type APIKeyAuth struct {
+	validator KeyValidator
+	header    string
+	query     string
+}
+
+// NewAPIKeyAuth returns an APIKeyAuth validating keys with validator.
+//
+// NewAPIKeyAuth panics if validator is nil.
+func NewAPIKeyAuth(validator KeyValidator) *APIKeyAuth {
+	if validator == nil {
+		panic("hmux: nil KeyValidator passed to NewAPIKeyAuth")
+	}
+
+	return &APIKeyAuth{validator: validator, header: "X-API-Key"}
+}
+
+// SetHeader sets the request header carrying the key. An empty name
+// disables the header.
+func (a *APIKeyAuth) SetHeader(name string) {
+	a.header = name
+}
+
+// SetQueryParam sets a query parameter carrying the key, consulted when
+// the header is absent. Keys in URLs end up in access logs and browser
+// histories, so prefer the header where clients support it.
+func (a *APIKeyAuth) SetQueryParam(name string) {
+	a.query = name
+}
+
+// Handler is middleware that requires a valid API key.
+func (a *APIKeyAuth) Handler(next http.Handler) http.Handler {
+	return a.middleware(next, true)
+}
+
+// Optional is middleware that validates an API key if one is present.
+// Requests with an invalid key are rejected; requests without a key
+// proceed with no claims in the context.
+func (a *APIKeyAuth) Optional(next http.Handler) http.Handler {
+	return a.middleware(next, false)
+}
+
+func (a *APIKeyAuth) middleware(next http.Handler, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := a.key(r)
+		if key == "" && !required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := a.validate(r.Context(), key)
+		switch {
+		case errors.Is(err, ErrAPIKeyMissing), errors.Is(err, ErrAPIKeyInvalid):
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(hmux.ContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// key returns the API key of r, or "" if it carries none.
+func (a *APIKeyAuth) key(r *http.Request) string {
+	if a.header != "" {
+		if key := r.Header.Get(a.header); key != "" {
+			return key
+		}
+	}
+	if a.query != "" {
+		return r.URL.Query().Get(a.query)
+	}
+
+	return ""
+}
+
+// validate checks key with the validator.
+func (a *APIKeyAuth) validate(ctx context.Context, key string) (hmux.Claims, error) {
+	if key == "" {
+		return nil, ErrAPIKeyMissing
+	}
+
+	claims, err := a.validator.Validate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		claims = hmux.Claims{}
+	}
+
+	return claims, nil
+}
+
+// KeyBySubject is a KeyFunc that limits by the subject of the claims in
+// the request context, as stored by APIKeyAuth or JWTAuth. Place it after
+// the authentication middleware.
+func KeyBySubject(r *http.Request) string {
+	return hmux.ClaimsFromContext(r.Context()).Subject()
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys := StaticKeys(map[string]string{"k-alice": "alice", "k-bob": "bob"})
+	validator := KeyValidatorFunc(func(ctx context.Context, key string) (hmux.Claims, error) {
+		if key == "k-down" {
+			return nil, errors.New("database unavailable")
+		}
+		return keys.Validate(ctx, key)
+	})
+
+	auth := NewAPIKeyAuth(validator)
+	auth.SetQueryParam("api_key")
+	subject := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(hmux.ClaimsFromContext(r.Context()).Subject()))
+	})
+
+	tests := []struct {
+		name     string
+		optional bool
+		header   string
+		target   string
+		code     int
+		body     string
+	}{
+		{"header", false, "k-alice", "/", http.StatusOK, "alice"},
+		{"query", false, "", "/?api_key=k-bob", http.StatusOK, "bob"},
+		{"header wins", false, "k-alice", "/?api_key=k-bob", http.StatusOK, "alice"},
+		{"missing", false, "", "/", http.StatusUnauthorized, "Unauthorized\n"},
+		{"invalid", false, "k-eve", "/", http.StatusUnauthorized, "Unauthorized\n"},
+		{"backend error", false, "k-down", "/", http.StatusServiceUnavailable, "Service Unavailable\n"},
+		{"optional anonymous", true, "", "/", http.StatusOK, ""},
+		{"optional invalid", true, "k-eve", "/", http.StatusUnauthorized, "Unauthorized\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := auth.Handler(subject)
+			if tt.optional {
+				h = auth.Optional(subject)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_RateLimitPerKey(t *testing.T) {
+	auth := NewAPIKeyAuth(StaticKeys(map[string]string{"k-alice": "alice", "k-bob": "bob"}))
+	h := auth.Handler(RateLimit(1, time.Minute, KeyBySubject)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	codes := ""
+	for _, key := range []string{"k-alice", "k-alice", "k-bob"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		codes += strconv.Itoa(rec.Code) + " "
+	}
+
+	if codes != "200 429 200 " {
+		t.Errorf("expected per-key limits, got %s", codes)
+	}
+}
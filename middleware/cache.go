@@ -0,0 +1,427 @@
+package middleware
+
+import (
+	"container/list"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// CacheStatusHeader reports whether a response was served from the cache
+// ("HIT"), stored by this request ("MISS"), or deliberately not looked
+// up ("BYPASS").
+const CacheStatusHeader = "X-Cache"
+
+// CachedResponse is a stored response.
+type CachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Stored  time.Time
+	Expires time.Time
+
+	// Vary lists the request headers the response varies on. An entry
+	// with a non-empty Vary and no Status is an index entry pointing at
+	// per-variant entries.
+	Vary []string
+}
+
+// size approximates the memory held by the entry.
+func (c *CachedResponse) size() int {
+	n := len(c.Body)
+	for k, vs := range c.Header {
+		n += len(k)
+		for _, v := range vs {
+			n += len(v)
+		}
+	}
+
+	return n
+}
+
+// CacheStore holds cached responses. The default MemoryCacheStore keeps
+// them in process memory; a shared store lets several instances share a
+// cache.
+type CacheStore interface {
+	// Get returns the entry for key if present. Expired entries may be
+	// returned; the caller checks Expires.
+	Get(key string) (*CachedResponse, bool)
+
+	// Set stores an entry under key.
+	Set(key string, resp *CachedResponse)
+
+	// DeletePrefix removes every entry whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// Cache is middleware caching successful GET responses in a CacheStore
+// for a fixed TTL. Entries are keyed by path, host, tenant and query, plus
+// the values of any request headers named in the response's Vary header,
+// so sites served by Host routers and tenants served by Tenants never
+// share responses.
+//
+// A response is only stored when it has status 200, is no larger than the
+// configured body size, sets no cookies, and does not carry
+// Cache-Control: no-store or private. Requests sending Cache-Control:
+// no-cache skip the lookup but still refresh the entry. Each response
+// reports X-Cache: HIT, MISS or BYPASS, and hits carry an Age header.
+//
+// Requests with an Authorization or Cookie header only share responses
+// marked Cache-Control: public, as RFC 9111 requires of shared caches:
+// other responses to them are neither stored nor served from the cache.
+// Only headers set by the handler are stored, so per-request headers set
+// by outer middleware, such as a request ID, are not replayed on hits.
+//
+// A successful unsafe request (POST, PUT, PATCH, DELETE) invalidates the
+// cached GET responses for its path on the same host and tenant.
+// Invalidate purges entries explicitly, for example after an out-of-band
+// data change.
+//
+// Example:
+//
+//	cache := middleware.NewCache(time.Minute, 1<<20)
+//	api.Use(cache.Handler)
+type Cache struct {
+	ttl     time.Duration
+	maxBody int
+	store   CacheStore
+	now     func() time.Time
+}
+
+// NewCache returns a Cache storing responses of at most maxBody bytes for
+// ttl, backed by a MemoryCacheStore limited to 64 MiB.
+//
+// NewCache panics if ttl or maxBody is not positive.
+func NewCache(ttl time.Duration, maxBody int) *Cache {
+	if ttl <= 0 || maxBody <= 0 {
+		panic("hmux: cache ttl and max body must be positive")
+	}
+
+	return &Cache{
+		ttl:     ttl,
+		maxBody: maxBody,
+		store:   NewMemoryCacheStore(64 << 20),
+		now:     time.Now,
+	}
+}
+
+// SetStore replaces the cache store. It must be called before the cache
+// starts serving requests.
+//
+// SetStore panics if store is nil.
+func (c *Cache) SetStore(store CacheStore) {
+	if store == nil {
+		panic("hmux: nil CacheStore passed to SetStore")
+	}
+
+	c.store = store
+}
+
+// Invalidate removes all cached responses for the given URL paths on
+// every host and tenant, including every query string and variant.
+func (c *Cache) Invalidate(paths ...string) {
+	for _, p := range paths {
+		c.store.DeletePrefix(pathKey(p))
+	}
+}
+
+// pathKey returns the prefix of the keys of the cached responses for
+// path. The path comes first so that Invalidate can purge it on every
+// host and tenant.
+func pathKey(path string) string {
+	return http.MethodGet + " " + strconv.Quote(path) + " "
+}
+
+// scopeKey returns the prefix of the keys of the cached responses for
+// the path of r on its host and tenant. The parts are quoted so that
+// none can pose as another.
+func scopeKey(r *http.Request) string {
+	return pathKey(r.URL.Path) + strconv.Quote(strings.ToLower(r.Host)) + " " + strconv.Quote(hmux.TenantFromContext(r.Context())) + " "
+}
+
+// Handler is the caching middleware.
+func (c *Cache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			c.invalidateAfter(next, w, r)
+			return
+		}
+
+		base := scopeKey(r) + "?" + r.URL.RawQuery
+		credentialed := r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+		if hasDirective(r.Header.Get("Cache-Control"), "no-cache") {
+			w.Header().Set(CacheStatusHeader, "BYPASS")
+		} else if entry, ok := c.lookup(base, r); ok && (!credentialed || public(entry.Header)) {
+			c.serve(w, entry)
+			return
+		} else if credentialed {
+			w.Header().Set(CacheStatusHeader, "BYPASS")
+		} else {
+			w.Header().Set(CacheStatusHeader, "MISS")
+		}
+
+		before := w.Header().Clone()
+		bw := hmux.NewBufferedWriter(w, c.maxBody)
+		next.ServeHTTP(bw, r)
+
+		if bw.Buffered() && c.storable(bw) && (!credentialed || public(bw.Header())) {
+			c.save(base, r, bw, handlerHeader(before, bw.Header()))
+		}
+
+		_ = bw.Commit()
+	})
+}
+
+// invalidateAfter runs an unsafe request and purges the cache for its
+// path on its host and tenant if it succeeded.
+func (c *Cache) invalidateAfter(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodHead, http.MethodOptions, http.MethodTrace:
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	sw := hmux.NewStatusWriter(w)
+	next.ServeHTTP(sw, r)
+
+	if sw.Status() < 400 {
+		c.store.DeletePrefix(scopeKey(r))
+	}
+}
+
+// lookup finds a fresh entry for the request, resolving Vary.
+func (c *Cache) lookup(base string, r *http.Request) (*CachedResponse, bool) {
+	entry, ok := c.store.Get(base)
+	if !ok {
+		return nil, false
+	}
+
+	if entry.Status == 0 && len(entry.Vary) > 0 {
+		entry, ok = c.store.Get(variantKey(base, entry.Vary, r.Header))
+		if !ok {
+			return nil, false
+		}
+	}
+
+	if !c.now().Before(entry.Expires) {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// serve writes a cached entry.
+func (c *Cache) serve(w http.ResponseWriter, entry *CachedResponse) {
+	h := w.Header()
+	for k, v := range entry.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(CacheStatusHeader, "HIT")
+	h.Set("Age", strconv.Itoa(int(c.now().Sub(entry.Stored).Seconds())))
+
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// storable reports whether a captured response may be cached.
+func (c *Cache) storable(bw *hmux.BufferedWriter) bool {
+	h := bw.Header()
+	cc := h.Get("Cache-Control")
+
+	return bw.Status() == http.StatusOK &&
+		h.Get("Set-Cookie") == "" &&
+		!hasDirective(cc, "no-store") &&
+		!hasDirective(cc, "private") &&
+		h.Get("Vary") != "*"
+}
+
+// public reports whether a response explicitly allows shared caching
+// of responses to requests carrying credentials.
+func public(h http.Header) bool {
+	return hasDirective(h.Get("Cache-Control"), "public")
+}
+
+// save stores a captured response with the given headers, writing an
+// index entry first when the response varies on request headers.
+func (c *Cache) save(base string, r *http.Request, bw *hmux.BufferedWriter, header http.Header) {
+	now := c.now()
+	entry := &CachedResponse{
+		Status:  bw.Status(),
+		Header:  header,
+		Body:    append([]byte(nil), bw.Bytes()...),
+		Stored:  now,
+		Expires: now.Add(c.ttl),
+	}
+
+	vary := varyHeaders(header)
+	if len(vary) == 0 {
+		c.store.Set(base, entry)
+		return
+	}
+
+	entry.Vary = vary
+	c.store.Set(base, &CachedResponse{Vary: vary, Stored: now, Expires: entry.Expires})
+	c.store.Set(variantKey(base, vary, r.Header), entry)
+}
+
+// hopHeaders are connection-specific headers that are never stored with
+// a response.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// handlerHeader returns the headers of after that a handler added or
+// changed relative to before, the headers present when it was called,
+// without hop-by-hop headers. Headers set by outer middleware, such as a
+// request ID, belong to a single request and are left out.
+func handlerHeader(before, after http.Header) http.Header {
+	h := make(http.Header, len(after))
+	for k, v := range after {
+		if !slices.Equal(before[k], v) {
+			h[k] = slices.Clone(v)
+		}
+	}
+	for _, k := range hopHeaders {
+		delete(h, k)
+	}
+
+	return h
+}
+
+// varyHeaders returns the canonical header names listed in Vary.
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, textproto.CanonicalMIMEHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+// variantKey extends a base key with the request's values for the
+// varying headers.
+func variantKey(base string, vary []string, h http.Header) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(h.Values(name), ","))
+	}
+
+	return b.String()
+}
+
+// hasDirective reports whether a Cache-Control value contains directive.
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MemoryCacheStore is an in-process CacheStore bounded by the total size
+// of cached bodies and headers. When full, the least recently used entries
+// are evicted.
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+// memoryCacheItem is the list payload of a MemoryCacheStore.
+type memoryCacheItem struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCacheStore returns an empty store holding at most maxBytes.
+func NewMemoryCacheStore(maxBytes int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements CacheStore.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	s.order.MoveToFront(el)
+
+	return el.Value.(*memoryCacheItem).resp, true
+}
+
+// Set implements CacheStore. Entries larger than the whole store are not
+// kept.
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+
+	size := resp.size() + len(key)
+	if size > s.maxBytes {
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryCacheItem{key: key, resp: resp})
+	s.size += size
+
+	for s.size > s.maxBytes {
+		s.remove(s.order.Back().Value.(*memoryCacheItem).key)
+	}
+}
+
+// DeletePrefix implements CacheStore.
+func (s *MemoryCacheStore) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(key)
+		}
+	}
+}
+
+// remove deletes key if present. The caller holds s.mu.
+func (s *MemoryCacheStore) remove(key string) {
+	el, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	item := s.order.Remove(el).(*memoryCacheItem)
+	delete(s.entries, key)
+	s.size -= item.resp.size() + len(key)
+}
@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// countingHandler responds with the number of times it has been called.
+func countingHandler(calls *int, setup func(w http.ResponseWriter)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if setup != nil {
+			setup(w)
+		}
+		fmt.Fprintf(w, "call %d", *calls)
+	})
+}
+
+func doGet(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCache_HitAndMiss(t *testing.T) {
+	var calls int
+	c := NewCache(time.Minute, 1024)
+	h := c.Handler(countingHandler(&calls, nil))
+
+	first := doGet(h, "/users?page=1", nil)
+	if first.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Errorf("expected MISS, got %q", first.Header().Get(CacheStatusHeader))
+	}
+
+	second := doGet(h, "/users?page=1", nil)
+	if second.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Errorf("expected HIT, got %q", second.Header().Get(CacheStatusHeader))
+	}
+	if second.Body.String() != "call 1" {
+		t.Errorf("expected cached body, got %q", second.Body.String())
+	}
+	if second.Header().Get("Age") == "" {
+		t.Error("expected Age header on hit")
+	}
+
+	if doGet(h, "/users?page=2", nil).Body.String() != "call 2" {
+		t.Error("expected different query to miss")
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	var calls int
+	now := time.Unix(0, 0)
+	c := NewCache(time.Minute, 1024)
+	c.now = func() time.Time { return now }
+	h := c.Handler(countingHandler(&calls, nil))
+
+	doGet(h, "/", nil)
+	now = now.Add(2 * time.Minute)
+
+	if rec := doGet(h, "/", nil); rec.Body.String() != "call 2" {
+		t.Errorf("expected expired entry to be refreshed, got %q", rec.Body.String())
+	}
+}
+
+func TestCache_Vary(t *testing.T) {
+	var calls int
+	h := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, func(w http.ResponseWriter) {
+		w.Header().Set("Vary", "Accept-Language")
+	}))
+
+	en := http.Header{"Accept-Language": {"en"}}
+	de := http.Header{"Accept-Language": {"de"}}
+
+	doGet(h, "/", en)
+	doGet(h, "/", de)
+	if rec := doGet(h, "/", en); rec.Body.String() != "call 1" {
+		t.Errorf("expected en variant from cache, got %q", rec.Body.String())
+	}
+	if rec := doGet(h, "/", de); rec.Body.String() != "call 2" {
+		t.Errorf("expected de variant from cache, got %q", rec.Body.String())
+	}
+}
+
+func TestCache_NotStored(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(w http.ResponseWriter)
+	}{
+		{"no-store", func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "no-store") }},
+		{"private", func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "private, max-age=60") }},
+		{"cookie", func(w http.ResponseWriter) { w.Header().Set("Set-Cookie", "a=b") }},
+		{"error", func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			h := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, tt.setup))
+
+			doGet(h, "/", nil)
+			doGet(h, "/", nil)
+			if calls != 2 {
+				t.Errorf("expected response not to be cached, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestCache_TooLarge(t *testing.T) {
+	var calls int
+	h := NewCache(time.Minute, 4).Handler(countingHandler(&calls, nil))
+
+	doGet(h, "/", nil)
+	if rec := doGet(h, "/", nil); rec.Body.String() != "call 2" {
+		t.Errorf("expected oversize response not to be cached, got %q", rec.Body.String())
+	}
+}
+
+func TestCache_NoCacheRequestBypasses(t *testing.T) {
+	var calls int
+	h := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, nil))
+
+	doGet(h, "/", nil)
+	rec := doGet(h, "/", http.Header{"Cache-Control": {"no-cache"}})
+	if rec.Header().Get(CacheStatusHeader) != "BYPASS" || rec.Body.String() != "call 2" {
+		t.Errorf("expected bypass, got %q %q", rec.Header().Get(CacheStatusHeader), rec.Body.String())
+	}
+	if rec := doGet(h, "/", nil); rec.Body.String() != "call 2" {
+		t.Errorf("expected bypass to refresh entry, got %q", rec.Body.String())
+	}
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	var calls int
+	c := NewCache(time.Minute, 1024)
+	h := c.Handler(countingHandler(&calls, nil))
+
+	doGet(h, "/users", nil)
+	doGet(h, "/users?page=2", nil)
+	doGet(h, "/users/1", nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("{}"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if rec := doGet(h, "/users", nil); rec.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Error("expected POST to invalidate /users")
+	}
+	if rec := doGet(h, "/users?page=2", nil); rec.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Error("expected POST to invalidate /users?page=2")
+	}
+	if rec := doGet(h, "/users/1", nil); rec.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Error("expected /users/1 to remain cached")
+	}
+
+	c.Invalidate("/users/1")
+	if rec := doGet(h, "/users/1", nil); rec.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Error("expected explicit invalidation")
+	}
+}
+
+func TestCache_HostsAndTenants(t *testing.T) {
+	c := NewCache(time.Minute, 1024)
+	invoices := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("invoices of " + hmux.TenantFromContext(r.Context())))
+	}
+
+	subdomains := hmux.NewTenants(hmux.TenantFromSubdomain("example.com"))
+	subdomains.Shared().Use(c.Handler)
+	subdomains.Shared().HandleFunc("GET /invoices", invoices)
+
+	paths := hmux.NewTenants(hmux.TenantFromPath())
+	paths.Shared().Use(c.Handler)
+	paths.Shared().HandleFunc("GET /invoices", invoices)
+
+	tests := []struct {
+		h      http.Handler
+		target string
+		body   string
+	}{
+		{subdomains, "http://acme.example.com/invoices", "invoices of acme"},
+		{subdomains, "http://globex.example.com/invoices", "invoices of globex"},
+		{paths, "http://example.com/acme/invoices", "invoices of acme"},
+		{paths, "http://example.com/globex/invoices", "invoices of globex"},
+	}
+	for _, tt := range tests {
+		for range 2 {
+			if rec := doGet(tt.h, tt.target, nil); rec.Body.String() != tt.body {
+				t.Errorf("%s: expected %q, got %q", tt.target, tt.body, rec.Body.String())
+			}
+		}
+	}
+
+	// An unsafe request only invalidates its own host.
+	subdomains.Shared().HandleFunc("POST /invoices", func(w http.ResponseWriter, r *http.Request) {})
+	subdomains.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "http://acme.example.com/invoices", nil))
+
+	if rec := doGet(subdomains, "http://acme.example.com/invoices", nil); rec.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Error("expected POST to invalidate its host")
+	}
+	if rec := doGet(subdomains, "http://globex.example.com/invoices", nil); rec.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Error("expected other hosts to remain cached")
+	}
+
+	c.Invalidate("/invoices")
+	if rec := doGet(paths, "http://example.com/globex/invoices", nil); rec.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Error("expected Invalidate to purge every tenant")
+	}
+}
+
+func TestMemoryCacheStore_Eviction(t *testing.T) {
+	s := NewMemoryCacheStore(20)
+	s.Set("a", &CachedResponse{Body: []byte("123456789")})
+	s.Set("b", &CachedResponse{Body: []byte("123456789")})
+	s.Get("a")
+	s.Set("c", &CachedResponse{Body: []byte("123456789")})
+
+	if _, ok := s.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Error("expected recently used entry to be kept")
+	}
+}
+
+func TestCache_OuterHeadersNotStored(t *testing.T) {
+	var calls, requests int
+	inner := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/plain")
+	}))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Request-ID", fmt.Sprint(requests))
+		w.Header().Set("Connection", "close")
+		inner.ServeHTTP(w, r)
+	})
+
+	doGet(h, "/", nil)
+	rec := doGet(h, "/", nil)
+	if rec.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Fatalf("expected HIT, got %q", rec.Header().Get(CacheStatusHeader))
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "2" {
+		t.Errorf("expected the live request ID 2, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("expected the handler's Content-Type, got %q", got)
+	}
+}
+
+func TestCache_Credentials(t *testing.T) {
+	for _, header := range []string{"Authorization", "Cookie"} {
+		t.Run(header, func(t *testing.T) {
+			var calls int
+			h := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, nil))
+			creds := http.Header{header: {"secret"}}
+
+			if rec := doGet(h, "/", creds); rec.Header().Get(CacheStatusHeader) != "BYPASS" {
+				t.Errorf("expected BYPASS, got %q", rec.Header().Get(CacheStatusHeader))
+			}
+			if rec := doGet(h, "/", nil); rec.Body.String() != "call 2" {
+				t.Errorf("expected a credentialed response not to be shared, got %q", rec.Body.String())
+			}
+			if rec := doGet(h, "/", creds); rec.Body.String() != "call 3" {
+				t.Errorf("expected a cached response not to be served to a credentialed request, got %q", rec.Body.String())
+			}
+		})
+	}
+
+	var calls int
+	h := NewCache(time.Minute, 1024).Handler(countingHandler(&calls, func(w http.ResponseWriter) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+	}))
+	creds := http.Header{"Authorization": {"Bearer x"}}
+
+	doGet(h, "/", creds)
+	if rec := doGet(h, "/", creds); rec.Body.String() != "call 1" {
+		t.Errorf("expected a public response to be shared, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Coalesce returns middleware that collapses concurrent identical GET
+// requests into one: while a request for a key is being served, requests
+// with the same key wait for it and receive a copy of its response
+// instead of running the handler. This protects a backend from the
+// thundering herd that follows a cache miss on a popular resource:
+//
+//	api.With(middleware.Coalesce(1<<20, nil)).HandleFunc("GET /feed", feed)
+//
+// key identifies identical requests; requests for which it returns an
+// empty string are not coalesced. A nil key uses KeyByRequest, which
+// tells clients apart by their credentials, so a response is never
+// shared between users unless a custom key says so.
+//
+// Responses are shared if their body is at most maxBody bytes. When the
+// response cannot be shared, because it is too large, the connection was
+// hijacked, the handler panicked or returned without writing anything,
+// as it may when its request is canceled, the waiting requests run the
+// handler themselves. A waiting request whose client goes away stops
+// waiting. Only the headers set by the handler are shared; each request
+// keeps those set by outer middleware, such as its request ID.
+//
+// Coalesce panics if maxBody is not positive.
+func Coalesce(maxBody int, key KeyFunc) func(http.Handler) http.Handler {
+	if maxBody <= 0 {
+		panic("hmux: coalesce max body must be positive")
+	}
+	if key == nil {
+		key = KeyByRequest
+	}
+
+	var (
+		mu      sync.Mutex
+		flights = make(map[string]*flight)
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := ""
+			if r.Method == http.MethodGet {
+				k = key(r)
+			}
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mu.Lock()
+			f, waiting := flights[k]
+			if !waiting {
+				f = &flight{done: make(chan struct{})}
+				flights[k] = f
+			}
+			mu.Unlock()
+
+			if waiting {
+				select {
+				case <-f.done:
+				case <-r.Context().Done():
+					return
+				}
+
+				if f.status == 0 {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				h := w.Header()
+				for name, v := range f.header {
+					h[name] = append([]string(nil), v...)
+				}
+				w.WriteHeader(f.status)
+				w.Write(f.body)
+				return
+			}
+
+			defer func() {
+				mu.Lock()
+				delete(flights, k)
+				mu.Unlock()
+				close(f.done)
+			}()
+
+			before := w.Header().Clone()
+			cw := hmux.NewCaptureWriter(w, maxBody)
+			next.ServeHTTP(cw, r)
+
+			if cw.Complete() && cw.Status() != 0 {
+				f.status = cw.Status()
+				f.header = handlerHeader(before, cw.SentHeader())
+				f.body = bytes.Clone(cw.Bytes())
+			}
+		})
+	}
+}
+
+// flight is a request being served by Coalesce. Its response fields are
+// set before done is closed, and are zero if the response cannot be
+// shared.
+type flight struct {
+	done   chan struct{}
+	status int
+	header http.Header
+	body   []byte
+}
+
+// KeyByRequest is a KeyFunc identifying a request by its method, URL and
+// the headers that commonly select or authorize a response: Accept,
+// Accept-Encoding, Accept-Language, Authorization and Cookie.
+func KeyByRequest(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.Host)
+	b.WriteString(r.URL.RequestURI())
+	for _, name := range [...]string{"Accept", "Accept-Encoding", "Accept-Language", "Authorization", "Cookie"} {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	inner := Coalesce(1<<10, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("X-Test", "yes")
+		w.Write([]byte("feed"))
+	}))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		inner.ServeHTTP(w, r)
+	})
+
+	const n = 5
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range recs {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+			req.Header.Set("X-Request-ID", fmt.Sprint(i))
+			h.ServeHTTP(recs[i], req)
+		}()
+	}
+
+	// Give the requests time to join the flight of the first.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 handler call, got %d", got)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != "feed" || rec.Header().Get("X-Test") != "yes" {
+			t.Errorf("expected shared response, got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+		}
+		if got := rec.Header().Get("X-Request-ID"); got != fmt.Sprint(i) {
+			t.Errorf("expected request ID %d, got %q", i, got)
+		}
+	}
+}
+
+func TestCoalesce_EmptyResponseNotShared(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := Coalesce(1<<10, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The leader gives up without writing, as a handler does
+			// when its request is canceled.
+			close(started)
+			<-release
+			return
+		}
+		w.Write([]byte("feed"))
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed", nil))
+	<-started
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+		done <- rec
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if rec := <-done; rec.Body.String() != "feed" {
+		t.Errorf("expected the waiter to run the handler, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 handler calls, got %d", got)
+	}
+}
+
+func TestCoalesce_NotShared(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		header  string
+		maxBody int
+	}{
+		{"post", http.MethodPost, "", 1 << 10},
+		{"other credentials", http.MethodGet, "Bearer b", 1 << 10},
+		{"oversized", http.MethodGet, "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			started := make(chan struct{})
+			release := make(chan struct{})
+			h := Coalesce(tt.maxBody, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					close(started)
+					<-release
+				}
+				w.Write([]byte("feed"))
+			}))
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(tt.method, "/feed", nil)
+				req.Header.Set("Authorization", "Bearer a")
+				h.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+			<-started
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(tt.method, "/feed", nil)
+				req.Header.Set("Authorization", "Bearer a")
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Body.String() != "feed" {
+					t.Errorf("expected feed, got %q", rec.Body.String())
+				}
+			}()
+
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := calls.Load(); got != 2 {
+				t.Errorf("expected 2 handler calls, got %d", got)
+			}
+		})
+	}
+}
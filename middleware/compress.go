@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// EncoderFunc creates a writer that compresses everything written to it
+// into w at the given level. The meaning of level is specific to the
+// encoding: gzip and deflate accept 1 (fastest) through 9 (best), while
+// brotli accepts 0 through 11.
+//
+// If the returned writer implements interface{ Flush() error }, it is
+// flushed whenever the handler flushes the response.
+type EncoderFunc func(w io.Writer, level int) io.WriteCloser
+
+// encoder is a registered content coding.
+type encoder struct {
+	name  string
+	level int
+	fn    EncoderFunc
+}
+
+// defaultCompressibleTypes are the media types compressed when no
+// explicit list is given to NewCompressor.
+var defaultCompressibleTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/atom+xml",
+	"application/rss+xml",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+// Compressor is a registry of response encoders together with the rules
+// deciding which responses are compressed. The encoding used for a given
+// request is negotiated from its Accept-Encoding header.
+//
+// A Compressor must be fully configured before its Handler is used; the
+// configuration methods are not safe for concurrent use with serving.
+type Compressor struct {
+	encoders []encoder // in order of server preference
+	types    []string
+}
+
+// NewCompressor returns a Compressor with gzip and deflate registered at
+// the given level, compressing responses whose Content-Type matches one
+// of types. Types may be exact ("application/json") or wildcard
+// subtypes ("text/*"). When types is empty a default list of textual
+// media types is used.
+//
+// Additional encoders such as brotli or zstd are plugged in with
+// SetEncoder:
+//
+//	c := middleware.NewCompressor(5)
+//	c.SetEncoder("br", 5, func(w io.Writer, level int) io.WriteCloser {
+//	    return brotli.NewWriterLevel(w, level)
+//	})
+//	mux.Use(c.Handler)
+func NewCompressor(level int, types ...string) *Compressor {
+	if len(types) == 0 {
+		types = defaultCompressibleTypes
+	}
+
+	c := &Compressor{types: types}
+	c.SetEncoder("deflate", level, func(w io.Writer, level int) io.WriteCloser {
+		fw, err := flate.NewWriter(w, level)
+		if err != nil {
+			fw, _ = flate.NewWriter(w, flate.DefaultCompression)
+		}
+		return fw
+	})
+	c.SetEncoder("gzip", level, func(w io.Writer, level int) io.WriteCloser {
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			gw = gzip.NewWriter(w)
+		}
+		return gw
+	})
+
+	return c
+}
+
+// SetEncoder registers fn as the encoder for the named content coding,
+// replacing any existing encoder of the same name. Newly registered
+// encodings take precedence over previously registered ones when a client
+// accepts several of them with equal weight, so registering "br" or
+// "zstd" after construction prefers them over gzip.
+//
+// SetEncoder panics if encoding is empty or fn is nil.
+func (c *Compressor) SetEncoder(encoding string, level int, fn EncoderFunc) {
+	if encoding == "" {
+		panic("hmux: empty encoding passed to SetEncoder")
+	}
+	if fn == nil {
+		panic("hmux: nil EncoderFunc passed to SetEncoder")
+	}
+
+	encoding = strings.ToLower(encoding)
+	for i, e := range c.encoders {
+		if e.name == encoding {
+			c.encoders = append(c.encoders[:i], c.encoders[i+1:]...)
+			break
+		}
+	}
+
+	c.encoders = append([]encoder{{name: encoding, level: level, fn: fn}}, c.encoders...)
+}
+
+// Handler is middleware that compresses responses using the best
+// registered encoding accepted by the client. Responses of compressible
+// types carry Vary: Accept-Encoding whether or not they are compressed,
+// so shared caches keep the variants apart. Protocol upgrade requests,
+// such as WebSocket handshakes, pass through untouched.
+func (c *Compressor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hmux.IsUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		enc, ok := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if !ok || r.Method == http.MethodHead {
+			enc = encoder{}
+		}
+
+		cw := &compressWriter{ResponseWriter: w, compressor: c, encoder: enc}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// Compress returns middleware compressing responses with gzip or deflate
+// at the given level. It is shorthand for NewCompressor(level, types...).Handler.
+func Compress(level int, types ...string) func(http.Handler) http.Handler {
+	return NewCompressor(level, types...).Handler
+}
+
+// negotiate picks the registered encoder with the highest client weight
+// in an Accept-Encoding header, breaking ties by server preference.
+func (c *Compressor) negotiate(header string) (encoder, bool) {
+	if header == "" {
+		return encoder{}, false
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		weights[name] = q
+	}
+
+	var (
+		best  encoder
+		bestQ float64
+	)
+	for _, e := range c.encoders {
+		q, ok := weights[e.name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// compressible reports whether a response with the given Content-Type
+// should be compressed.
+func (c *Compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range c.types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressWriter decides on the first write whether the response is
+// compressed and, if so, routes the body through the encoder. Its encoder
+// is the zero value when the response must not be compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	compressor  *Compressor
+	encoder     encoder
+	w           io.WriteCloser
+	wroteHeader bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	if status >= http.StatusOK &&
+		h.Get("Content-Encoding") == "" &&
+		cw.compressor.compressible(h.Get("Content-Type")) {
+
+		hmux.AddVary(h, "Accept-Encoding")
+
+		if cw.encoder.fn != nil &&
+			status != http.StatusNoContent &&
+			status != http.StatusNotModified &&
+			h.Get("Content-Range") == "" {
+
+			h.Set("Content-Encoding", cw.encoder.name)
+			h.Del("Content-Length")
+			cw.w = cw.encoder.fn(cw.ResponseWriter, cw.encoder.level)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.w != nil {
+		return cw.w.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush flushes buffered compressed data, then the underlying writer.
+// Flushing before the first write sends the headers, so the decision to
+// compress is made then.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close finalizes the compressed stream, if any.
+func (cw *compressWriter) Close() error {
+	if cw.w == nil {
+		return nil
+	}
+
+	return cw.w.Close()
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upperEncoder is a toy encoder used to verify registry plumbing.
+type upperEncoder struct{ w io.Writer }
+
+func (u upperEncoder) Write(p []byte) (int, error) {
+	return u.w.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func (u upperEncoder) Close() error { return nil }
+
+func textHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, body)
+	})
+}
+
+func TestCompress_Gzip(t *testing.T) {
+	h := Compress(5)(textHandler("hello world"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", rec.Header().Get("Vary"))
+	}
+
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if string(body) != "hello world" {
+		t.Errorf("expected %q, got %q", "hello world", body)
+	}
+}
+
+func TestCompress_FlushFirst(t *testing.T) {
+	h := Compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: hi\n\n")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding decided at flush, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != "data: hi\n\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestCompress_NoAcceptEncoding(t *testing.T) {
+	h := Compress(5)(textHandler("hello"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected no encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("expected %q, got %q", "hello", rec.Body.String())
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding on the identity response, got %q", rec.Header().Get("Vary"))
+	}
+}
+
+func TestCompress_SkipsIncompressibleType(t *testing.T) {
+	h := Compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected no encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "" {
+		t.Errorf("expected no Vary, got %q", rec.Header().Get("Vary"))
+	}
+}
+
+func TestCompress_CustomTypes(t *testing.T) {
+	h := Compress(5, "application/x-custom")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-custom")
+		w.Write([]byte("data"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("expected gzip encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressor_SetEncoder_Preferred(t *testing.T) {
+	c := NewCompressor(5)
+	c.SetEncoder("upper", 0, func(w io.Writer, level int) io.WriteCloser {
+		return upperEncoder{w}
+	})
+	h := c.Handler(textHandler("hello"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, upper")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "upper" {
+		t.Fatalf("expected upper encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Body.String() != "HELLO" {
+		t.Errorf("expected %q, got %q", "HELLO", rec.Body.String())
+	}
+}
+
+func TestCompressor_Negotiate(t *testing.T) {
+	c := NewCompressor(5)
+	c.SetEncoder("br", 5, func(w io.Writer, level int) io.WriteCloser {
+		return upperEncoder{w}
+	})
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"*", "br"},
+		{"identity", ""},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			enc, ok := c.negotiate(tt.header)
+			if !ok {
+				enc.name = ""
+			}
+			if enc.name != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, enc.name)
+			}
+		})
+	}
+}
+
+func TestCompressor_SetEncoder_Panics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for nil EncoderFunc")
+		}
+	}()
+	NewCompressor(5).SetEncoder("br", 5, nil)
+}
+
+func TestCompress_SkipsUpgrade(t *testing.T) {
+	h := Compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if ce := rec.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("expected no Content-Encoding for upgrade, got %q", ce)
+	}
+}
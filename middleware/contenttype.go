@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// AllowContentType returns middleware that rejects requests whose body
+// has a Content-Type outside the allowed set with 415 Unsupported Media
+// Type. Media type parameters such as charset are ignored and matching is
+// case-insensitive, so "application/json" allows
+// "Application/JSON; charset=utf-8". Requests without a body are always
+// allowed.
+//
+// Example:
+//
+//	api := mux.Group("/api")
+//	api.Use(middleware.AllowContentType("application/json"))
+func AllowContentType(types ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 && len(r.TransferEncoding) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if _, ok := allowed[mediaType]; err != nil || !ok {
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAllowContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"exact match", "application/json", "{}", http.StatusOK},
+		{"charset ignored", "application/json; charset=utf-8", "{}", http.StatusOK},
+		{"case insensitive", "Application/JSON", "{}", http.StatusOK},
+		{"second type", "text/plain", "hi", http.StatusOK},
+		{"disallowed", "application/xml", "<a/>", http.StatusUnsupportedMediaType},
+		{"missing", "", "{}", http.StatusUnsupportedMediaType},
+		{"malformed", "application/", "{}", http.StatusUnsupportedMediaType},
+		{"no body", "", "", http.StatusOK},
+	}
+
+	h := AllowContentType("application/json", "TEXT/PLAIN")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	csrf := NewCSRF(NewCookieCSRFStore([]byte("secret")))
+
+	var token string
+	h := csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	}))
+
+	// A safe request issues a token and cookie.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/form", nil))
+
+	if rec.Code != http.StatusOK || token == "" {
+		t.Fatalf("expected token on GET, got %d %q", rec.Code, token)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("expected HttpOnly CSRF cookie, got %v", cookies)
+	}
+	cookie := cookies[0]
+
+	tests := []struct {
+		name   string
+		header string
+		form   string
+		cookie *http.Cookie
+		code   int
+	}{
+		{"header token", token, "", cookie, http.StatusOK},
+		{"form token", "", token, cookie, http.StatusOK},
+		{"missing token", "", "", cookie, http.StatusForbidden},
+		{"wrong token", "forged", "", cookie, http.StatusForbidden},
+		{"no cookie", token, "", nil, http.StatusForbidden},
+		{"tampered cookie", token, "", &http.Cookie{Name: CSRFCookieName, Value: token + ".bad"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.form != "" {
+				form.Set(CSRFField, tt.form)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+}
+
+func TestCSRF_SessionBound(t *testing.T) {
+	store := NewCookieCSRFStore([]byte("secret"))
+	store.SetSessionID(func(r *http.Request) string {
+		c, _ := r.Cookie("session")
+		if c == nil {
+			return ""
+		}
+		return c.Value
+	})
+	csrf := NewCSRF(store)
+
+	var token string
+	h := csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	}))
+
+	// The attacker obtains a valid token and cookie for their own session.
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "attacker"})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	planted := rec.Result().Cookies()[0]
+
+	for session, code := range map[string]int{"attacker": http.StatusOK, "victim": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/form", nil)
+		req.Header.Set(CSRFHeader, token)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.AddCookie(planted)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != code {
+			t.Errorf("session %s: expected %d, got %d", session, code, rec.Code)
+		}
+	}
+}
+
+// memoryCSRFStore stands in for a session-backed synchronizer token store.
+type memoryCSRFStore struct{ token string }
+
+func (s *memoryCSRFStore) Get(*http.Request) (string, error) { return s.token, nil }
+
+func (s *memoryCSRFStore) Save(_ http.ResponseWriter, _ *http.Request, token string) error {
+	s.token = token
+	return nil
+}
+
+func TestCSRF_CustomStore(t *testing.T) {
+	store := &memoryCSRFStore{}
+	h := NewCSRF(store).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if store.token == "" {
+		t.Fatal("expected token to be saved to the custom store")
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set(CSRFHeader, store.token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Decompress returns middleware that transparently decodes request
+// bodies sent with a gzip or deflate Content-Encoding. The handler reads
+// the decoded body; Content-Encoding and Content-Length are removed from
+// the request because they no longer describe it.
+//
+// The decoded body is limited to maxSize bytes to defend against
+// decompression bombs. Reading past the limit fails with an
+// *http.MaxBytesError, exactly as with http.MaxBytesReader. Requests with
+// an unsupported encoding are rejected with 415 Unsupported Media Type
+// and bodies with a corrupt header with 400 Bad Request.
+//
+// Decompress panics if maxSize is not positive.
+func Decompress(maxSize int64) func(http.Handler) http.Handler {
+	if maxSize <= 0 {
+		panic("hmux: decompress max size must be positive")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+
+			var (
+				body io.ReadCloser
+				err  error
+			)
+			switch encoding {
+			case "", "identity":
+				next.ServeHTTP(w, r)
+				return
+			case "gzip", "x-gzip":
+				body, err = gzip.NewReader(r.Body)
+			case "deflate":
+				body, err = zlib.NewReader(r.Body)
+			default:
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			defer body.Close()
+
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			r.Body = http.MaxBytesReader(w, body, maxSize)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func zlibBytes(s string) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+// echoBody responds with the request body or, on read failure, 413.
+func echoBody(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Write(body)
+}
+
+func TestDecompress(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		code     int
+		expected string
+	}{
+		{"gzip", "gzip", gzipBytes("hello"), http.StatusOK, "hello"},
+		{"deflate", "deflate", zlibBytes("hello"), http.StatusOK, "hello"},
+		{"identity", "", []byte("plain"), http.StatusOK, "plain"},
+		{"unsupported", "br", []byte("x"), http.StatusUnsupportedMediaType, ""},
+		{"corrupt", "gzip", []byte("not gzip"), http.StatusBadRequest, ""},
+		{"too large", "gzip", gzipBytes(strings.Repeat("a", 100)), http.StatusRequestEntityTooLarge, ""},
+	}
+
+	h := Decompress(64)(http.HandlerFunc(echoBody))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if tt.expected != "" && rec.Body.String() != tt.expected {
+				t.Errorf("expected body %q, got %q", tt.expected, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDecompress_StripsHeaders(t *testing.T) {
+	h := Decompress(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Error("expected Content-Encoding to be removed")
+		}
+		if r.ContentLength != -1 {
+			t.Errorf("expected unknown ContentLength, got %d", r.ContentLength)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipBytes("hello")))
+	req.Header.Set("Content-Encoding", "gzip")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// DeprecationMeta is the route metadata key marking a route as
+// deprecated, with a Deprecation value or, without further details, true.
+const DeprecationMeta = "deprecation"
+
+// Deprecation describes the deprecation of a route to its consumers.
+type Deprecation struct {
+	// Since is when the route was deprecated. If zero, the Deprecation
+	// header is "true".
+	Since time.Time
+
+	// Sunset is when the route will stop responding. If zero, no Sunset
+	// header is sent.
+	Sunset time.Time
+
+	// Link is the URL of documentation about the deprecation, such as a
+	// migration guide.
+	Link string
+
+	// Successor is the URL of the route replacing the deprecated one.
+	Successor string
+}
+
+// Deprecated is middleware announcing the deprecation of the routes that
+// declare one in their metadata under DeprecationMeta, so API consumers
+// get machine-readable migration signals. Their responses carry the
+// Deprecation header (RFC 9745), the Sunset header (RFC 8594) and Link
+// headers with the relations "deprecation" and "successor-version".
+// Metadata set on a router applies to the routes of groups created from
+// it, so a whole API version is deprecated at once:
+//
+//	mux.Use(middleware.Deprecated)
+//
+//	v1 := mux.Meta(hmux.M{middleware.DeprecationMeta: middleware.Deprecation{
+//	    Since:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+//	    Sunset: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+//	    Link:   "https://example.com/docs/migrate-to-v2",
+//	}}).Group("/v1")
+//
+// Requests for routes that are not deprecated pass through unchanged.
+func Deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Deprecation
+		switch v := hmux.MetaFromContext(r.Context())[DeprecationMeta].(type) {
+		case Deprecation:
+			d = v
+		case *Deprecation:
+			if v == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			d = *v
+		case bool:
+			if !v {
+				next.ServeHTTP(w, r)
+				return
+			}
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		if d.Since.IsZero() {
+			h.Set("Deprecation", "true")
+		} else {
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" {
+			h.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+		}
+		if d.Successor != "" {
+			h.Add("Link", "<"+d.Successor+`>; rel="successor-version"`)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestDeprecated(t *testing.T) {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := hmux.New()
+	m.Use(Deprecated)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	m.HandleFunc("GET /v2/users", ok)
+	m.Meta(hmux.M{DeprecationMeta: true}).HandleFunc("GET /legacy", ok)
+	m.Meta(hmux.M{DeprecationMeta: false}).HandleFunc("GET /kept", ok)
+	v1 := m.Meta(hmux.M{DeprecationMeta: Deprecation{
+		Since:     since,
+		Sunset:    sunset,
+		Link:      "https://example.com/migrate",
+		Successor: "/v2/users",
+	}}).Group("/v1")
+	v1.HandleFunc("GET /users", ok)
+
+	tests := []struct {
+		path        string
+		deprecation string
+		sunset      string
+		links       []string
+	}{
+		{"/v2/users", "", "", nil},
+		{"/kept", "", "", nil},
+		{"/legacy", "true", "", nil},
+		{"/v1/users", "@1717200000", "Wed, 01 Jan 2025 00:00:00 GMT", []string{
+			`<https://example.com/migrate>; rel="deprecation"`,
+			`</v2/users>; rel="successor-version"`,
+		}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		h := rec.Header()
+		if h.Get("Deprecation") != tt.deprecation {
+			t.Errorf("%s: expected Deprecation %q, got %q", tt.path, tt.deprecation, h.Get("Deprecation"))
+		}
+		if h.Get("Sunset") != tt.sunset {
+			t.Errorf("%s: expected Sunset %q, got %q", tt.path, tt.sunset, h.Get("Sunset"))
+		}
+		if !slices.Equal(h.Values("Link"), tt.links) {
+			t.Errorf("%s: expected Link %q, got %q", tt.path, tt.links, h.Values("Link"))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// ETag returns middleware that computes a strong ETag from the body of
+// successful GET responses and answers matching If-None-Match requests
+// with 304 Not Modified. Responses larger than maxSize bytes, streamed
+// responses, and responses that already carry an ETag are passed through
+// without hashing, although an ETag set by the handler is still honoured
+// for If-None-Match. A maxSize of zero or less buffers without bound.
+//
+// Strong ETags promise byte-for-byte identical bodies. Use WeakETag when
+// a later middleware, such as compression, may alter the bytes.
+//
+// Example:
+//
+//	mux.Use(middleware.ETag(1 << 20))
+func ETag(maxSize int) func(http.Handler) http.Handler {
+	return etag(maxSize, false)
+}
+
+// WeakETag is like ETag but emits weak validators (W/"...").
+func WeakETag(maxSize int) func(http.Handler) http.Handler {
+	return etag(maxSize, true)
+}
+
+func etag(maxSize int, weak bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := hmux.NewBufferedWriter(w, maxSize)
+			next.ServeHTTP(bw, r)
+
+			if bw.Buffered() && bw.Status() == http.StatusOK {
+				tag := w.Header().Get("ETag")
+				if tag == "" {
+					sum := sha256.Sum256(bw.Bytes())
+					tag = `"` + hex.EncodeToString(sum[:16]) + `"`
+					if weak {
+						tag = "W/" + tag
+					}
+					w.Header().Set("ETag", tag)
+				}
+
+				if etagMatch(r.Header.Get("If-None-Match"), tag) {
+					bw.Reset()
+					for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+						w.Header().Del(k)
+					}
+					bw.WriteHeader(http.StatusNotModified)
+				}
+			}
+
+			_ = bw.Commit()
+		})
+	}
+}
+
+// etagMatch reports whether an If-None-Match header matches tag using the
+// weak comparison function of RFC 9110.
+func etagMatch(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
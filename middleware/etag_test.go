@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	h := ETag(0)(textHandler("hello"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	tag := rec.Header().Get("ETag")
+	if !strings.HasPrefix(tag, `"`) || len(tag) != 34 {
+		t.Fatalf("expected strong ETag, got %q", tag)
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("expected body %q, got %q", "hello", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", `+tag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", rec.Body.String())
+	}
+	if rec.Header().Get("ETag") != tag {
+		t.Errorf("expected ETag %q on 304, got %q", tag, rec.Header().Get("ETag"))
+	}
+}
+
+func TestWeakETag(t *testing.T) {
+	h := WeakETag(0)(textHandler("hello"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	tag := rec.Header().Get("ETag")
+	if !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("expected weak ETag, got %q", tag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", strings.TrimPrefix(tag, "W/"))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected weak comparison to match, got %d", rec.Code)
+	}
+}
+
+func TestETag_SizeCutoff(t *testing.T) {
+	h := ETag(4)(textHandler("hello world"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Header().Get("ETag") != "" {
+		t.Errorf("expected no ETag above cutoff, got %q", rec.Header().Get("ETag"))
+	}
+	if rec.Body.String() != "hello world" {
+		t.Errorf("expected full body, got %q", rec.Body.String())
+	}
+}
+
+func TestETag_HandlerTagHonoured(t *testing.T) {
+	h := ETag(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v7"`)
+		w.Write([]byte("data"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"v7"`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+}
+
+func TestETag_SkipsNonGET(t *testing.T) {
+	h := ETag(0)(textHandler("created"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if rec.Header().Get("ETag") != "" {
+		t.Errorf("expected no ETag for POST, got %q", rec.Header().Get("ETag"))
+	}
+}
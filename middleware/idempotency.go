@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// IdempotencyKeyHeader is the request header carrying an idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" on responses replayed by
+// Idempotency.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKey is the longest idempotency key accepted.
+const maxIdempotencyKey = 255
+
+// defaultIdempotencyLock is how long a key stays reserved for a request
+// in progress unless SetLockTTL says otherwise.
+const defaultIdempotencyLock = time.Minute
+
+// IdempotentResponse is the stored response to an idempotent request.
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// Fingerprint identifies the request that produced the response, so
+	// a key reused for a different request can be told apart from a
+	// retry.
+	Fingerprint string
+}
+
+// IdempotencyStore records idempotency keys and the responses to their
+// requests. The default MemoryIdempotencyStore keeps them in process
+// memory; a shared store lets retries reach any instance.
+//
+// Methods must be safe for concurrent use, and Begin must be atomic: of
+// concurrent calls with the same key, only one may reserve it.
+type IdempotencyStore interface {
+	// Begin reserves key for a request in progress, for at most ttl. If
+	// key is already reserved or holds a response, Begin reserves
+	// nothing and returns the response, or nil while the request holding
+	// the reservation is still in progress.
+	Begin(key string, ttl time.Duration) (reserved bool, resp *IdempotentResponse, err error)
+
+	// Complete stores the response to the request holding the
+	// reservation of key, for ttl.
+	Complete(key string, resp *IdempotentResponse, ttl time.Duration) error
+
+	// Release drops the reservation of key without a response, so the
+	// request may be retried.
+	Release(key string) error
+}
+
+// Idempotency is middleware implementing the Idempotency-Key pattern for
+// unsafe requests: the response to the first request carrying a key is
+// stored, and retries with the same key within the TTL get the stored
+// response instead of running the handler again, so a client can safely
+// retry a payment or an order after a network failure:
+//
+//	idem := middleware.NewIdempotency(24*time.Hour, 1<<20)
+//	api.With(idem.Handler).HandleFunc("POST /payments", createPayment)
+//
+// Replayed responses carry Idempotent-Replayed: true. A retry arriving
+// while the first request is still running gets 409 Conflict, and a key
+// reused with a different method, URL or body gets 422 Unprocessable
+// Entity. Keys are scoped to the subject of the claims in the request
+// context, so place Idempotency after the authentication middleware.
+//
+// Responses with a 5xx status, responses with a body larger than the
+// configured limit, and requests whose handler panics are not stored, so
+// those requests may be retried. Requests without a key, and GET, HEAD,
+// OPTIONS and TRACE requests, are served as usual. If the store fails,
+// requests with a key get 503 Service Unavailable, since serving them
+// could run the handler twice.
+//
+// A key is reserved for a request in progress for a lock TTL much
+// shorter than the response TTL, one minute by default, so a key whose
+// request never completes, because the instance serving it crashed,
+// becomes usable again soon. Only the headers set by the handler are
+// stored; those set by outer middleware, such as a request ID, are not
+// replayed.
+//
+// Request bodies are read into memory to fingerprint the request; limit
+// their size with MaxBytes.
+type Idempotency struct {
+	ttl     time.Duration
+	lockTTL time.Duration
+	maxBody int
+	store   IdempotencyStore
+}
+
+// NewIdempotency returns an Idempotency storing responses of at most
+// maxBody bytes for ttl, backed by a MemoryIdempotencyStore.
+//
+// NewIdempotency panics if ttl or maxBody is not positive.
+func NewIdempotency(ttl time.Duration, maxBody int) *Idempotency {
+	if ttl <= 0 || maxBody <= 0 {
+		panic("hmux: idempotency ttl and max body must be positive")
+	}
+
+	return &Idempotency{
+		ttl:     ttl,
+		lockTTL: min(ttl, defaultIdempotencyLock),
+		maxBody: maxBody,
+		store:   NewMemoryIdempotencyStore(),
+	}
+}
+
+// SetLockTTL sets how long a key stays reserved while its request is in
+// progress. It should exceed the longest time the handler may take: once
+// it passes, a retry runs the handler again. It must be called before
+// the middleware starts serving requests.
+//
+// SetLockTTL panics if ttl is not positive.
+func (i *Idempotency) SetLockTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		panic("hmux: idempotency lock ttl must be positive")
+	}
+
+	i.lockTTL = ttl
+}
+
+// SetStore replaces the idempotency store. It must be called before the
+// middleware starts serving requests.
+//
+// SetStore panics if store is nil.
+func (i *Idempotency) SetStore(store IdempotencyStore) {
+	if store == nil {
+		panic("hmux: nil IdempotencyStore passed to SetStore")
+	}
+
+	i.store = store
+}
+
+// Handler is the idempotency middleware.
+func (i *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			key = ""
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(r, body)
+
+		key = hmux.ClaimsFromContext(r.Context()).Subject() + "\x00" + key
+		reserved, stored, err := i.store.Begin(key, i.lockTTL)
+		switch {
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		case reserved:
+			i.serve(w, r, next, key, fingerprint)
+		case stored == nil:
+			http.Error(w, "A request with this Idempotency-Key is in progress", http.StatusConflict)
+		case stored.Fingerprint != fingerprint:
+			http.Error(w, "Idempotency-Key reused for a different request", http.StatusUnprocessableEntity)
+		default:
+			replay(w, stored)
+		}
+	})
+}
+
+// serve runs the request holding the reservation of key and stores its
+// response, or releases the key if the response must not be replayed.
+func (i *Idempotency) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key, fingerprint string) {
+	completed := false
+	defer func() {
+		if !completed {
+			_ = i.store.Release(key)
+		}
+	}()
+
+	before := w.Header().Clone()
+	cw := hmux.NewCaptureWriter(w, i.maxBody)
+	next.ServeHTTP(cw, r)
+
+	status, header := cw.Status(), cw.SentHeader()
+	if status == 0 {
+		status, header = http.StatusOK, w.Header()
+	}
+	header = handlerHeader(before, header)
+	if status >= 500 || !cw.Complete() {
+		return
+	}
+
+	resp := &IdempotentResponse{
+		Status:      status,
+		Header:      header,
+		Body:        bytes.Clone(cw.Bytes()),
+		Fingerprint: fingerprint,
+	}
+	completed = i.store.Complete(key, resp, i.ttl) == nil
+}
+
+// replay writes a stored response.
+func replay(w http.ResponseWriter, resp *IdempotentResponse) {
+	h := w.Header()
+	for k, v := range resp.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(IdempotentReplayedHeader, "true")
+
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// requestFingerprint hashes the method, URL and body of r.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. Expired keys
+// are swept as new keys are reserved.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+	now     func() time.Time
+	swept   time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	resp    *IdempotentResponse // nil while in progress
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore returns an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]memoryIdempotencyEntry), now: time.Now}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(key string, ttl time.Duration) (bool, *IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return false, e.resp, nil
+	}
+
+	if now.Sub(s.swept) > time.Minute {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.swept = now
+	}
+
+	s.entries[key] = memoryIdempotencyEntry{expires: now.Add(ttl)}
+
+	return true, nil, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(key string, resp *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, expires: s.now().Add(ttl)}
+
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
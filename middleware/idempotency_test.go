@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	h := NewIdempotency(time.Hour, 1<<10).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/orders/"+strconv.Itoa(calls))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("order " + strconv.Itoa(calls)))
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		key      string
+		body     string
+		code     int
+		response string
+		replayed string
+		calls    int
+	}{
+		{"first", http.MethodPost, "k1", "{}", http.StatusCreated, "order 1", "", 1},
+		{"retry", http.MethodPost, "k1", "{}", http.StatusCreated, "order 1", "true", 1},
+		{"other key", http.MethodPost, "k2", "{}", http.StatusCreated, "order 2", "", 2},
+		{"no key", http.MethodPost, "", "{}", http.StatusCreated, "order 3", "", 3},
+		{"safe method", http.MethodGet, "k1", "", http.StatusCreated, "order 4", "", 4},
+		{"different body", http.MethodPost, "k1", `{"n":2}`, http.StatusUnprocessableEntity, "Idempotency-Key reused for a different request\n", "", 4},
+		{"key too long", http.MethodPost, strings.Repeat("k", 256), "{}", http.StatusBadRequest, "Idempotency-Key too long\n", "", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/orders", strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Body.String() != tt.response {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.response, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get(IdempotentReplayedHeader); got != tt.replayed {
+				t.Errorf("expected %s %q, got %q", IdempotentReplayedHeader, tt.replayed, got)
+			}
+			if tt.replayed != "" && rec.Header().Get("Location") != "/orders/1" {
+				t.Errorf("expected replayed Location, got %q", rec.Header().Get("Location"))
+			}
+			if calls != tt.calls {
+				t.Errorf("expected %d handler calls, got %d", tt.calls, calls)
+			}
+		})
+	}
+}
+
+func TestIdempotency_NotStored(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}},
+		{"oversized", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strings.Repeat("x", 32)))
+		}},
+		{"panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			h := Recoverer(NewIdempotency(time.Hour, 16).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			})))
+
+			for range 2 {
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				req.Header.Set(IdempotencyKeyHeader, "k")
+				h.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			if calls != 2 {
+				t.Errorf("expected the retry to run the handler, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := NewIdempotency(time.Hour, 1<<10).Handler(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-started
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(IdempotencyKeyHeader, "k")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	close(release)
+	wg.Wait()
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", rec.Code)
+	}
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+
+	if ok, _, _ := s.Begin("k", time.Minute); !ok {
+		t.Fatal("expected first Begin to reserve")
+	}
+	s.Complete("k", &IdempotentResponse{Status: http.StatusOK}, time.Minute)
+	if ok, resp, _ := s.Begin("k", time.Minute); ok || resp == nil {
+		t.Errorf("expected stored response, got reserved=%v resp=%v", ok, resp)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if ok, _, _ := s.Begin("k", time.Minute); !ok {
+		t.Error("expected expired key to be reserved again")
+	}
+}
+
+// lockRecordingStore records the TTL of each reservation.
+type lockRecordingStore struct {
+	*MemoryIdempotencyStore
+	locks []time.Duration
+}
+
+func (s *lockRecordingStore) Begin(key string, ttl time.Duration) (bool, *IdempotentResponse, error) {
+	s.locks = append(s.locks, ttl)
+	return s.MemoryIdempotencyStore.Begin(key, ttl)
+}
+
+func TestIdempotency_LockTTL(t *testing.T) {
+	store := &lockRecordingStore{MemoryIdempotencyStore: NewMemoryIdempotencyStore()}
+	idem := NewIdempotency(24*time.Hour, 1<<10)
+	idem.SetStore(store)
+	h := idem.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	idem.SetLockTTL(5 * time.Second)
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(IdempotencyKeyHeader, "k2")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := []time.Duration{time.Minute, 5 * time.Second}; !slices.Equal(store.locks, want) {
+		t.Errorf("expected reservations for %v, got %v", want, store.locks)
+	}
+}
+
+func TestIdempotency_OuterHeadersNotReplayed(t *testing.T) {
+	inner := NewIdempotency(time.Hour, 1<<10).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/orders/1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		inner.ServeHTTP(w, r)
+	})
+
+	for _, id := range []string{"a", "b"} {
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		req.Header.Set("X-Request-ID", id)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("X-Request-ID"); got != id {
+			t.Errorf("expected request ID %q, got %q", id, got)
+		}
+		if rec.Header().Get("Location") != "/orders/1" {
+			t.Errorf("expected Location, got %q", rec.Header().Get("Location"))
+		}
+	}
+}
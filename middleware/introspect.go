@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// ErrTokenInactive is returned by Introspection when the authorization
+// server reports a token as inactive: expired, revoked or unknown.
+var ErrTokenInactive = errors.New("hmux: token inactive")
+
+// maxIntrospectionCache bounds the number of cached introspection
+// results, so a flood of random tokens cannot exhaust memory.
+const maxIntrospectionCache = 10000
+
+// Introspection authenticates bearer tokens by asking an OAuth 2.0
+// authorization server about them (RFC 7662), for opaque tokens that
+// cannot be verified locally. The claims of active tokens, such as
+// "sub" and "scope", are stored in the request context, where
+// hmux.ClaimsFromContext returns them; use Claims.HasScope to authorize.
+//
+// Results are cached for a configurable TTL, and never past the token's
+// "exp" claim, so repeated requests with a token cost one round trip.
+// Inactive tokens are cached too. A revoked token is therefore accepted
+// until its cached result expires; keep the TTL short where that
+// matters.
+//
+// Requests with a missing or inactive token are rejected with 401
+// Unauthorized, and requests whose token cannot be checked because the
+// server is unreachable with 503 Service Unavailable.
+//
+//	auth := middleware.NewIntrospection("https://auth.example.com/oauth2/introspect", time.Minute)
+//	auth.SetCredentials(clientID, clientSecret)
+//	api.Use(auth.Handler)
+type Introspection struct {
+	url          string
+	ttl          time.Duration
+	client       *http.Client
+	clientID     string
+	clientSecret string
+	now          func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspected
+}
+
+// introspected is a cached introspection result.
+type introspected struct {
+	claims  hmux.Claims // nil if the token is inactive
+	expires time.Time
+}
+
+// NewIntrospection returns an Introspection querying the introspection
+// endpoint at url and caching results for ttl. A zero ttl disables
+// caching.
+func NewIntrospection(url string, ttl time.Duration) *Introspection {
+	return &Introspection{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+		cache:  make(map[[sha256.Size]byte]introspected),
+	}
+}
+
+// SetClient replaces the HTTP client used to query the endpoint.
+func (a *Introspection) SetClient(c *http.Client) {
+	a.client = c
+}
+
+// SetCredentials sets the client credentials sent with HTTP Basic
+// authentication, which most authorization servers require.
+func (a *Introspection) SetCredentials(clientID, clientSecret string) {
+	a.clientID = clientID
+	a.clientSecret = clientSecret
+}
+
+// Handler is middleware that requires an active token.
+func (a *Introspection) Handler(next http.Handler) http.Handler {
+	return a.middleware(next, true)
+}
+
+// Optional is middleware that introspects a token if one is present.
+// Requests with an inactive token are rejected; requests without a token
+// proceed with no claims in the context.
+func (a *Introspection) Optional(next http.Handler) http.Handler {
+	return a.middleware(next, false)
+}
+
+func (a *Introspection) middleware(next http.Handler, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" && !required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := a.Introspect(r.Context(), token)
+		switch {
+		case errors.Is(err, ErrTokenMissing), errors.Is(err, ErrTokenInactive):
+			unauthorized(w, err)
+			return
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(hmux.ContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// Introspect returns the claims of an active token, from the cache or the
+// authorization server. It returns ErrTokenInactive if the token is not
+// active.
+func (a *Introspection) Introspect(ctx context.Context, token string) (hmux.Claims, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	key := sha256.Sum256([]byte(token))
+	now := a.now()
+
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+
+	if !ok || !now.Before(entry.expires) {
+		claims, err := a.query(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		entry = introspected{claims: claims, expires: now.Add(a.ttl)}
+		if exp, ok := claims["exp"].(float64); ok && unixTime(exp).Before(entry.expires) {
+			entry.expires = unixTime(exp)
+		}
+		a.store(key, entry, now)
+	}
+
+	if entry.claims == nil {
+		return nil, ErrTokenInactive
+	}
+
+	return entry.claims, nil
+}
+
+// store caches entry under key, evicting expired entries when the cache
+// is full, and all entries if none has expired.
+func (a *Introspection) store(key [sha256.Size]byte, entry introspected, now time.Time) {
+	if a.ttl <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.cache) >= maxIntrospectionCache {
+		for k, e := range a.cache {
+			if !now.Before(e.expires) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= maxIntrospectionCache {
+			clear(a.cache)
+		}
+	}
+
+	a.cache[key] = entry
+}
+
+// query asks the authorization server about token. It returns nil claims
+// for an inactive token.
+func (a *Introspection) query(ctx context.Context, token string) (hmux.Claims, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("hmux: introspecting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("hmux: introspecting token: unexpected status %d", resp.StatusCode)
+	}
+
+	var claims hmux.Claims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("hmux: decoding introspection response: %w", err)
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, nil
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestIntrospection(t *testing.T) {
+	var queries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "s3cret" {
+			http.Error(w, "bad client", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.PostFormValue("token") {
+		case "good":
+			json.NewEncoder(w).Encode(map[string]any{"active": true, "sub": "alice", "scope": "read write"})
+		case "expiring":
+			json.NewEncoder(w).Encode(map[string]any{"active": true, "sub": "bob", "exp": 1030})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"active": false})
+		}
+	}))
+	defer server.Close()
+
+	auth := NewIntrospection(server.URL, time.Minute)
+	auth.SetCredentials("client", "s3cret")
+	now := time.Unix(1000, 0)
+	auth.now = func() time.Time { return now }
+
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := hmux.ClaimsFromContext(r.Context())
+		if c.HasScope("write") {
+			w.Write([]byte("write:"))
+		}
+		w.Write([]byte(c.Subject()))
+	}))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		code      int
+		body      string
+		challenge string
+	}{
+		{"active", "good", http.StatusOK, "write:alice", ""},
+		{"missing", "", http.StatusUnauthorized, "Unauthorized\n", "Bearer"},
+		{"inactive", "revoked", http.StatusUnauthorized, "Unauthorized\n", `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.token)
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected challenge %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		before := queries.Load()
+		serve("good")
+		serve("revoked")
+		if got := queries.Load() - before; got != 0 {
+			t.Errorf("expected cached results, got %d queries", got)
+		}
+
+		now = now.Add(2 * time.Minute)
+		serve("good")
+		if got := queries.Load() - before; got != 1 {
+			t.Errorf("expected a query after the TTL, got %d", got)
+		}
+	})
+
+	t.Run("cached until exp", func(t *testing.T) {
+		now = time.Unix(1000, 0)
+		if rec := serve("expiring"); rec.Body.String() != "bob" {
+			t.Fatalf("expected bob, got %q", rec.Body.String())
+		}
+
+		before := queries.Load()
+		now = now.Add(40 * time.Second)
+		serve("expiring")
+		if got := queries.Load() - before; got != 1 {
+			t.Errorf("expected a query after exp, got %d", got)
+		}
+	})
+}
+
+func TestIntrospection_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	auth := NewIntrospection(server.URL, time.Minute)
+	if _, err := auth.Introspect(context.Background(), "good"); err == nil || errors.Is(err, ErrTokenInactive) {
+		t.Errorf("expected an endpoint error, got %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	rec := httptest.NewRecorder()
+	auth.Handler(http.NotFoundHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned by a KeyProvider when no key matches the
+// token's key ID.
+var ErrKeyNotFound = errors.New("hmux: verification key not found")
+
+// minJWKSRefresh bounds how often the key set may be fetched, so tokens
+// with random kids cannot hammer the key server and a failing server is
+// not retried on every request.
+const minJWKSRefresh = 10 * time.Second
+
+// JWKS is a KeyProvider that fetches verification keys from a JSON Web
+// Key Set endpoint (RFC 7517) and caches them for a configurable TTL.
+// RSA, EC (P-256, P-384, P-521) and Ed25519 (OKP) keys are supported.
+//
+// Keys are refreshed when the cache expires or when a token references a
+// key ID that is not cached, which picks up key rotation without waiting
+// for the TTL. Fetches are limited to one every ten seconds and shared by
+// concurrent requests. If a refresh fails, the previously fetched keys
+// keep being served until a later refresh succeeds.
+//
+// Example:
+//
+//	keys := middleware.NewJWKS("https://auth.example.com/.well-known/jwks.json", time.Hour)
+//	auth := middleware.NewJWTAuth(keys)
+type JWKS struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time // last successful fetch
+	attempted time.Time // last fetch, successful or not
+	err       error     // result of the last fetch
+	inflight  *jwksFetch
+}
+
+// jwksFetch is a fetch in progress, shared by all callers that need it.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a JWKS provider for the key set at url, caching keys
+// for ttl.
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	return &JWKS{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetClient replaces the HTTP client used to fetch the key set.
+func (j *JWKS) SetClient(c *http.Client) {
+	j.client = c
+}
+
+// Key implements KeyProvider. If kid is empty and the set contains a
+// single key, that key is returned.
+func (j *JWKS) Key(ctx context.Context, _, kid string) (any, error) {
+	j.mu.Lock()
+	expired := j.keys == nil || time.Since(j.fetchedAt) > j.ttl
+	j.mu.Unlock()
+
+	if expired {
+		if err := j.refresh(ctx); err != nil && !j.loaded() {
+			return nil, err
+		}
+	}
+
+	key, ok := j.lookup(kid)
+	if !ok {
+		err := j.refresh(ctx)
+		if err != nil && !j.loaded() {
+			return nil, err
+		}
+		key, ok = j.lookup(kid)
+	}
+
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (j *JWKS) loaded() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.keys != nil
+}
+
+func (j *JWKS) lookup(kid string) (any, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if kid == "" && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			return k, true
+		}
+	}
+
+	k, ok := j.keys[kid]
+	return k, ok
+}
+
+// refresh fetches the key set, unless a fetch was started less than
+// minJWKSRefresh ago, in which case it returns that fetch's result.
+// Concurrent callers share one fetch, which runs without holding j.mu so
+// cached keys stay available while the key server is slow.
+func (j *JWKS) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	if f := j.inflight; f != nil {
+		j.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !j.attempted.IsZero() && time.Since(j.attempted) < minJWKSRefresh {
+		err := j.err
+		j.mu.Unlock()
+		return err
+	}
+	f := &jwksFetch{done: make(chan struct{})}
+	j.inflight = f
+	j.attempted = time.Now()
+	j.mu.Unlock()
+
+	// The fetch is shared, so it must not fail because the request that
+	// happened to start it went away.
+	keys, err := j.fetch(context.WithoutCancel(ctx))
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = time.Now()
+	}
+	j.err = err
+	j.inflight = nil
+	j.mu.Unlock()
+
+	f.err = err
+	close(f.done)
+
+	return err
+}
+
+// fetch fetches and parses the key set. Keys of unsupported types are
+// skipped.
+func (j *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("hmux: fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("hmux: fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("hmux: decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+
+	return keys, nil
+}
+
+// jwk is a single JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts the JWK to a crypto public key.
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("hmux: RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("hmux: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("hmux: unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("hmux: invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("hmux: unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("hmux: empty integer")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
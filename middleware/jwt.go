@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 for crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Errors returned when verifying a token.
+var (
+	ErrTokenMissing   = errors.New("hmux: token missing")
+	ErrTokenMalformed = errors.New("hmux: token malformed")
+	ErrTokenSignature = errors.New("hmux: token signature invalid")
+	ErrTokenExpired   = errors.New("hmux: token expired")
+	ErrTokenNotYet    = errors.New("hmux: token not valid yet")
+	ErrTokenClaims    = errors.New("hmux: token claims invalid")
+)
+
+// KeyProvider resolves the key used to verify a token. The alg and kid
+// arguments come from the token header; kid may be empty.
+//
+// The returned key must be a []byte for HMAC algorithms (HS256, HS384,
+// HS512), an *rsa.PublicKey for RS* and PS* algorithms, an
+// *ecdsa.PublicKey for ES* algorithms, or an ed25519.PublicKey for EdDSA.
+type KeyProvider interface {
+	Key(ctx context.Context, alg, kid string) (any, error)
+}
+
+// KeyProviderFunc adapts a function to the KeyProvider interface.
+type KeyProviderFunc func(ctx context.Context, alg, kid string) (any, error)
+
+// Key implements KeyProvider.
+func (f KeyProviderFunc) Key(ctx context.Context, alg, kid string) (any, error) {
+	return f(ctx, alg, kid)
+}
+
+// StaticKey returns a KeyProvider that verifies every token with key.
+func StaticKey(key any) KeyProvider {
+	return KeyProviderFunc(func(context.Context, string, string) (any, error) {
+		return key, nil
+	})
+}
+
+// JWTAuth verifies JSON Web Tokens carried in the Authorization header
+// as "Bearer <token>" and stores their claims in the request context,
+// where hmux.ClaimsFromContext returns them.
+//
+// Tokens must be signed with one of HS256/384/512, RS256/384/512,
+// PS256/384/512, ES256/384/512 or EdDSA. The key type returned by the
+// KeyProvider must match the algorithm family, which rules out algorithm
+// confusion attacks; unsigned ("none") tokens are always rejected. The
+// exp and nbf claims are enforced when present.
+//
+// JWTAuth offers two middleware so enforcement can be decided per group:
+// Handler rejects requests without a valid token, while Optional only
+// rejects invalid tokens and lets anonymous requests through.
+//
+//	auth := middleware.NewJWTAuth(middleware.StaticKey(secret))
+//	mux.Use(auth.Optional)     // claims available where present
+//	admin := mux.Group("/admin")
+//	admin.Use(auth.Handler)    // token required
+type JWTAuth struct {
+	keys     KeyProvider
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTAuth returns a JWTAuth resolving verification keys from keys.
+//
+// NewJWTAuth panics if keys is nil.
+func NewJWTAuth(keys KeyProvider) *JWTAuth {
+	if keys == nil {
+		panic("hmux: nil KeyProvider passed to NewJWTAuth")
+	}
+
+	return &JWTAuth{keys: keys, now: time.Now}
+}
+
+// SetIssuer requires the "iss" claim to equal iss.
+func (a *JWTAuth) SetIssuer(iss string) {
+	a.issuer = iss
+}
+
+// SetAudience requires the "aud" claim to contain aud.
+func (a *JWTAuth) SetAudience(aud string) {
+	a.audience = aud
+}
+
+// SetLeeway sets the clock skew tolerated when checking exp and nbf.
+func (a *JWTAuth) SetLeeway(d time.Duration) {
+	a.leeway = d
+}
+
+// Handler is middleware that requires a valid token. Requests without
+// one are rejected with 401 Unauthorized.
+func (a *JWTAuth) Handler(next http.Handler) http.Handler {
+	return a.middleware(next, true)
+}
+
+// Optional is middleware that verifies a token if one is present.
+// Requests with an invalid token are rejected with 401 Unauthorized;
+// requests without a token proceed with no claims in the context.
+func (a *JWTAuth) Optional(next http.Handler) http.Handler {
+	return a.middleware(next, false)
+}
+
+func (a *JWTAuth) middleware(next http.Handler, required bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" && !required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := a.Verify(r.Context(), token)
+		if err != nil {
+			unauthorized(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(hmux.ContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// Verify parses and verifies a compact-serialized token and returns its
+// claims.
+func (a *JWTAuth) Verify(ctx context.Context, token string) (hmux.Claims, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	key, err := a.keys.Key(ctx, header.Alg, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenSignature, err)
+	}
+
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims hmux.Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	if err := a.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validate checks the registered time, issuer and audience claims.
+func (a *JWTAuth) validate(c hmux.Claims) error {
+	now := a.now()
+
+	if exp, ok := c["exp"].(float64); ok && now.After(unixTime(exp).Add(a.leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := c["nbf"].(float64); ok && now.Add(a.leeway).Before(unixTime(nbf)) {
+		return ErrTokenNotYet
+	}
+
+	if a.issuer != "" && c.String("iss") != a.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrTokenClaims)
+	}
+
+	if a.audience != "" {
+		found := false
+		switch aud := c["aud"].(type) {
+		case string:
+			found = aud == a.audience
+		case []any:
+			for _, v := range aud {
+				if s, ok := v.(string); ok && s == a.audience {
+					found = true
+					break
+				}
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%w: unexpected audience", ErrTokenClaims)
+		}
+	}
+
+	return nil
+}
+
+// verifySignature checks sig over signed with key according to alg.
+func verifySignature(alg string, key any, signed, sig []byte) error {
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+
+	if alg == "EdDSA" {
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, sig) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+
+	if len(alg) != 5 {
+		return ErrTokenSignature
+	}
+	h, ok := hashes[alg[2:]]
+	if !ok {
+		return ErrTokenSignature
+	}
+
+	digest := func() []byte {
+		d := h.New()
+		d.Write(signed)
+		return d.Sum(nil)
+	}
+
+	var valid bool
+	switch alg[:2] {
+	case "HS":
+		if k, ok := key.([]byte); ok && len(k) > 0 {
+			mac := hmac.New(h.New, k)
+			mac.Write(signed)
+			valid = hmac.Equal(mac.Sum(nil), sig)
+		}
+	case "RS":
+		if k, ok := key.(*rsa.PublicKey); ok {
+			valid = rsa.VerifyPKCS1v15(k, h, digest(), sig) == nil
+		}
+	case "PS":
+		if k, ok := key.(*rsa.PublicKey); ok {
+			valid = rsa.VerifyPSS(k, h, digest(), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case "ES":
+		if k, ok := key.(*ecdsa.PublicKey); ok {
+			size := (k.Curve.Params().BitSize + 7) / 8
+			if len(sig) == 2*size {
+				r := new(big.Int).SetBytes(sig[:size])
+				s := new(big.Int).SetBytes(sig[size:])
+				valid = ecdsa.Verify(k, digest(), r, s)
+			}
+		}
+	}
+
+	if !valid {
+		return ErrTokenSignature
+	}
+
+	return nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// unauthorized writes a 401 response with a Bearer challenge.
+func unauthorized(w http.ResponseWriter, err error) {
+	challenge := "Bearer"
+	if !errors.Is(err, ErrTokenMissing) {
+		challenge = `Bearer error="invalid_token"`
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// decodeSegment decodes a base64url JSON segment into v.
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate to a time.Time.
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
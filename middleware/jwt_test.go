@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signToken builds a compact JWT with the given header and claims,
+// signing it with sign.
+func signToken(t *testing.T, header, claims map[string]any, sign func([]byte) []byte) string {
+	t.Helper()
+
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(data []byte) []byte {
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hsToken(t *testing.T, claims map[string]any) string {
+	return signToken(t, map[string]any{"alg": "HS256", "typ": "JWT"}, claims, hs256)
+}
+
+func TestJWTAuth_Verify(t *testing.T) {
+	auth := NewJWTAuth(StaticKey(testSecret))
+	auth.now = func() time.Time { return time.Unix(1000, 0) }
+
+	tests := []struct {
+		name   string
+		token  string
+		target error
+	}{
+		{"valid", hsToken(t, map[string]any{"sub": "u1", "exp": 2000}), nil},
+		{"expired", hsToken(t, map[string]any{"exp": 500}), ErrTokenExpired},
+		{"not yet valid", hsToken(t, map[string]any{"nbf": 1500}), ErrTokenNotYet},
+		{"malformed", "abc.def", ErrTokenMalformed},
+		{"bad signature", hsToken(t, map[string]any{"sub": "u1"}) + "x", ErrTokenSignature},
+		{"none algorithm", signToken(t, map[string]any{"alg": "none"}, map[string]any{}, func([]byte) []byte { return nil }), ErrTokenSignature},
+		{"missing", "", ErrTokenMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := auth.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.target) {
+				t.Fatalf("expected error %v, got %v", tt.target, err)
+			}
+			if tt.target == nil && claims.Subject() != "u1" {
+				t.Errorf("expected subject u1, got %q", claims.Subject())
+			}
+		})
+	}
+}
+
+func TestJWTAuth_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	digest := func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+
+	tests := []struct {
+		alg  string
+		key  any
+		sign func([]byte) []byte
+	}{
+		{"RS256", &rsaKey.PublicKey, func(data []byte) []byte {
+			sig, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest(data))
+			return sig
+		}},
+		{"PS256", &rsaKey.PublicKey, func(data []byte) []byte {
+			sig, _ := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest(data), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			return sig
+		}},
+		{"ES256", &ecKey.PublicKey, func(data []byte) []byte {
+			r, s, _ := ecdsa.Sign(rand.Reader, ecKey, digest(data))
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig
+		}},
+		{"EdDSA", edPub, func(data []byte) []byte {
+			return ed25519.Sign(edPriv, data)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token := signToken(t, map[string]any{"alg": tt.alg}, map[string]any{"sub": "u1"}, tt.sign)
+
+			if _, err := NewJWTAuth(StaticKey(tt.key)).Verify(context.Background(), token); err != nil {
+				t.Errorf("expected valid token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_AlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	auth := NewJWTAuth(StaticKey(&rsaKey.PublicKey))
+
+	// An HS256 token must not verify against an RSA public key.
+	if _, err := auth.Verify(context.Background(), hsToken(t, map[string]any{})); !errors.Is(err, ErrTokenSignature) {
+		t.Errorf("expected signature error, got %v", err)
+	}
+}
+
+func TestJWTAuth_IssuerAudience(t *testing.T) {
+	auth := NewJWTAuth(StaticKey(testSecret))
+	auth.SetIssuer("https://issuer")
+	auth.SetAudience("api")
+
+	ok := hsToken(t, map[string]any{"iss": "https://issuer", "aud": []string{"web", "api"}})
+	if _, err := auth.Verify(context.Background(), ok); err != nil {
+		t.Errorf("expected valid token, got %v", err)
+	}
+
+	for _, claims := range []map[string]any{
+		{"iss": "https://other", "aud": "api"},
+		{"iss": "https://issuer", "aud": "web"},
+	} {
+		if _, err := auth.Verify(context.Background(), hsToken(t, claims)); !errors.Is(err, ErrTokenClaims) {
+			t.Errorf("expected claims error for %v, got %v", claims, err)
+		}
+	}
+}
+
+func TestJWTAuth_Handler(t *testing.T) {
+	auth := NewJWTAuth(StaticKey(testSecret))
+
+	var subject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = hmux.ClaimsFromContext(r.Context()).Subject()
+	})
+
+	tests := []struct {
+		name      string
+		mw        func(http.Handler) http.Handler
+		auth      string
+		code      int
+		subject   string
+		challenge string
+	}{
+		{"required valid", auth.Handler, "Bearer " + hsToken(t, map[string]any{"sub": "u1"}), http.StatusOK, "u1", ""},
+		{"required missing", auth.Handler, "", http.StatusUnauthorized, "", "Bearer"},
+		{"required invalid", auth.Handler, "Bearer nope", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+		{"optional missing", auth.Optional, "", http.StatusOK, "", ""},
+		{"optional invalid", auth.Optional, "Bearer nope", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject = ""
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			tt.mw(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, subject)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected challenge %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	auth := NewJWTAuth(NewJWKS(srv.URL, time.Hour))
+	token := signToken(t, map[string]any{"alg": "RS256", "kid": "k1"}, map[string]any{"sub": "u1"}, func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+		return sig
+	})
+
+	for range 2 {
+		if _, err := auth.Verify(context.Background(), token); err != nil {
+			t.Fatalf("expected valid token, got %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected key set to be cached, got %d fetches", fetches)
+	}
+
+	unknown := signToken(t, map[string]any{"alg": "RS256", "kid": "k2"}, map[string]any{}, func([]byte) []byte { return nil })
+	if _, err := auth.Verify(context.Background(), unknown); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestJWKS_Refresh(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{{
+				"kty": "EC",
+				"kid": "k1",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, time.Hour)
+
+	// Concurrent first requests, including one for an unknown kid,
+	// share a single fetch.
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			kid := "k1"
+			if i == 0 {
+				kid = "k2"
+			}
+			_, errs[i] = jwks.Key(context.Background(), "ES256", kid)
+		}()
+	}
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if !errors.Is(errs[0], ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound for unknown kid, got %v", errs[0])
+	}
+	for _, err := range errs[1:] {
+		if err != nil {
+			t.Errorf("expected key, got %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected a single fetch, got %d", n)
+	}
+
+	// Once the TTL expires and the server fails, stale keys are served
+	// and the server is not retried on every request.
+	failing.Store(true)
+	jwks.mu.Lock()
+	jwks.fetchedAt = time.Now().Add(-2 * time.Hour)
+	jwks.attempted = jwks.fetchedAt
+	jwks.mu.Unlock()
+
+	for range 3 {
+		if _, err := jwks.Key(context.Background(), "ES256", "k1"); err != nil {
+			t.Errorf("expected stale key, got %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected one refresh attempt, got %d fetches", n-1)
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// maxBytesBody is the response written when a body exceeds the limit.
+const maxBytesBody = `{"error":"request body too large"}`
+
+// MaxBytes returns middleware limiting request bodies to n bytes. Requests
+// declaring a larger Content-Length are rejected before the handler runs.
+// For bodies of unknown length, reads past the limit fail with an
+// *http.MaxBytesError and whatever response the handler then writes is
+// replaced, so clients consistently receive:
+//
+//	413 Request Entity Too Large
+//	{"error":"request body too large"}
+//
+// Example:
+//
+//	uploads := mux.Group("/uploads")
+//	uploads.Use(middleware.MaxBytes(32 << 20))
+//
+// MaxBytes panics if n is negative.
+func MaxBytes(n int64) func(http.Handler) http.Handler {
+	if n < 0 {
+		panic("hmux: max bytes must not be negative")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				tooLarge(w)
+				return
+			}
+
+			mw := &maxBytesWriter{ResponseWriter: w}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &maxBytesReader{ReadCloser: http.MaxBytesReader(w, r.Body, n), w: mw}
+			}
+
+			next.ServeHTTP(mw, r)
+		})
+	}
+}
+
+// tooLarge writes the 413 JSON response.
+func tooLarge(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-Length", strconv.Itoa(len(maxBytesBody)))
+	h.Del("Content-Encoding")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	io.WriteString(w, maxBytesBody)
+}
+
+// maxBytesReader flags the writer when the limit is exceeded.
+type maxBytesReader struct {
+	io.ReadCloser
+	w *maxBytesWriter
+}
+
+func (b *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.w.exceeded = true
+	}
+
+	return n, err
+}
+
+// maxBytesWriter replaces the handler's response with the 413 response
+// once the body limit has been exceeded.
+type maxBytesWriter struct {
+	http.ResponseWriter
+	exceeded    bool
+	wroteHeader bool
+	replaced    bool
+}
+
+func (mw *maxBytesWriter) WriteHeader(status int) {
+	if mw.wroteHeader {
+		return
+	}
+	mw.wroteHeader = true
+
+	if mw.exceeded {
+		mw.replaced = true
+		tooLarge(mw.ResponseWriter)
+		return
+	}
+
+	mw.ResponseWriter.WriteHeader(status)
+}
+
+func (mw *maxBytesWriter) Write(p []byte) (int, error) {
+	if !mw.wroteHeader {
+		mw.WriteHeader(http.StatusOK)
+	}
+	if mw.replaced {
+		return len(p), nil
+	}
+
+	return mw.ResponseWriter.Write(p)
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (mw *maxBytesWriter) Flush() {
+	if !mw.wroteHeader {
+		mw.WriteHeader(http.StatusOK)
+	}
+
+	_ = http.NewResponseController(mw.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (mw *maxBytesWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(mw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (mw *maxBytesWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
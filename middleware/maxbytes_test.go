@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBytes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	})
+	h := MaxBytes(8)(handler)
+
+	tests := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		code          int
+		expected      string
+	}{
+		{"within limit", "small", false, http.StatusOK, "small"},
+		{"declared too large", "this is too large", false, http.StatusRequestEntityTooLarge, maxBytesBody},
+		{"streamed too large", "this is too large", true, http.StatusRequestEntityTooLarge, maxBytesBody},
+		{"streamed within limit", "small", true, http.StatusOK, "small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if rec.Body.String() != tt.expected {
+				t.Errorf("expected body %q, got %q", tt.expected, rec.Body.String())
+			}
+			if tt.code == http.StatusRequestEntityTooLarge && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("expected JSON content type, got %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideHeader is the request header naming the method a POST
+// request stands in for.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodOverride returns middleware that lets POST requests stand in for
+// the allowed methods, for clients such as HTML forms that can only send
+// GET and POST. The method is taken from the X-HTTP-Method-Override
+// header or, for URL-encoded form bodies, the "_method" form field, and
+// replaces r.Method. Without allowed methods, PUT, PATCH and DELETE may
+// be overridden. Other methods named by a request are ignored, and the
+// request is served as a POST.
+//
+// The method must be rewritten before the request is routed, so
+// register MethodOverride as outer middleware:
+//
+//	mux.UseOuter(middleware.MethodOverride())
+//
+// Reading the form field parses the request body into r.PostForm, where
+// handlers then find the form values.
+func MethodOverride(allowed ...string) func(http.Handler) http.Handler {
+	if len(allowed) == 0 {
+		allowed = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	methods := make(map[string]struct{}, len(allowed))
+	for _, m := range allowed {
+		methods[strings.ToUpper(m)] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			method := r.Header.Get(MethodOverrideHeader)
+			if method == "" {
+				mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if mediaType == "application/x-www-form-urlencoded" {
+					method = r.PostFormValue("_method")
+				}
+			}
+
+			method = strings.ToUpper(strings.TrimSpace(method))
+			if _, ok := methods[method]; ok {
+				// Outer middleware keeps seeing the method the client sent.
+				r2 := *r
+				r2.Method = method
+				r = &r2
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
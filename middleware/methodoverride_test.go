@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestMethodOverride(t *testing.T) {
+	m := hmux.New()
+	m.UseOuter(MethodOverride())
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		m.HandleFunc(method+" /posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Method + " " + r.FormValue("title")))
+		})
+	}
+	m.HandleFunc("GET /posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		header      string
+		contentType string
+		body        string
+		response    string
+	}{
+		{"header", http.MethodPost, "delete", "", "", "DELETE "},
+		{"form field", http.MethodPost, "", "application/x-www-form-urlencoded", "_method=PUT&title=hi", "PUT hi"},
+		{"header wins", http.MethodPost, "DELETE", "application/x-www-form-urlencoded", "_method=PUT", "DELETE "},
+		{"not allowed", http.MethodPost, "GET", "", "", "POST "},
+		{"json body ignored", http.MethodPost, "", "application/json", `{"_method":"PUT"}`, "POST "},
+		{"only from POST", http.MethodGet, "DELETE", "", "", "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/posts/1", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(MethodOverrideHeader, tt.header)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.response {
+				t.Errorf("expected %q, got %d %q", tt.response, rec.Code, rec.Body.String())
+			}
+			if req.Method != tt.method {
+				t.Errorf("expected the caller's request to keep method %s, got %s", tt.method, req.Method)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// DefaultDurationBuckets are the request duration histogram buckets, in
+// seconds, used when none are given. They match the Prometheus client
+// defaults.
+var DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the response size histogram buckets, in bytes.
+var DefaultSizeBuckets = []float64{100, 1000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// unmatchedRoute labels requests that did not match a registered pattern.
+const unmatchedRoute = "unmatched"
+
+// Metrics collects Prometheus-style HTTP metrics and serves them in the
+// Prometheus text exposition format, without depending on the Prometheus
+// client library. It records:
+//
+//   - <namespace>_http_requests_total{method,route,code}
+//   - <namespace>_http_request_duration_seconds{method,route} (histogram)
+//   - <namespace>_http_response_size_bytes{method,route} (histogram)
+//   - <namespace>_http_requests_in_flight{method} (gauge)
+//
+// The route label is the pattern the request matched, such as
+// "GET /users/{id}", rather than the raw path, which keeps label
+// cardinality bounded by the number of registered routes. It is read
+// once the request has been served, so Metrics also works as outer
+// middleware added with UseOuter. Requests that matched no pattern are
+// labeled "unmatched". Methods other than the standard ones are labeled
+// "_OTHER", so clients cannot create series at will. The in-flight gauge
+// is labeled by method only, since a request's route is not known while
+// outer middleware waits for it.
+//
+// Metrics implements http.Handler to expose the collected series:
+//
+//	metrics := middleware.NewMetrics("myapp")
+//	mux.Use(metrics.Handler)
+//	mux.Handle("GET /metrics", metrics)
+type Metrics struct {
+	prefix          string
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	mu       sync.Mutex
+	requests map[[3]string]uint64
+	duration map[[2]string]*histogram
+	size     map[[2]string]*histogram
+	inFlight map[string]int64
+}
+
+// histogram is a cumulative Prometheus histogram.
+type histogram struct {
+	counts []uint64 // per bucket, non-cumulative
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+
+	for i, b := range buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics returns a Metrics collector whose metric names are prefixed
+// with namespace and an underscore. An empty namespace omits the prefix.
+func NewMetrics(namespace string) *Metrics {
+	prefix := ""
+	if namespace != "" {
+		prefix = namespace + "_"
+	}
+
+	return &Metrics{
+		prefix:          prefix,
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		requests:        make(map[[3]string]uint64),
+		duration:        make(map[[2]string]*histogram),
+		size:            make(map[[2]string]*histogram),
+		inFlight:        make(map[string]int64),
+	}
+}
+
+// SetDurationBuckets sets the upper bounds, in seconds, of the request
+// duration histogram.
+//
+// SetDurationBuckets panics if a request has already been observed.
+func (m *Metrics) SetDurationBuckets(buckets ...float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkUnobserved("SetDurationBuckets")
+	m.durationBuckets = sortedBuckets(buckets)
+}
+
+// SetSizeBuckets sets the upper bounds, in bytes, of the response size
+// histogram.
+//
+// SetSizeBuckets panics if a request has already been observed.
+func (m *Metrics) SetSizeBuckets(buckets ...float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkUnobserved("SetSizeBuckets")
+	m.sizeBuckets = sortedBuckets(buckets)
+}
+
+// checkUnobserved panics if a request has been observed, since existing
+// histograms cannot change their buckets. The caller holds m.mu.
+func (m *Metrics) checkUnobserved(name string) {
+	if len(m.requests) > 0 || len(m.inFlight) > 0 {
+		panic("hmux: " + name + " called after requests were observed")
+	}
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	b := slices.Clone(buckets)
+	slices.Sort(b)
+
+	return b
+}
+
+// Handler is middleware recording metrics for every request.
+func (m *Metrics) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := metricsMethod(r.Method)
+
+		m.mu.Lock()
+		m.inFlight[method]++
+		m.mu.Unlock()
+
+		start := time.Now()
+		sw := hmux.NewStatusWriter(w)
+		completed := false
+
+		defer func() {
+			status := sw.Status()
+			switch {
+			case status != 0:
+			case completed:
+				status = http.StatusOK
+			default:
+				// The handler panicked before writing a response, which
+				// the server or a recovery middleware answers with 500.
+				status = http.StatusInternalServerError
+			}
+
+			route := hmux.RoutePattern(r)
+			if route == "" {
+				route = unmatchedRoute
+			}
+			key := [2]string{method, route}
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			m.inFlight[method]--
+			m.requests[[3]string{method, route, strconv.Itoa(status)}]++
+			m.histogram(m.duration, key).observe(m.durationBuckets, time.Since(start).Seconds())
+			m.histogram(m.size, key).observe(m.sizeBuckets, float64(sw.BytesWritten()))
+		}()
+
+		next.ServeHTTP(sw, r)
+		completed = true
+	})
+}
+
+// metricsMethod returns the request method as a label value, mapping
+// methods other than the standard ones to "_OTHER".
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "_OTHER"
+	}
+}
+
+// histogram returns the histogram for key, creating it if needed. The
+// caller holds m.mu.
+func (m *Metrics) histogram(hs map[[2]string]*histogram, key [2]string) *histogram {
+	h, ok := hs[key]
+	if !ok {
+		h = &histogram{}
+		hs[key] = h
+	}
+
+	return h
+}
+
+// ServeHTTP writes all collected metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all collected metrics in the Prometheus text format. The
+// output is sorted by label values, so it is deterministic.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	name := m.prefix + "http_requests_total"
+	fmt.Fprintf(&b, "# HELP %s Total number of HTTP requests.\n# TYPE %s counter\n", name, name)
+	for _, k := range sortedKeys(m.requests) {
+		fmt.Fprintf(&b, "%s{method=%s,route=%s,code=%s} %d\n", name, label(k[0]), label(k[1]), label(k[2]), m.requests[k])
+	}
+
+	name = m.prefix + "http_request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s HTTP request latency in seconds.\n# TYPE %s histogram\n", name, name)
+	writeHistograms(&b, name, m.duration, m.durationBuckets)
+
+	name = m.prefix + "http_response_size_bytes"
+	fmt.Fprintf(&b, "# HELP %s HTTP response body size in bytes.\n# TYPE %s histogram\n", name, name)
+	writeHistograms(&b, name, m.size, m.sizeBuckets)
+
+	name = m.prefix + "http_requests_in_flight"
+	fmt.Fprintf(&b, "# HELP %s Number of HTTP requests being served.\n# TYPE %s gauge\n", name, name)
+	for _, k := range slices.Sorted(maps.Keys(m.inFlight)) {
+		fmt.Fprintf(&b, "%s{method=%s} %d\n", name, label(k), m.inFlight[k])
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func writeHistograms(b *strings.Builder, name string, hs map[[2]string]*histogram, buckets []float64) {
+	for _, k := range sortedKeys(hs) {
+		h := hs[k]
+		labels := "method=" + label(k[0]) + ",route=" + label(k[1])
+
+		var cumulative uint64
+		for i, bound := range buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// sortedKeys returns the keys of m in lexical order.
+func sortedKeys[K [2]string | [3]string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, func(a, b K) int {
+		for i := range len(a) {
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	return keys
+}
+
+// labelEscaper escapes label values for the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label returns s as a quoted label value.
+func label(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
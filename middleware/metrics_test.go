@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics("test")
+	metrics.SetSizeBuckets(10, 100)
+
+	m := hmux.New()
+	m.Use(metrics.Handler)
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user"))
+	})
+	api := m.Group("/api")
+	api.HandleFunc("POST /items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	m.Handle("GET /metrics", metrics)
+
+	for _, target := range []string{"/users/1", "/users/2"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/items", nil))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+
+	expected := []string{
+		"# TYPE test_http_requests_total counter",
+		`test_http_requests_total{method="GET",route="GET /users/{id}",code="200"} 2`,
+		`test_http_requests_total{method="POST",route="POST /api/items",code="201"} 1`,
+		`test_http_response_size_bytes_bucket{method="GET",route="GET /users/{id}",le="10"} 2`,
+		`test_http_response_size_bytes_sum{method="GET",route="GET /users/{id}"} 8`,
+		`test_http_request_duration_seconds_count{method="GET",route="GET /users/{id}"} 2`,
+		`test_http_requests_in_flight{method="GET"} 1`,
+		`test_http_requests_in_flight{method="POST"} 0`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected output to contain %q\n%s", line, out)
+		}
+	}
+
+	if strings.Contains(out, "/users/1") {
+		t.Error("expected raw paths not to appear as labels")
+	}
+}
+
+func TestMetrics_Unmatched(t *testing.T) {
+	metrics := NewMetrics("")
+	h := metrics.Handler(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+
+	if !strings.Contains(b.String(), `http_requests_total{method="GET",route="unmatched",code="404"} 1`) {
+		t.Errorf("expected unmatched route label\n%s", b.String())
+	}
+}
+
+func TestMetrics_Outer(t *testing.T) {
+	metrics := NewMetrics("")
+	m := hmux.New()
+	m.UseOuter(metrics.Handler)
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/users/1", nil))
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="GET /users/{id}",code="200"} 1`,
+		`http_requests_total{method="_OTHER",route="unmatched",code="405"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("expected output to contain %q\n%s", line, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "BREW") {
+		t.Error("expected unknown methods not to appear as labels")
+	}
+}
+
+func TestMetrics_Panic(t *testing.T) {
+	metrics := NewMetrics("")
+	h := metrics.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+
+	if line := `http_requests_total{method="GET",route="unmatched",code="500"} 1`; !strings.Contains(b.String(), line+"\n") {
+		t.Errorf("expected output to contain %q\n%s", line, b.String())
+	}
+}
+
+func TestMetrics_LateBuckets_Panics(t *testing.T) {
+	metrics := NewMetrics("")
+	metrics.Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for name, set := range map[string]func(...float64){
+		"SetDurationBuckets": metrics.SetDurationBuckets,
+		"SetSizeBuckets":     metrics.SetSizeBuckets,
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %s to panic after requests were observed", name)
+				}
+			}()
+			set(1, 2)
+		}()
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if got := label("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("unexpected escaping %s", got)
+	}
+}
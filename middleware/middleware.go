@@ -0,0 +1,11 @@
+// Package middleware provides first-party HTTP middleware for use with
+// hmux. Every middleware in this package has the standard
+// func(http.Handler) http.Handler shape (or returns one), so it can be
+// passed directly to Mux.Use, Group.Use, With, or hmux.Chain:
+//
+//	mux := hmux.New()
+//	mux.Use(middleware.Recoverer)
+//
+// The middleware here only depend on the standard library and work with
+// any router that accepts standard middleware, not just hmux.
+package middleware
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mirror replays a copy of a sample of the incoming requests to a shadow
+// handler, such as a new implementation of a service or a reverse proxy
+// to one, while the primary handler serves them as usual. It lets a new
+// implementation be tested against production traffic without clients
+// seeing its responses:
+//
+//	shadow := httputil.NewSingleHostReverseProxy(candidateURL)
+//	mirror := middleware.NewMirror(shadow, 0.1) // 10% of requests
+//	mux.With(mirror.Handler).HandleFunc("GET /search", search)
+//
+// The shadow handler runs in its own goroutine with a copy of the
+// request whose context is not canceled with the client's request but
+// after the mirror timeout. Its response is discarded and its panics are
+// recovered. To bound the cost of mirroring, request bodies are buffered
+// only up to a size limit, larger requests are not mirrored, and requests
+// arriving while the maximum number of shadow requests is in flight are
+// dropped. Configure the Mirror before serving requests.
+type Mirror struct {
+	shadow      http.Handler
+	rate        float64
+	maxBody     int64
+	timeout     time.Duration
+	maxInFlight int64
+
+	float func() float64 // rand.Float64, replaced in tests
+
+	inFlight atomic.Int64
+	mirrored atomic.Int64
+	dropped  atomic.Int64
+	wg       sync.WaitGroup
+}
+
+// NewMirror returns a Mirror replaying the given share of requests, from
+// 0 to 1, to shadow. Bodies are buffered up to 1 MiB, shadow requests
+// time out after 10 seconds, and at most 100 are in flight.
+//
+// NewMirror panics if shadow is nil or rate is not between 0 and 1.
+func NewMirror(shadow http.Handler, rate float64) *Mirror {
+	if shadow == nil {
+		panic("hmux: nil shadow handler passed to NewMirror")
+	}
+	if rate < 0 || rate > 1 {
+		panic("hmux: mirror rate must be between 0 and 1")
+	}
+
+	return &Mirror{
+		shadow:      shadow,
+		rate:        rate,
+		maxBody:     1 << 20,
+		timeout:     10 * time.Second,
+		maxInFlight: 100,
+		float:       rand.Float64,
+	}
+}
+
+// SetMaxBody sets the size of the largest request body that is buffered
+// for mirroring. Requests with larger bodies are served but not mirrored.
+func (m *Mirror) SetMaxBody(n int64) {
+	m.maxBody = n
+}
+
+// SetTimeout sets how long a shadow request may run before its context
+// is canceled.
+func (m *Mirror) SetTimeout(d time.Duration) {
+	m.timeout = d
+}
+
+// SetMaxInFlight sets the number of shadow requests that may be in
+// flight at once. Sampled requests beyond it are not mirrored.
+//
+// SetMaxInFlight panics if n is less than 1.
+func (m *Mirror) SetMaxInFlight(n int) {
+	if n < 1 {
+		panic("hmux: mirror in-flight limit must be positive")
+	}
+
+	m.maxInFlight = int64(n)
+}
+
+// Mirrored returns the number of requests replayed to the shadow handler
+// so far.
+func (m *Mirror) Mirrored() int {
+	return int(m.mirrored.Load())
+}
+
+// Dropped returns the number of sampled requests that were not mirrored
+// because their body was too large or too many shadow requests were in
+// flight.
+func (m *Mirror) Dropped() int {
+	return int(m.dropped.Load())
+}
+
+// Wait blocks until the shadow requests in flight have finished, for
+// example during a graceful shutdown.
+func (m *Mirror) Wait() {
+	m.wg.Wait()
+}
+
+// Handler is the mirroring middleware.
+func (m *Mirror) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.rate == 0 || m.float() >= m.rate {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, ok := bufferBody(r, m.maxBody)
+		if !ok || m.inFlight.Add(1) > m.maxInFlight {
+			if ok {
+				m.inFlight.Add(-1)
+			}
+			m.dropped.Add(1)
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.mirrored.Add(1)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), m.timeout)
+		shadow := r.Clone(ctx)
+		if body != nil {
+			shadow.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			defer m.inFlight.Add(-1)
+			defer cancel()
+			defer func() { _ = recover() }()
+
+			m.shadow.ServeHTTP(&discardWriter{header: make(http.Header)}, shadow)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bufferBody reads the body of r, if it has one, and replaces it with a
+// reader replaying it. It reports false if the body is larger than
+// maxBody, in which case the handler still receives all of it.
+func bufferBody(r *http.Request, maxBody int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > maxBody {
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	if err != nil || int64(len(body)) > maxBody {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false
+	}
+	r.Body = readCloser{bytes.NewReader(body), r.Body}
+
+	return body, true
+}
+
+// readCloser reads from a Reader and closes a separate Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// discardWriter is the ResponseWriter of shadow requests. It discards
+// the response.
+type discardWriter struct {
+	header http.Header
+}
+
+func (dw *discardWriter) Header() http.Header {
+	return dw.header
+}
+
+func (dw *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (dw *discardWriter) WriteHeader(int) {}
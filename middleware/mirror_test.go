@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	shadow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Test")+" "+string(body))
+		mu.Unlock()
+		w.Write([]byte("shadow response"))
+	})
+
+	m := NewMirror(shadow, 1)
+	m.SetMaxBody(8)
+	h := m.Handler(http.HandlerFunc(echoBody))
+
+	for _, body := range []string{"", "small", "much too large"} {
+		req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(body))
+		req.Header.Set("X-Test", "yes")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Body.String() != body {
+			t.Errorf("expected primary body %q, got %q", body, rec.Body.String())
+		}
+	}
+	m.Wait()
+
+	slices.Sort(seen)
+	expected := []string{"POST /search yes ", "POST /search yes small"}
+	if !slices.Equal(seen, expected) {
+		t.Errorf("expected shadow requests %q, got %q", expected, seen)
+	}
+	if m.Mirrored() != 2 || m.Dropped() != 1 {
+		t.Errorf("expected 2 mirrored and 1 dropped, got %d and %d", m.Mirrored(), m.Dropped())
+	}
+}
+
+func TestMirror_Sampling(t *testing.T) {
+	m := NewMirror(http.NotFoundHandler(), 0.25)
+	samples := []float64{0.1, 0.3, 0.24, 0.9}
+	m.float = func() float64 {
+		f := samples[0]
+		samples = samples[1:]
+		return f
+	}
+	h := m.Handler(http.HandlerFunc(echoBody))
+
+	for range 4 {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	m.Wait()
+
+	if m.Mirrored() != 2 {
+		t.Errorf("expected 2 mirrored, got %d", m.Mirrored())
+	}
+}
+
+func TestMirror_Detached(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var shadowErr error
+	shadow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		shadowErr = r.Context().Err()
+		panic("shadow failure")
+	})
+
+	m := NewMirror(shadow, 1)
+	m.SetMaxInFlight(1)
+	h := m.Handler(http.HandlerFunc(echoBody))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	cancel()
+	<-started
+
+	// The shadow limit is reached, so this request is served unmirrored.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	close(release)
+	m.Wait()
+
+	if shadowErr != nil {
+		t.Errorf("expected the shadow context to outlive the request, got %v", shadowErr)
+	}
+	if m.Mirrored() != 1 || m.Dropped() != 1 {
+		t.Errorf("expected 1 mirrored and 1 dropped, got %d and %d", m.Mirrored(), m.Dropped())
+	}
+}
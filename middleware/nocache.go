@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// noCacheHeaders are set on every response passing through NoCache.
+var noCacheHeaders = map[string]string{
+	"Cache-Control":     "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+	"Pragma":            "no-cache",
+	"Expires":           time.Unix(0, 0).UTC().Format(http.TimeFormat),
+	"X-Accel-Expires":   "0",
+	"Surrogate-Control": "no-store",
+}
+
+// validatorHeaders are response headers that let caches revalidate.
+var validatorHeaders = []string{"ETag", "Last-Modified"}
+
+// conditionalHeaders are request headers that could turn a response into
+// a 304 Not Modified.
+var conditionalHeaders = []string{
+	"If-Match",
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Unmodified-Since",
+	"If-Range",
+}
+
+// NoCache is middleware that prevents clients and intermediaries from
+// caching responses. It sets Cache-Control, Pragma, Expires and the
+// proxy-specific X-Accel-Expires and Surrogate-Control headers, strips
+// ETag and Last-Modified from the response, and removes conditional
+// headers from the request so the handler always produces a full
+// response.
+//
+// Example:
+//
+//	dashboard := mux.Group("/dashboard")
+//	dashboard.Use(middleware.NoCache)
+func NoCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range conditionalHeaders {
+			r.Header.Del(h)
+		}
+
+		next.ServeHTTP(&noCacheWriter{ResponseWriter: w}, r)
+	})
+}
+
+// noCacheWriter applies the no-cache headers just before the response
+// header is written, overriding anything the handler set.
+type noCacheWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (nw *noCacheWriter) WriteHeader(status int) {
+	if !nw.wroteHeader {
+		nw.wroteHeader = true
+
+		h := nw.Header()
+		for _, k := range validatorHeaders {
+			h.Del(k)
+		}
+		for k, v := range noCacheHeaders {
+			h.Set(k, v)
+		}
+	}
+
+	nw.ResponseWriter.WriteHeader(status)
+}
+
+func (nw *noCacheWriter) Write(p []byte) (int, error) {
+	if !nw.wroteHeader {
+		nw.WriteHeader(http.StatusOK)
+	}
+
+	return nw.ResponseWriter.Write(p)
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (nw *noCacheWriter) Flush() {
+	if !nw.wroteHeader {
+		nw.WriteHeader(http.StatusOK)
+	}
+
+	_ = http.NewResponseController(nw.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (nw *noCacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(nw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (nw *noCacheWriter) Unwrap() http.ResponseWriter {
+	return nw.ResponseWriter
+}
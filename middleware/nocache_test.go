@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoCache(t *testing.T) {
+	var sawIfNoneMatch bool
+	h := NoCache(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawIfNoneMatch = r.Header.Get("If-None-Match") != ""
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write([]byte("fresh"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if sawIfNoneMatch {
+		t.Error("expected If-None-Match to be stripped from the request")
+	}
+	for k, v := range noCacheHeaders {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("expected %s %q, got %q", k, v, got)
+		}
+	}
+	for _, k := range validatorHeaders {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("expected %s to be stripped, got %q", k, got)
+		}
+	}
+	if rec.Body.String() != "fresh" {
+		t.Errorf("expected body %q, got %q", "fresh", rec.Body.String())
+	}
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KeyFunc extracts the rate-limiting key from a request, for example the
+// client IP, an API key, or a tenant ID. Requests for which the key is
+// empty are not limited.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP is a KeyFunc that limits by client IP as found in r.RemoteAddr.
+// Combine it with RealIP when running behind a reverse proxy.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// KeyByHeader returns a KeyFunc that limits by the value of the named
+// request header.
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+
+	// Reset is the time until the bucket is completely refilled.
+	Reset time.Duration
+
+	// RetryAfter is the time until the next token becomes available.
+	// It is zero when Allowed is true.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore holds token buckets. The default store keeps buckets in
+// process memory; alternative backends (Redis, memcached) implement this
+// interface to share limits across instances.
+//
+// Take must be safe for concurrent use. Each bucket holds at most limit
+// tokens and refills at limit tokens per window.
+type RateLimitStore interface {
+	Take(key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimiter is token-bucket rate-limiting middleware. Each key gets a
+// bucket of Limit tokens refilled evenly over Window, so bursts of up to
+// Limit requests are allowed while the sustained rate is Limit per Window.
+//
+// Every response carries X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds until the bucket is full). Rejected requests
+// receive 429 Too Many Requests with a Retry-After header.
+//
+// If the store returns an error the request is allowed through, so an
+// unavailable backend degrades to no limiting rather than an outage.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	key    KeyFunc
+	store  RateLimitStore
+}
+
+// NewRateLimiter returns a RateLimiter allowing limit requests per window
+// for each key, backed by an in-memory store.
+//
+// NewRateLimiter panics if limit or window is not positive or key is nil.
+func NewRateLimiter(limit int, window time.Duration, key KeyFunc) *RateLimiter {
+	if limit <= 0 || window <= 0 {
+		panic("hmux: rate limit and window must be positive")
+	}
+	if key == nil {
+		panic("hmux: nil KeyFunc passed to NewRateLimiter")
+	}
+
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		key:    key,
+		store:  NewMemoryRateLimitStore(),
+	}
+}
+
+// SetStore replaces the bucket store. It must be called before the
+// limiter starts serving requests.
+//
+// SetStore panics if store is nil.
+func (l *RateLimiter) SetStore(store RateLimitStore) {
+	if store == nil {
+		panic("hmux: nil RateLimitStore passed to SetStore")
+	}
+
+	l.store = store
+}
+
+// Handler is middleware enforcing the rate limit.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := l.store.Take(key, l.limit, l.window)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimit returns middleware allowing limit requests per window for
+// each key. It is shorthand for NewRateLimiter(limit, window, key).Handler.
+//
+// Example:
+//
+//	api.Use(middleware.RateLimit(100, time.Minute, middleware.KeyByIP))
+func RateLimit(limit int, window time.Duration, key KeyFunc) func(http.Handler) http.Handler {
+	return NewRateLimiter(limit, window, key).Handler
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore. Buckets that have
+// been full for at least one window are evicted periodically, so memory
+// use is bounded by the number of recently active keys.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// bucket is a token bucket whose level is computed lazily on access.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rate := float64(limit) / window.Seconds() // tokens per second
+
+	if now.Sub(s.lastSweep) >= window {
+		s.sweep(now, float64(limit), rate)
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	var res RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit) - b.tokens) / rate)
+
+	return res, nil
+}
+
+// sweep evicts buckets that would have refilled completely by now.
+func (s *MemoryRateLimitStore) sweep(now time.Time, limit, rate float64) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= limit {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// seconds converts fractional seconds to a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
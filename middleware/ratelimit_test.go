@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(string, int, time.Duration) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit(2, time.Minute, KeyByIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 3)
+	var last *httptest.ResponseRecorder
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "1.2.3.4:1000"
+		last = httptest.NewRecorder()
+		h.ServeHTTP(last, req)
+		codes = append(codes, last.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("expected [200 200 429], got %v", codes)
+	}
+	if last.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("expected X-RateLimit-Limit 2, got %q", last.Header().Get("X-RateLimit-Limit"))
+	}
+	if last.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0, got %q", last.Header().Get("X-RateLimit-Remaining"))
+	}
+	if last.Header().Get("Retry-After") != "30" {
+		t.Errorf("expected Retry-After 30, got %q", last.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimit_SeparateKeys(t *testing.T) {
+	h := RateLimit(1, time.Minute, KeyByHeader("X-API-Key"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, key := range []string{"a", "b", ""} {
+		for range 2 {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-API-Key", key)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if key == "" && rec.Code != http.StatusOK {
+				t.Errorf("expected empty key to be unlimited, got %d", rec.Code)
+			}
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "c")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected fresh key to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_StoreErrorFailsOpen(t *testing.T) {
+	l := NewRateLimiter(1, time.Minute, KeyByIP)
+	l.SetStore(failingStore{})
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestMemoryRateLimitStore_Refill(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryRateLimitStore()
+	s.now = func() time.Time { return now }
+
+	for range 10 {
+		s.Take("k", 10, 10*time.Second)
+	}
+	if res, _ := s.Take("k", 10, 10*time.Second); res.Allowed {
+		t.Fatal("expected bucket to be empty")
+	}
+
+	now = now.Add(time.Second)
+	res, _ := s.Take("k", 10, 10*time.Second)
+	if !res.Allowed {
+		t.Error("expected one token after one second")
+	}
+	if res.Reset != 10*time.Second {
+		t.Errorf("expected reset 10s, got %v", res.Reset)
+	}
+}
+
+func TestMemoryRateLimitStore_Sweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryRateLimitStore()
+	s.now = func() time.Time { return now }
+
+	s.Take("old", 1, time.Second)
+	now = now.Add(2 * time.Second)
+	s.Take("new", 1, time.Second)
+
+	if _, ok := s.buckets["old"]; ok {
+		t.Error("expected refilled bucket to be evicted")
+	}
+}
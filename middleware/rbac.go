@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// PermissionMeta is the route metadata key declaring the permission a
+// route requires, as a string or, to require several, a []string.
+const PermissionMeta = "permission"
+
+// Authorizer decides whether the principal described by claims holds a
+// permission.
+type Authorizer interface {
+	Authorize(ctx context.Context, claims hmux.Claims, permission string) (bool, error)
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface.
+type AuthorizerFunc func(ctx context.Context, claims hmux.Claims, permission string) (bool, error)
+
+// Authorize implements Authorizer.
+func (f AuthorizerFunc) Authorize(ctx context.Context, claims hmux.Claims, permission string) (bool, error) {
+	return f(ctx, claims, permission)
+}
+
+// RolePermissions returns an Authorizer granting the permissions of the
+// roles in the "roles" claim, a string or an array of strings. roles maps
+// each role to its permissions; the permission "*" grants all.
+func RolePermissions(roles map[string][]string) Authorizer {
+	return AuthorizerFunc(func(_ context.Context, claims hmux.Claims, permission string) (bool, error) {
+		var names []string
+		switch v := claims["roles"].(type) {
+		case string:
+			names = []string{v}
+		case []any:
+			for _, role := range v {
+				if s, ok := role.(string); ok {
+					names = append(names, s)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if granted := roles[name]; slices.Contains(granted, permission) || slices.Contains(granted, "*") {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// RBAC is authorization middleware enforcing the permissions that routes
+// declare in their metadata, so the policy lives next to the route
+// registration:
+//
+//	rbac := middleware.NewRBAC(middleware.RolePermissions(map[string][]string{
+//	    "admin":      {"*"},
+//	    "accountant": {"invoices:read", "invoices:write"},
+//	}))
+//	api.Use(auth.Handler, rbac.Handler)
+//
+//	api.Meta(hmux.M{middleware.PermissionMeta: "invoices:write"}).
+//	    HandleFunc("POST /invoices", createInvoice)
+//
+// The principal is read from the claims in the request context, so RBAC
+// must run after the authentication middleware. Requests for routes that
+// declare a permission are rejected with 401 Unauthorized if they carry
+// no claims, with 403 Forbidden if a permission is not held, and with 503
+// Service Unavailable if the Authorizer fails. Routes without a
+// permission are not checked.
+type RBAC struct {
+	authorizer Authorizer
+}
+
+// NewRBAC returns an RBAC checking permissions with authorizer.
+//
+// NewRBAC panics if authorizer is nil.
+func NewRBAC(authorizer Authorizer) *RBAC {
+	if authorizer == nil {
+		panic("hmux: nil Authorizer passed to NewRBAC")
+	}
+
+	return &RBAC{authorizer: authorizer}
+}
+
+// Handler is the authorization middleware.
+func (a *RBAC) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var required []string
+		switch p := hmux.MetaFromContext(r.Context())[PermissionMeta].(type) {
+		case string:
+			required = []string{p}
+		case []string:
+			required = p
+		}
+		if len(required) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims := hmux.ClaimsFromContext(r.Context())
+		if claims == nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		for _, permission := range required {
+			ok, err := a.authorizer.Authorize(r.Context(), claims, permission)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestRBAC(t *testing.T) {
+	roles := RolePermissions(map[string][]string{
+		"admin":      {"*"},
+		"accountant": {"invoices:read", "invoices:write"},
+		"viewer":     {"invoices:read"},
+	})
+	rbac := NewRBAC(AuthorizerFunc(func(ctx context.Context, c hmux.Claims, permission string) (bool, error) {
+		if c.Subject() == "broken" {
+			return false, errors.New("policy store down")
+		}
+		return roles.Authorize(ctx, c, permission)
+	}))
+
+	// Claims come from a header so each case can pick its principal.
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Header.Get("X-User") {
+			case "":
+			case "multi":
+				r = r.WithContext(hmux.ContextWithClaims(r.Context(), hmux.Claims{"roles": []any{"viewer", "accountant"}}))
+			default:
+				c := hmux.Claims{"sub": r.Header.Get("X-User"), "roles": r.Header.Get("X-User")}
+				r = r.WithContext(hmux.ContextWithClaims(r.Context(), c))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	m := hmux.New()
+	m.Use(auth, rbac.Handler)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	m.HandleFunc("GET /public", ok)
+	m.Meta(hmux.M{PermissionMeta: "invoices:read"}).HandleFunc("GET /invoices", ok)
+	m.Meta(hmux.M{PermissionMeta: "invoices:write"}).HandleFunc("POST /invoices", ok)
+	m.Meta(hmux.M{PermissionMeta: []string{"invoices:write", "invoices:void"}}).HandleFunc("POST /invoices/{id}/void", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		code   int
+	}{
+		{"no permission declared", http.MethodGet, "/public", "", http.StatusOK},
+		{"anonymous", http.MethodGet, "/invoices", "", http.StatusUnauthorized},
+		{"granted", http.MethodGet, "/invoices", "viewer", http.StatusOK},
+		{"denied", http.MethodPost, "/invoices", "viewer", http.StatusForbidden},
+		{"roles array", http.MethodPost, "/invoices", "multi", http.StatusOK},
+		{"wildcard", http.MethodPost, "/invoices/1/void", "admin", http.StatusOK},
+		{"all required", http.MethodPost, "/invoices/1/void", "accountant", http.StatusForbidden},
+		{"unknown role", http.MethodGet, "/invoices", "guest", http.StatusForbidden},
+		{"authorizer error", http.MethodGet, "/invoices", "broken", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP returns middleware that sets r.RemoteAddr to the originating
+// client IP reported by a reverse proxy. The X-Forwarded-For and
+// X-Real-IP headers are only honoured when the immediate peer, taken from
+// r.RemoteAddr, belongs to one of the trusted networks. Requests from any
+// other peer are passed through untouched, so clients cannot spoof their
+// address by sending the headers themselves.
+//
+// Each entry in trusted is a CIDR prefix ("10.0.0.0/8") or a single
+// address ("192.168.1.10"). X-Forwarded-For is walked from right to left,
+// skipping trusted hops, and the first untrusted address is taken as the
+// client. When every hop is trusted the leftmost address is used. If the
+// request has no usable X-Forwarded-For, X-Real-IP is consulted.
+//
+// The rewritten RemoteAddr holds a bare IP address without a port.
+//
+// Example:
+//
+//	mux.Use(middleware.RealIP("10.0.0.0/8", "fd00::/8"))
+//
+// RealIP panics if any entry in trusted cannot be parsed.
+func RealIP(trusted ...string) func(http.Handler) http.Handler {
+	prefixes := make([]netip.Prefix, 0, len(trusted))
+	for _, s := range trusted {
+		p, err := parseTrusted(s)
+		if err != nil {
+			panic("hmux: invalid trusted proxy " + s + ": " + err.Error())
+		}
+
+		prefixes = append(prefixes, p)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range prefixes {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseRemoteAddr(r.RemoteAddr)
+			if ok && isTrusted(peer) {
+				if ip, ok := clientIP(r.Header, isTrusted); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP extracts the client address from proxy headers, preferring
+// X-Forwarded-For over X-Real-IP.
+func clientIP(h http.Header, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []netip.Addr
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, s := range strings.Split(v, ",") {
+			addr, err := netip.ParseAddr(strings.TrimSpace(s))
+			if err != nil {
+				// A malformed hop makes everything to its left
+				// untrustworthy.
+				hops = hops[:0]
+				continue
+			}
+
+			hops = append(hops, addr.Unmap())
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !isTrusted(hops[i]) {
+			return hops[i], true
+		}
+	}
+
+	if len(hops) > 0 {
+		return hops[0], true
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(h.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap(), true
+	}
+
+	return netip.Addr{}, false
+}
+
+// parseRemoteAddr parses an "ip:port" or bare "ip" remote address.
+func parseRemoteAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+// parseTrusted parses a CIDR prefix or a single IP address.
+func parseTrusted(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		return p.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
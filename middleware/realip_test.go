@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		xRealIP    string
+		expected   string
+	}{
+		{
+			name:       "untrusted peer ignores headers",
+			remoteAddr: "203.0.113.5:1234",
+			xff:        []string{"1.2.3.4"},
+			expected:   "203.0.113.5:1234",
+		},
+		{
+			name:       "trusted peer uses X-Forwarded-For",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "skips trusted hops from the right",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"6.6.6.6, 1.2.3.4, 10.0.0.2"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "multiple header lines",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"6.6.6.6", "1.2.3.4"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "all hops trusted uses leftmost",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"10.0.0.3, 10.0.0.2"},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "falls back to X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			xRealIP:    "1.2.3.4",
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "no headers leaves address",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1:1234",
+		},
+		{
+			name:       "single trusted address",
+			remoteAddr: "192.168.1.10:80",
+			xff:        []string{"1.2.3.4"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "ipv6 peer",
+			remoteAddr: "[fd00::1]:1234",
+			xff:        []string{"2001:db8::1"},
+			expected:   "2001:db8::1",
+		},
+	}
+
+	mw := RealIP("10.0.0.0/8", "192.168.1.10", "fd00::/8")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRealIP_InvalidTrusted_Panics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for invalid trusted proxy")
+		}
+	}()
+	RealIP("not-an-ip")
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Exchange is a request and its response as recorded by Recorder. It
+// encodes to JSON; bodies are base64-encoded.
+type Exchange struct {
+	Time     time.Time        `json:"time"`
+	Method   string           `json:"method"`
+	URL      string           `json:"url"` // request URI as sent by the client
+	Host     string           `json:"host,omitempty"`
+	Header   http.Header      `json:"header,omitempty"`
+	Body     []byte           `json:"body,omitempty"`
+	Route    string           `json:"route,omitempty"` // matched pattern
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedResponse is the response of an Exchange.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Recorder is middleware that records requests and their responses, so
+// production or staging traffic can be replayed against a new build in
+// regression tests with hmuxtest.Client.Replay:
+//
+//	f, _ := os.Create("recordings.jsonl")
+//	rec := middleware.NewRecorder(f, 64<<10)
+//	api.Use(rec.Handler)
+//
+// Each exchange is written to the writer as one line of JSON, with a
+// single Write call; calls are serialized. Exchanges whose request or
+// response body exceeds the size limit, or whose connection was
+// hijacked, are not recorded. Headers, URLs and bodies are scrubbed by
+// the Redactor of the route, set under RedactorMeta, or else by the
+// Recorder's own, which has the default rules of NewRedactor unless
+// replaced with SetRedactor. SetSanitize scrubs anything else. Configure
+// the Recorder before serving requests.
+type Recorder struct {
+	w        io.Writer
+	maxBody  int
+	redactor *Redactor
+	sanitize func(e *Exchange)
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a Recorder writing exchanges with bodies of up to
+// maxBody bytes to w.
+//
+// NewRecorder panics if w is nil or maxBody is less than 1.
+func NewRecorder(w io.Writer, maxBody int) *Recorder {
+	if w == nil {
+		panic("hmux: nil writer passed to NewRecorder")
+	}
+	if maxBody < 1 {
+		panic("hmux: recorder body limit must be positive")
+	}
+
+	return &Recorder{
+		w:        w,
+		maxBody:  maxBody,
+		redactor: NewRedactor(),
+	}
+}
+
+// SetRedactor sets the Redactor scrubbing the exchanges of routes that
+// have none in their metadata. A nil Redactor records them as is.
+func (rec *Recorder) SetRedactor(rd *Redactor) {
+	rec.redactor = rd
+}
+
+// SetSanitize sets a hook scrubbing each exchange before it is written,
+// for data no Redactor rule covers. It runs after redaction.
+func (rec *Recorder) SetSanitize(fn func(e *Exchange)) {
+	rec.sanitize = fn
+}
+
+// Handler is the recording middleware.
+func (rec *Recorder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		body, ok := bufferBody(r, int64(rec.maxBody))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := r.Header.Clone()
+
+		cw := hmux.NewCaptureWriter(w, rec.maxBody)
+		next.ServeHTTP(cw, r)
+		if !cw.Complete() {
+			return
+		}
+
+		e := &Exchange{
+			Time:   start,
+			Method: r.Method,
+			URL:    r.RequestURI,
+			Host:   r.Host,
+			Header: header,
+			Body:   body,
+			Route:  r.Pattern,
+			Response: RecordedResponse{
+				Status: cw.Status(),
+				Header: cw.SentHeader(),
+				Body:   cw.Bytes(),
+			},
+		}
+		if e.URL == "" {
+			e.URL = r.URL.RequestURI()
+		}
+		if e.Response.Status == 0 {
+			e.Response.Status = http.StatusOK
+			e.Response.Header = w.Header().Clone()
+		}
+
+		rd := RedactorFromContext(r.Context())
+		if rd == nil {
+			rd = rec.redactor
+		}
+		rec.write(e, rd)
+	})
+}
+
+// write redacts and sanitizes e and writes it as a line of JSON.
+func (rec *Recorder) write(e *Exchange, rd *Redactor) {
+	e.URL = rd.URL(e.URL)
+	e.Body = rd.Body(e.Header.Get("Content-Type"), e.Body)
+	e.Header = rd.Header(e.Header)
+	e.Response.Body = rd.Body(e.Response.Header.Get("Content-Type"), e.Response.Body)
+	e.Response.Header = rd.Header(e.Response.Header)
+	if rec.sanitize != nil {
+		rec.sanitize(e)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	rec.mu.Lock()
+	rec.w.Write(line)
+	rec.mu.Unlock()
+}
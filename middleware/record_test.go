@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, 8)
+	rec.SetSanitize(func(e *Exchange) {
+		e.Header.Del("X-Trace")
+	})
+	h := rec.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+		echoBody(w, r)
+	}))
+
+	tests := []struct {
+		body     string
+		recorded bool
+	}{
+		{"short", true},
+		{"much too large", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users?email=secret@example.com", strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("Accept", "text/plain")
+		req.Header.Set("X-Trace", "1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.body {
+			t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 recorded exchange, got %d: %q", len(lines), lines)
+	}
+
+	var e Exchange
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name, expected, got string
+	}{
+		{"method", http.MethodPost, e.Method},
+		{"url", "/users?email=" + Redacted, e.URL},
+		{"x-trace", "", e.Header.Get("X-Trace")},
+		{"body", "short", string(e.Body)},
+		{"authorization", Redacted, e.Header.Get("Authorization")},
+		{"accept", "text/plain", e.Header.Get("Accept")},
+		{"set-cookie", Redacted, e.Response.Header.Get("Set-Cookie")},
+		{"response body", "short", string(e.Response.Body)},
+	}
+	for _, c := range checks {
+		if c.got != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, c.got)
+		}
+	}
+	if e.Response.Status != http.StatusOK {
+		t.Errorf("expected status 200, got %d", e.Response.Status)
+	}
+}
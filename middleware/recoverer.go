@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Recoverer is middleware that recovers from panics in downstream
+// handlers, logs the panic value together with the goroutine stack trace,
+// and responds with 500 Internal Server Error.
+//
+// Panics with http.ErrAbortHandler are re-raised untouched. That sentinel
+// is used to deliberately abort a response, and net/http already handles
+// it by closing the connection without logging a stack trace.
+//
+// Recoverer should be registered as the first (outermost) middleware so
+// that panics raised by other middleware are recovered as well:
+//
+//	mux := hmux.New()
+//	mux.Use(middleware.Recoverer)
+//	mux.Use(logging, auth)
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+
+			if err, ok := rvr.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rvr)
+			}
+
+			log.Printf("hmux: %spanic serving %s %s: %v\n%s", logPrefix(r), r.Method, RedactorFromContext(r.Context()).String(r.URL.Path), rvr, debug.Stack())
+
+			// A connection taken over by an upgrade no longer speaks HTTP,
+			// so there is no response to write.
+			if !hmux.IsUpgrade(r) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logPrefix returns "[<request-id>] " when the request carries an ID set
+// by RequestID, or an empty string otherwise.
+func logPrefix(r *http.Request) string {
+	if id := hmux.RequestIDFromContext(r.Context()); id != "" {
+		return "[" + id + "] "
+	}
+
+	return ""
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLog redirects the standard logger into a buffer for the
+// duration of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	flags, out := log.Flags(), log.Writer()
+	log.SetFlags(0)
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		log.SetFlags(flags)
+		log.SetOutput(out)
+	})
+
+	return &buf
+}
+
+func TestRecoverer_PanicReturns500(t *testing.T) {
+	logs := captureLog(t)
+
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+	if !strings.Contains(logs.String(), "boom") {
+		t.Errorf("expected panic value in log, got %q", logs.String())
+	}
+	if !strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("expected stack trace in log, got %q", logs.String())
+	}
+}
+
+func TestRecoverer_NoPanic(t *testing.T) {
+	logs := captureLog(t)
+
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("expected status 418, got %d", rec.Code)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no log output, got %q", logs.String())
+	}
+}
+
+func TestRecoverer_ErrAbortHandler_Repanics(t *testing.T) {
+	logs := captureLog(t)
+
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		rvr := recover()
+		err, ok := rvr.(error)
+		if !ok || !errors.Is(err, http.ErrAbortHandler) {
+			t.Errorf("expected http.ErrAbortHandler to be re-raised, got %v", rvr)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no log output, got %q", logs.String())
+		}
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+}
+
+func TestRecoverer_Upgrade_NoStatus(t *testing.T) {
+	captureLog(t)
+
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code == http.StatusInternalServerError {
+		t.Error("expected no 500 response for upgraded connection")
+	}
+}
@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Redacted replaces sensitive data in logs and recordings.
+const Redacted = "[REDACTED]"
+
+// RedactorMeta is the route metadata key holding the *Redactor that the
+// logging and recording middleware apply to the route's requests, so
+// redaction is configured per group:
+//
+//	api := mux.Meta(hmux.M{middleware.RedactorMeta: middleware.NewRedactor()}).Group("/api")
+const RedactorMeta = "redactor"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Redactor removes personal data and secrets from the requests and
+// responses that reach logs and recordings. It replaces with Redacted
+//
+//   - the values of sensitive headers, such as Authorization and Cookie;
+//   - the values of sensitive fields, such as "password" or "token", in
+//     query strings, form bodies and JSON bodies, at any depth;
+//   - email addresses, JSON Web Tokens, card numbers passing the Luhn
+//     check, and matches of custom patterns, wherever they appear.
+//
+// Routes pick their Redactor from their metadata under RedactorMeta; see
+// RedactorFromContext. A Redactor must be fully configured before it is
+// used, and is then safe for concurrent use. A nil Redactor redacts
+// nothing.
+type Redactor struct {
+	headers  map[string]bool
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor with the default rules: the headers
+// Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-API-Key,
+// the fields password, secret, token, access_token, refresh_token,
+// id_token, client_secret, api_key and apikey, and the patterns for email
+// addresses, JSON Web Tokens and card numbers.
+func NewRedactor() *Redactor {
+	rd := &Redactor{
+		headers:  make(map[string]bool),
+		fields:   make(map[string]bool),
+		patterns: []*regexp.Regexp{emailPattern, jwtPattern},
+	}
+	rd.RedactHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key")
+	rd.RedactFields("password", "secret", "token", "access_token", "refresh_token", "id_token", "client_secret", "api_key", "apikey")
+
+	return rd
+}
+
+// RedactHeaders adds headers whose values are redacted and returns the
+// Redactor.
+func (rd *Redactor) RedactHeaders(names ...string) *Redactor {
+	for _, name := range names {
+		rd.headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return rd
+}
+
+// RedactFields adds query parameters, form fields and JSON object keys
+// whose values are redacted, matched case-insensitively, and returns the
+// Redactor.
+func (rd *Redactor) RedactFields(names ...string) *Redactor {
+	for _, name := range names {
+		rd.fields[strings.ToLower(name)] = true
+	}
+
+	return rd
+}
+
+// RedactPattern adds a pattern whose matches are redacted wherever they
+// appear and returns the Redactor.
+func (rd *Redactor) RedactPattern(re *regexp.Regexp) *Redactor {
+	rd.patterns = append(rd.patterns, re)
+	return rd
+}
+
+// String returns s with the matches of the Redactor's patterns redacted.
+func (rd *Redactor) String(s string) string {
+	if rd == nil || s == "" {
+		return s
+	}
+
+	for _, re := range rd.patterns {
+		s = re.ReplaceAllLiteralString(s, Redacted)
+	}
+
+	return cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return Redacted
+		}
+		return m
+	})
+}
+
+// Header returns a copy of h with the values of sensitive headers
+// redacted and patterns redacted in the others.
+func (rd *Redactor) Header(h http.Header) http.Header {
+	if rd == nil || h == nil {
+		return h
+	}
+
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		clean := make([]string, len(values))
+		for i, v := range values {
+			if rd.headers[name] {
+				clean[i] = Redacted
+			} else {
+				clean[i] = rd.String(v)
+			}
+		}
+		redacted[name] = clean
+	}
+
+	return redacted
+}
+
+// URL returns u, a URL or request URI, with the values of sensitive
+// query parameters redacted and patterns redacted in the rest.
+func (rd *Redactor) URL(u string) string {
+	if rd == nil {
+		return u
+	}
+
+	base, query, ok := strings.Cut(u, "?")
+	if !ok {
+		return rd.String(u)
+	}
+
+	return rd.String(base) + "?" + rd.query(query)
+}
+
+// Body returns body, of the given media type, with sensitive fields of
+// form and JSON bodies redacted and patterns redacted in textual bodies.
+// Other bodies are returned unchanged.
+func (rd *Redactor) Body(contentType string, body []byte) []byte {
+	if rd == nil || len(body) == 0 {
+		return body
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return []byte(rd.query(string(body)))
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return []byte(rd.String(string(body)))
+		}
+		redacted, err := json.Marshal(rd.value(v))
+		if err != nil {
+			return []byte(rd.String(string(body)))
+		}
+		return redacted
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return []byte(rd.String(string(body)))
+	}
+
+	return body
+}
+
+// query redacts a URL-encoded query string, keeping its order.
+func (rd *Redactor) query(query string) string {
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+
+		switch {
+		case !ok:
+		case rd.fields[strings.ToLower(name)]:
+			pairs[i] = key + "=" + Redacted
+		default:
+			v, err := url.QueryUnescape(value)
+			if err != nil {
+				pairs[i] = key + "=" + rd.String(value)
+			} else if clean := rd.String(v); clean != v {
+				pairs[i] = key + "=" + strings.ReplaceAll(url.QueryEscape(clean), url.QueryEscape(Redacted), Redacted)
+			}
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// value redacts a decoded JSON value.
+func (rd *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			if rd.fields[strings.ToLower(k)] {
+				v[k] = Redacted
+			} else {
+				v[k] = rd.value(elem)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = rd.value(elem)
+		}
+	case string:
+		return rd.String(v)
+	}
+
+	return v
+}
+
+// luhn reports whether the digits of s pass the Luhn check used by card
+// numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// RedactorFromContext returns the Redactor of the route serving the
+// request, as set in its metadata under RedactorMeta, or nil if the
+// route has none. Custom logging and audit layers use it to honor the
+// per-group configuration:
+//
+//	rd := middleware.RedactorFromContext(r.Context())
+//	slog.Info("request", "uri", rd.URL(r.RequestURI))
+func RedactorFromContext(ctx context.Context) *Redactor {
+	rd, _ := hmux.MetaFromContext(ctx)[RedactorMeta].(*Redactor)
+	return rd
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	rd := NewRedactor().
+		RedactFields("cvv").
+		RedactPattern(regexp.MustCompile(`\bDE\d{20}\b`))
+
+	texts := []struct {
+		in, expected string
+	}{
+		{"contact ada@example.com today", "contact [REDACTED] today"},
+		{"card 4111 1111 1111 1111 on file", "card [REDACTED] on file"},
+		{"order 1234567890123", "order 1234567890123"}, // fails the Luhn check
+		{"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln", "token [REDACTED]"},
+		{"iban DE89370400440532013000", "iban [REDACTED]"},
+	}
+	for _, tt := range texts {
+		if got := rd.String(tt.in); got != tt.expected {
+			t.Errorf("String(%q): expected %q, got %q", tt.in, tt.expected, got)
+		}
+	}
+
+	urls := []struct {
+		in, expected string
+	}{
+		{"/users/ada@example.com", "/users/[REDACTED]"},
+		{"/login?user=ada&Password=x&next=%2Fhome", "/login?user=ada&Password=[REDACTED]&next=%2Fhome"},
+		{"/invite?to=ada%40example.com&flag", "/invite?to=[REDACTED]&flag"},
+		{"https://example.com/?access_token=x", "https://example.com/?access_token=[REDACTED]"},
+	}
+	for _, tt := range urls {
+		if got := rd.URL(tt.in); got != tt.expected {
+			t.Errorf("URL(%q): expected %q, got %q", tt.in, tt.expected, got)
+		}
+	}
+
+	bodies := []struct {
+		contentType, in, expected string
+	}{
+		{"application/json", `{"user":{"email":"ada@example.com","cvv":123,"tags":["a","b@example.com"]},"n":1.50}`,
+			`{"n":1.50,"user":{"cvv":"[REDACTED]","email":"[REDACTED]","tags":["a","[REDACTED]"]}}`},
+		{"application/problem+json", `{"secret":"x"}`, `{"secret":"[REDACTED]"}`},
+		{"application/json", `not json ada@example.com`, `not json [REDACTED]`},
+		{"application/x-www-form-urlencoded", "name=Ada&password=x", "name=Ada&password=[REDACTED]"},
+		{"text/plain; charset=utf-8", "mail ada@example.com", "mail [REDACTED]"},
+		{"application/octet-stream", "ada@example.com", "ada@example.com"},
+	}
+	for _, tt := range bodies {
+		if got := string(rd.Body(tt.contentType, []byte(tt.in))); got != tt.expected {
+			t.Errorf("Body(%q, %q): expected %s, got %s", tt.contentType, tt.in, tt.expected, got)
+		}
+	}
+
+	h := rd.Header(http.Header{
+		"Authorization": {"Bearer x"},
+		"From":          {"ada@example.com"},
+		"Accept":        {"text/html"},
+	})
+	if h.Get("Authorization") != Redacted || h.Get("From") != Redacted || h.Get("Accept") != "text/html" {
+		t.Errorf("unexpected redacted header %v", h)
+	}
+
+	var none *Redactor
+	if got := none.URL("/?token=x"); got != "/?token=x" {
+		t.Errorf("expected a nil Redactor to redact nothing, got %q", got)
+	}
+}
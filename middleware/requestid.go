@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// RequestIDHeader is the header used to receive and propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of client-supplied request IDs so
+// that a hostile client cannot inflate every log line.
+const maxRequestIDLength = 128
+
+// RequestID is middleware that assigns an ID to every request. If the
+// incoming request carries a well-formed X-Request-ID header, that value
+// is propagated; otherwise a random 128-bit hex ID is generated.
+//
+// The ID is stored in the request context, where it can be read with
+// hmux.RequestIDFromContext, and echoed in the X-Request-ID response
+// header. Logging middleware in this package, such as Recoverer, include
+// the ID in their output automatically.
+//
+// RequestID should be registered before any middleware that logs:
+//
+//	mux.Use(middleware.RequestID, middleware.Recoverer)
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(hmux.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether a client-supplied ID is safe to reuse:
+// non-empty, reasonably short, and made of printable ASCII only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns a random 32-character hex string.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
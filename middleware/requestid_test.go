@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestRequestID_Generates(t *testing.T) {
+	var got string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = hmux.RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if len(got) != 32 {
+		t.Errorf("expected 32-character generated ID, got %q", got)
+	}
+	if rec.Header().Get(RequestIDHeader) != got {
+		t.Errorf("expected response header %q, got %q", got, rec.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestID_Propagates(t *testing.T) {
+	var got string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = hmux.RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got != "upstream-42" {
+		t.Errorf("expected propagated ID %q, got %q", "upstream-42", got)
+	}
+}
+
+func TestRequestID_RejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"whitespace", "has space"},
+		{"control", "bad\x01id"},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = hmux.RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(RequestIDHeader, tt.id)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got == tt.id || len(got) != 32 {
+				t.Errorf("expected a freshly generated ID, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRecoverer_LogsRequestID(t *testing.T) {
+	logs := captureLog(t)
+
+	h := RequestID(Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(RequestIDHeader, "req-7")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(logs.String(), "[req-7]") {
+		t.Errorf("expected request ID in log, got %q", logs.String())
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureHeaders_Defaults(t *testing.T) {
+	h := SecureHeaders(DefaultSecurityHeaders)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := map[string]string{
+		"Strict-Transport-Security": DefaultSecurityHeaders.StrictTransportSecurity,
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           DefaultSecurityHeaders.ReferrerPolicy,
+		"Content-Security-Policy":   DefaultSecurityHeaders.ContentSecurityPolicy,
+	}
+	for k, v := range expected {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("expected %s %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestSecureHeaders_InnerOverrides(t *testing.T) {
+	override := SecurityHeaders{FrameOptions: "SAMEORIGIN"}
+	h := SecureHeaders(DefaultSecurityHeaders)(SecureHeaders(override)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("expected overridden X-Frame-Options, got %q", got)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected outer X-Content-Type-Options kept, got %q", got)
+	}
+}
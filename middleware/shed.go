@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LoadShedder rejects requests with 503 Service Unavailable and a
+// Retry-After header while the server is overloaded, so that the
+// requests it does accept are served in time instead of all of them
+// timing out. Overload is detected from up to three signals:
+//
+//   - the number of requests in flight through the shedder, as set with
+//     NewLoadShedder;
+//   - the time a request waited before reaching the server, as reported
+//     by a proxy in the X-Request-Start header (SetMaxQueueWait);
+//   - a pressure value supplied by the application, such as CPU usage or
+//     the depth of a work queue (SetPressure).
+//
+// Requests for which the exemption hook returns true are never shed, so
+// health checks keep reporting the instance as alive while it sheds:
+//
+//	shed := middleware.NewLoadShedder(512)
+//	shed.SetMaxQueueWait(500 * time.Millisecond)
+//	shed.SetExempt(func(r *http.Request) bool { return r.URL.Path == "/healthz" })
+//	mux.Use(shed.Handler)
+//
+// Unlike Throttle, which protects a resource with a fixed concurrency
+// limit, LoadShedder is meant to wrap the whole server. Configure it
+// before serving requests.
+type LoadShedder struct {
+	maxInFlight  int64
+	maxQueueWait time.Duration
+	pressure     func() float64
+	maxPressure  float64
+	retryAfter   string
+	exempt       func(r *http.Request) bool
+	now          func() time.Time
+
+	inFlight atomic.Int64
+	shed     atomic.Int64
+}
+
+// NewLoadShedder returns a LoadShedder rejecting requests while
+// maxInFlight requests are in flight. A zero maxInFlight disables the
+// limit, leaving the other signals.
+//
+// NewLoadShedder panics if maxInFlight is negative.
+func NewLoadShedder(maxInFlight int) *LoadShedder {
+	if maxInFlight < 0 {
+		panic("hmux: load shedder limit must not be negative")
+	}
+
+	return &LoadShedder{maxInFlight: int64(maxInFlight), retryAfter: "1", now: time.Now}
+}
+
+// SetMaxQueueWait sheds requests that waited longer than d before
+// reaching the server, by the time in their X-Request-Start header. The
+// header holds a Unix timestamp in seconds, milliseconds or
+// microseconds, optionally prefixed with "t=", as set by Heroku or by
+// nginx with:
+//
+//	proxy_set_header X-Request-Start "t=${msec}";
+//
+// Requests without the header are not shed by this signal. Only enable it
+// behind a proxy that sets the header, as clients could otherwise have
+// their requests shed at will.
+func (s *LoadShedder) SetMaxQueueWait(d time.Duration) {
+	s.maxQueueWait = d
+}
+
+// SetPressure sheds requests while fn returns more than threshold. fn is
+// called for every request and must be cheap and safe for concurrent
+// use; sample expensive signals in the background and return the last
+// sample.
+func (s *LoadShedder) SetPressure(fn func() float64, threshold float64) {
+	s.pressure = fn
+	s.maxPressure = threshold
+}
+
+// SetRetryAfter sets the delay advertised in the Retry-After header of
+// shed requests, rounded up to whole seconds. It defaults to one second.
+func (s *LoadShedder) SetRetryAfter(d time.Duration) {
+	s.retryAfter = strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// SetExempt sets a hook reporting which requests are never shed, such as
+// health checks and requests from operators.
+func (s *LoadShedder) SetExempt(fn func(r *http.Request) bool) {
+	s.exempt = fn
+}
+
+// InFlight returns the number of requests in flight through the shedder.
+func (s *LoadShedder) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// Rejected returns the number of requests shed so far.
+func (s *LoadShedder) Rejected() int {
+	return int(s.shed.Load())
+}
+
+// Handler is the load shedding middleware.
+func (s *LoadShedder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.exempt != nil && s.exempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		if s.overloaded(r, n) {
+			s.shed.Add(1)
+			w.Header().Set("Retry-After", s.retryAfter)
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// overloaded reports whether r must be shed, n being the number of
+// requests in flight including r.
+func (s *LoadShedder) overloaded(r *http.Request, n int64) bool {
+	if s.maxInFlight > 0 && n > s.maxInFlight {
+		return true
+	}
+
+	if s.maxQueueWait > 0 {
+		if start, ok := requestStart(r.Header.Get("X-Request-Start")); ok && s.now().Sub(start) > s.maxQueueWait {
+			return true
+		}
+	}
+
+	return s.pressure != nil && s.pressure() > s.maxPressure
+}
+
+// requestStart parses an X-Request-Start header, guessing the unit of
+// the timestamp from its magnitude.
+func requestStart(header string) (time.Time, bool) {
+	v, err := strconv.ParseFloat(strings.TrimPrefix(header, "t="), 64)
+	if err != nil || v <= 0 {
+		return time.Time{}, false
+	}
+
+	switch {
+	case v < 1e11: // seconds
+		v *= 1e9
+	case v < 1e14: // milliseconds
+		v *= 1e6
+	case v < 1e17: // microseconds
+		v *= 1e3
+	}
+
+	return time.Unix(0, int64(v)), true
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadShedder_InFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	shed := NewLoadShedder(1)
+	shed.SetExempt(func(r *http.Request) bool { return r.URL.Path == "/healthz" })
+	shed.SetRetryAfter(1500 * time.Millisecond)
+	h := shed.Handler(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("expected exempt request to be served")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if shed.InFlight() != 0 || shed.Rejected() != 1 {
+		t.Errorf("expected 0 in flight and 1 rejected, got %d and %d", shed.InFlight(), shed.Rejected())
+	}
+}
+
+func TestLoadShedder_Signals(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	pressure := 0.5
+
+	shed := NewLoadShedder(0)
+	shed.SetMaxQueueWait(time.Second)
+	shed.SetPressure(func() float64 { return pressure }, 0.9)
+	shed.now = func() time.Time { return now }
+	h := shed.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	ms := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(-d).UnixMilli(), 10)
+	}
+
+	tests := []struct {
+		name     string
+		start    string
+		pressure float64
+		code     int
+	}{
+		{"no header", "", 0.5, http.StatusOK},
+		{"short wait ms", ms(100 * time.Millisecond), 0.5, http.StatusOK},
+		{"long wait ms", ms(2 * time.Second), 0.5, http.StatusServiceUnavailable},
+		{"long wait seconds", "t=1699999997.5", 0.5, http.StatusServiceUnavailable},
+		{"short wait seconds", "t=1699999999.5", 0.5, http.StatusOK},
+		{"long wait microseconds", "1699999997000000", 0.5, http.StatusServiceUnavailable},
+		{"malformed", "t=soon", 0.5, http.StatusOK},
+		{"pressure", "", 0.95, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pressure = tt.pressure
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.start != "" {
+				req.Header.Set("X-Request-Start", tt.start)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner(testSecret)
+	now := time.Unix(1000, 0)
+	signer.now = func() time.Time { return now }
+
+	m := hmux.New()
+	m.Meta(hmux.M{hmux.RouteName: "export"}).
+		With(signer.Handler).
+		HandleFunc("GET /exports/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("export " + r.PathValue("id")))
+		})
+
+	link, err := signer.SignRoute(m, "export", time.Minute, "id", "a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, "/exports/a%20b?expires=1060&signature=") {
+		t.Errorf("unexpected link %q", link)
+	}
+
+	withQuery, err := signer.Sign("/exports/7?format=csv", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		later  time.Duration
+		code   int
+		body   string
+	}{
+		{"valid", link, 0, http.StatusOK, "export a b"},
+		{"valid with query", withQuery, 0, http.StatusOK, "export 7"},
+		{"expired", link, time.Minute, http.StatusForbidden, "Link expired\n"},
+		{"tampered path", strings.Replace(link, "a%20b", "c", 1), 0, http.StatusForbidden, "Invalid signature\n"},
+		{"tampered expiry", strings.Replace(link, "1060", "9999", 1), 0, http.StatusForbidden, "Invalid signature\n"},
+		{"added param", withQuery + "&admin=1", 0, http.StatusForbidden, "Invalid signature\n"},
+		{"unsigned", "/exports/7", 0, http.StatusForbidden, "Invalid signature\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Unix(1000, 0).Add(tt.later)
+
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if _, err := signer.SignRoute(m, "missing", time.Minute); !errors.Is(err, hmux.ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// Throttle returns middleware that limits the number of requests
+// processed concurrently to limit. Requests beyond the limit are rejected
+// immediately with 503 Service Unavailable.
+//
+// The limit is shared by every route the returned middleware wraps, so
+// attaching one instance to a group caps the group as a whole while
+// attaching it with With caps a single route:
+//
+//	api.With(middleware.Throttle(10)).HandleFunc("POST /reports", generateReport)
+//
+// Throttle panics if limit is not positive.
+func Throttle(limit int) func(http.Handler) http.Handler {
+	return ThrottleBacklog(limit, 0, 0)
+}
+
+// ThrottleBacklog is like Throttle but additionally queues up to backlog
+// requests while all slots are busy. A queued request waits at most
+// timeout for a slot; if none frees up, or the client goes away, it is
+// rejected with 503. Requests arriving while the backlog is full are
+// rejected immediately.
+//
+// ThrottleBacklog panics if limit is not positive or backlog is negative.
+func ThrottleBacklog(limit, backlog int, timeout time.Duration) func(http.Handler) http.Handler {
+	if limit <= 0 {
+		panic("hmux: throttle limit must be positive")
+	}
+	if backlog < 0 {
+		panic("hmux: throttle backlog must not be negative")
+	}
+
+	tokens := make(chan struct{}, limit)
+	waiting := make(chan struct{}, limit+backlog)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case waiting <- struct{}{}:
+				defer func() { <-waiting }()
+			default:
+				throttled(w)
+				return
+			}
+
+			select {
+			case tokens <- struct{}{}:
+			default:
+				if timeout <= 0 {
+					throttled(w)
+					return
+				}
+
+				timer := time.NewTimer(timeout)
+				defer timer.Stop()
+
+				select {
+				case tokens <- struct{}{}:
+				case <-timer.C:
+					throttled(w)
+					return
+				case <-r.Context().Done():
+					throttled(w)
+					return
+				}
+			}
+			defer func() { <-tokens }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// throttled writes the 503 response for a rejected request.
+func throttled(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingHandler returns a handler that signals on started and then
+// blocks until release is closed.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+}
+
+func TestThrottle_RejectsOverLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := Throttle(1)(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestThrottleBacklog_QueuesUntilSlotFrees(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	h := ThrottleBacklog(1, 1, time.Second)(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	queued := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(queued, httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	// Wait for the second request to occupy the backlog, then verify a
+	// third one overflows it.
+	time.Sleep(20 * time.Millisecond)
+	overflow := httptest.NewRecorder()
+	h.ServeHTTP(overflow, httptest.NewRequest(http.MethodGet, "/", nil))
+	if overflow.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected overflow 503, got %d", overflow.Code)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if queued.Code != http.StatusOK {
+		t.Errorf("expected queued request to succeed, got %d", queued.Code)
+	}
+}
+
+func TestThrottleBacklog_Timeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := ThrottleBacklog(1, 1, 10*time.Millisecond)(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after timeout, got %d", rec.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestThrottle_InvalidLimit_Panics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for zero limit")
+		}
+	}()
+	Throttle(0)
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Timeout returns middleware that cancels the request context after d
+// and, if the handler has not finished by then, responds with 503 Service
+// Unavailable. It is shorthand for TimeoutWith(d, nil).
+//
+// Example:
+//
+//	mux.Use(hmux.Chain(middleware.Recoverer, middleware.Timeout(5*time.Second)))
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return TimeoutWith(d, nil)
+}
+
+// TimeoutWith returns middleware that runs the handler with a context
+// cancelled after d. If the handler has not returned by then, onTimeout
+// is invoked to write the response instead; a nil onTimeout writes
+// 503 Service Unavailable.
+//
+// The handler's output is buffered until it returns so that a late write
+// can never interleave with the timeout response. Writes made after the
+// deadline fail with http.ErrHandlerTimeout, and handlers should stop
+// work once their context is done. Because of the buffering, the wrapped
+// handler cannot stream or flush partial responses. Protocol upgrade
+// requests, such as WebSocket handshakes, are long-lived by design and
+// pass through without a timeout.
+//
+// A panic in the handler is propagated to the goroutine serving the
+// request, so Recoverer placed outside Timeout still observes it.
+//
+// TimeoutWith panics if d is not positive.
+func TimeoutWith(d time.Duration, onTimeout http.Handler) func(http.Handler) http.Handler {
+	if d <= 0 {
+		panic("hmux: timeout must be positive")
+	}
+	if onTimeout == nil {
+		onTimeout = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hmux.IsUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+
+				tw.mu.Lock()
+				tw.finished = ctx.Err() == nil
+				tw.mu.Unlock()
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+
+			// When the handler returns just as the deadline passes, both
+			// cases above are ready and select picks one at random, so
+			// the outcome is decided by whether the handler finished
+			// before the deadline.
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			if tw.finished {
+				tw.writeTo(w)
+				return
+			}
+
+			tw.timedOut = true
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				onTimeout.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response until it completes or the
+// deadline passes.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+	finished bool // the handler returned before the deadline
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+
+	return tw.buf.Write(p)
+}
+
+// writeTo writes the buffered response to w. The caller holds tw.mu.
+func (tw *timeoutWriter) writeTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	w.Write(tw.buf.Bytes())
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+
+	tw.status = status
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout_FastHandler(t *testing.T) {
+	h := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rec.Code)
+	}
+	if rec.Body.String() != "done" {
+		t.Errorf("expected body %q, got %q", "done", rec.Body.String())
+	}
+	if rec.Header().Get("X-Test") != "yes" {
+		t.Error("expected handler headers to be copied")
+	}
+}
+
+// notifyWriter is a ResponseRecorder that closes written once the
+// response header is written.
+type notifyWriter struct {
+	*httptest.ResponseRecorder
+	written chan struct{}
+}
+
+func (w *notifyWriter) WriteHeader(code int) {
+	w.ResponseRecorder.WriteHeader(code)
+	close(w.written)
+}
+
+func TestTimeout_SlowHandler(t *testing.T) {
+	rec := &notifyWriter{ResponseRecorder: httptest.NewRecorder(), written: make(chan struct{})}
+	writeErr := make(chan error, 1)
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// Write only once the timeout response is out, so the write is
+		// guaranteed to come late.
+		<-rec.written
+		_, err := w.Write([]byte("late"))
+		writeErr <- err
+	}))
+
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("expected ErrHandlerTimeout for late write, got %v", err)
+	}
+}
+
+func TestTimeoutWith_CustomResponse(t *testing.T) {
+	onTimeout := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte(`{"error":"timeout"}`))
+	})
+	h := TimeoutWith(10*time.Millisecond, onTimeout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d", rec.Code)
+	}
+	if rec.Body.String() != `{"error":"timeout"}` {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestTimeout_PropagatesPanic(t *testing.T) {
+	captureLog(t)
+
+	h := Recoverer(Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestTimeout_SkipsUpgrade(t *testing.T) {
+	var hasDeadline bool
+	h := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if hasDeadline {
+		t.Error("expected upgrade request to bypass the timeout")
+	}
+}
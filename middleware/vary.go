@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Vary returns middleware that adds the given request header names to
+// the Vary header of every response, merged with the names other
+// middleware and the handler add through hmux.AddVary. Use it on routes
+// whose responses depend on request headers that no middleware declares,
+// such as a handler branching on Accept-Language:
+//
+//	mux.With(middleware.Vary("Accept-Language")).HandleFunc("GET /", home)
+//
+// The names are added before the handler runs, so a handler that sets
+// Vary outright replaces them; handlers should use hmux.AddVary too.
+func Vary(names ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hmux.AddVary(w.Header(), names...)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
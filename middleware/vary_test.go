@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestVary(t *testing.T) {
+	h := Vary("Accept-Language", "Origin")(Compress(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hmux.AddVary(w.Header(), "origin")
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(strings.Repeat("hello ", 100)))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Language, Origin, Accept-Encoding" {
+		t.Errorf("expected merged Vary, got %q", got)
+	}
+}
@@ -27,29 +27,34 @@
 //	}
 //
 // Once all routes are registered, ServeHTTP is safe for concurrent use.
+// A Mux created with the Dynamic option also accepts registrations while
+// serving.
 //
-// # Limitations
+// # Host-Based Routing
 //
-// Host-based patterns are not supported in route groups. When using groups,
-// patterns should be path-only. For example:
+// Patterns may carry a host, as with http.ServeMux. Inside a group the
+// host stays in front of the joined path:
 //
-//	// Correct - path-only pattern
 //	api := mux.Group("/api")
-//	api.HandleFunc("/users", handler)           // ✓ Works: GET /api/users
-//	api.HandleFunc("GET /users/{id}", handler)  // ✓ Works: GET /api/users/{id}
+//	api.HandleFunc("GET example.com/users", handler) // GET example.com/api/users
 //
-//	// Incorrect - host-based pattern
-//	api.HandleFunc("example.com/users", handler)  // ✗ Creates malformed pattern
+// GroupHost creates a group whose routes all match a single host:
 //
-// If you need host-based routing, register patterns directly on the Mux
-// without using groups:
-//
-//	mux.HandleFunc("example.com/users", handler)  // ✓ Works
+//	v1 := mux.GroupHost("api.example.com", "/v1")
+//	v1.HandleFunc("GET /users", handler)            // GET api.example.com/v1/users
 package hmux
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Mux is an HTTP request multiplexer with middleware support. It wraps
@@ -57,21 +62,112 @@ import (
 // and route grouping capabilities while maintaining full compatibility
 // with Go 1.22+ routing patterns.
 type Mux struct {
-	mux        *http.ServeMux
-	middleware []func(http.Handler) http.Handler
+	mux           atomic.Pointer[http.ServeMux]
+	middleware    []layer
+	trailingSlash TrailingSlashPolicy
+	caseFold      bool
+	strict        bool
+	notFound      http.Handler
+	named         map[string]*namedMiddleware
+	frozen        atomic.Bool
+	outer         []func(http.Handler) http.Handler
+	dispatch      http.Handler
+	deferred      bool
+	hosts         []*hostRoute
+	parent        *Mux
+	errorHandler  ErrorHandlerFunc
+	panicHandler  PanicHandlerFunc
+
+	versions       []*versionRoute
+	defaultVersion string
+
+	routes []*route
+
+	// providers holds the dependencies registered with Provide, keyed by
+	// type.
+	providers map[reflect.Type]reflect.Value
+
+	// sets holds the match sets of the patterns registered with
+	// matchers, keyed by pattern.
+	sets map[string]*matchSet
+
+	// wrapped is set for a Mux created with Wrap, whose ServeMux holds
+	// routes that are not in routes.
+	wrapped bool
+
+	// chains memoizes composed middleware stacks when cacheChains is
+	// set, keyed by the identity of the stack.
+	cacheChains bool
+	chains      map[chainKey]http.Handler
+
+	// dynamic makes registration safe while serving. regMu then guards
+	// routes and middleware, and each registration swaps in a new mux.
+	dynamic bool
+	regMu   sync.Mutex
 }
 
-// Verify Mux implements Router interface.
-var _ Router = (*Mux)(nil)
+// Verify Mux implements the Router and ExtendedRouter interfaces.
+var (
+	_ Router         = (*Mux)(nil)
+	_ ExtendedRouter = (*Mux)(nil)
+)
 
-// New creates and returns a new Mux instance backed by an http.ServeMux.
-// The returned Mux has no middleware configured and is ready to register
-// handlers.
-func New() *Mux {
-	return &Mux{
-		mux:        http.NewServeMux(),
+// New creates and returns a new Mux instance backed by an http.ServeMux,
+// configured by the given options. The returned Mux has no middleware
+// configured and is ready to register handlers.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.TrailingSlash(hmux.TrailingSlashRedirectStrip))
+func New(opts ...Option) *Mux {
+	m := &Mux{
 		middleware: nil,
 	}
+
+	m.mux.Store(http.NewServeMux())
+	m.dispatch = http.HandlerFunc(m.serve)
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.deferred && m.dynamic {
+		panic("hmux: Deferred and Dynamic cannot be combined")
+	}
+
+	return m
+}
+
+// Wrap returns a Mux that registers its routes on existing, so a codebase
+// with a populated http.ServeMux can adopt hmux incrementally: routes
+// registered through the Mux get its middleware, while routes already on
+// existing keep working unchanged. Serve the returned Mux in place of
+// existing.
+//
+// Example:
+//
+//	legacy := http.NewServeMux()
+//	legacy.HandleFunc("GET /old", oldHandler)
+//
+//	mux := hmux.Wrap(legacy)
+//	mux.Use(middleware.Logger)
+//	mux.HandleFunc("GET /new", newHandler) // logged; GET /old is not
+//
+// Routes registered directly on existing are not reported by Routes, and
+// since they cannot be rebuilt, Unhandle and Reload panic on a wrapped
+// Mux.
+//
+// Wrap panics if existing is nil.
+func Wrap(existing *http.ServeMux) *Mux {
+	if existing == nil {
+		panic("hmux: nil ServeMux passed to Wrap")
+	}
+
+	m := New()
+	m.mux.Store(existing)
+	m.wrapped = true
+
+	return m
 }
 
 // Handle registers the handler for the given pattern. The handler is
@@ -79,10 +175,19 @@ func New() *Mux {
 // call. The pattern follows Go 1.22+ syntax including method prefixes
 // (e.g., "GET /users/{id}").
 //
+// While a request is served, the handler and its middleware can read the
+// registered pattern from r.Pattern. For routes registered through a
+// group, r.Pattern holds the full pattern including the group prefix, so
+// it is a stable, low-cardinality label for logs and metrics.
+//
+// To constrain the route with matchers, so several handlers can share a
+// pattern, register it on the router returned by Matching.
+//
 // Handle panics if the pattern is invalid, already registered, or if
 // handler is nil. This matches http.ServeMux behavior.
 func (m *Mux) Handle(pattern string, handler http.Handler) {
-	m.mux.Handle(pattern, m.wrap(handler))
+	m.checkStrict(pattern)
+	m.handle(pattern, handler, m.stack, nil, m.errorHandlerFunc, nil)
 }
 
 // HandleFunc registers the handler function for the given pattern.
@@ -96,8 +201,119 @@ func (m *Mux) HandleFunc(pattern string, handler http.HandlerFunc) {
 	m.Handle(pattern, handler)
 }
 
+// Unhandle removes the route registered under pattern and reports
+// whether there was one. The pattern must be given as Routes reports it,
+// including any group prefix, or as it was registered: inline
+// constraints such as "{id:[0-9]+}" are stripped before the comparison.
+// Routes sharing the pattern through matchers are all removed. Routes of Host and Version routers are
+// removed through the Mux they were registered on and cannot be
+// removed with Unhandle.
+//
+// Since http.ServeMux cannot drop a route, Unhandle builds a new one
+// from the remaining routes and swaps it in. On a Mux created with
+// Dynamic it is safe to call while serving; requests in flight finish
+// on the route they matched. Otherwise it must not be called
+// concurrently with ServeHTTP.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /beta", beta)
+//	mux.Unhandle("GET /beta") // GET /beta → 404
+//
+// Unhandle panics on a Mux created with Wrap.
+func (m *Mux) Unhandle(pattern string) bool {
+	if m.wrapped {
+		panic("hmux: Unhandle is not supported on a Mux created with Wrap")
+	}
+
+	pattern, _ = parseConstraints(pattern)
+	if m.caseFold {
+		pattern = lowerPattern(pattern)
+	}
+
+	defer m.lock()()
+
+	routes := slices.DeleteFunc(slices.Clone(m.routes), func(rt *route) bool { return rt.pattern == pattern })
+	if len(routes) == len(m.routes) {
+		return false
+	}
+
+	m.swap(routes)
+
+	return true
+}
+
+// Reload replaces the routes registered on the Mux with those fn
+// registers on the given Router, for configuration-driven deployments
+// that reload their routes at runtime. The new routes are built while
+// the current ones keep serving, then swapped in atomically: every
+// request is served by either the old or the new route set, and none is
+// dropped. Reload is safe to call while the Mux is serving, but not
+// concurrently with other registrations unless the Mux was created with
+// Dynamic.
+//
+// The Router has no prefix and the Mux's current middleware, like
+// Group(""). Middleware registered with Use, Host and Version routers
+// and the error handler are kept. If fn panics, for example because of
+// a conflicting pattern, Reload returns the panic as an error and the
+// current routes stay in place.
+//
+// Example:
+//
+//	err := mux.Reload(func(r hmux.Router) {
+//	    for _, rt := range cfg.Routes {
+//	        r.Handle(rt.Pattern, handlers[rt.Handler])
+//	    }
+//	})
+//
+// Reload panics on a Mux created with Wrap.
+func (m *Mux) Reload(fn func(r Router)) error {
+	if m.wrapped {
+		panic("hmux: Reload is not supported on a Mux created with Wrap")
+	}
+
+	staging := m.newChild()
+	g := m.Group("").(*Group)
+	g.mux = staging
+
+	if err := tryRegister("", func() { fn(g) }); err != nil {
+		return fmt.Errorf("hmux: reload: %w", errors.Unwrap(err))
+	}
+
+	defer m.lock()()
+	m.mux.Store(staging.mux.Load())
+	m.routes = staging.routes
+	m.sets = staging.sets
+
+	return nil
+}
+
+// TryHandle is like Handle but returns a *RouteError instead of
+// panicking if the route cannot be registered, for example because its
+// pattern is invalid or conflicts with a registered one. It suits
+// services that build routes from user or plugin input:
+//
+//	if err := mux.TryHandle(plugin.Pattern, plugin.Handler); err != nil {
+//	    log.Printf("skipping plugin %s: %v", plugin.Name, err)
+//	}
+//
+// Nothing is registered when an error is returned. Matchers are applied
+// as with Handle, through the router returned by Matching:
+//
+//	err := mux.Matching(hmux.MatchHeader("X-Beta", "1")).TryHandle(pattern, h)
+func (m *Mux) TryHandle(pattern string, handler http.Handler) error {
+	return tryRegister(pattern, func() { m.Handle(pattern, handler) })
+}
+
+// TryHandleFunc is like HandleFunc but returns an error instead of
+// panicking. See TryHandle.
+func (m *Mux) TryHandleFunc(pattern string, handler http.HandlerFunc) error {
+	return m.TryHandle(pattern, handler)
+}
+
 // Use appends middleware to the Mux. Only handlers registered after
-// this call will be wrapped with these middleware. Multiple calls to
+// this call will be wrapped with these middleware, unless the Mux was
+// created with the Deferred option. Multiple calls to
 // Use accumulate middleware. If Use(A, B, C) is called, then for a
 // subsequent handler H, requests flow: A → B → C → H → C → B → A.
 //
@@ -108,12 +324,59 @@ func (m *Mux) Use(mw ...func(http.Handler) http.Handler) {
 			panic("hmux: nil middleware passed to Use")
 		}
 	}
+	m.checkDeferredUse()
+
+	defer m.lock()()
+	m.middleware = append(m.middleware, layersOf(mw)...)
+}
+
+// NotFound registers a fallback handler for requests that match no other
+// route. The handler is registered as a catch-all "/" pattern and wrapped
+// with the Mux's middleware like any other route, so unlike the default
+// 404 it passes through Use middleware. It is responsible for writing the
+// status code, typically 404 Not Found.
+//
+// Because the catch-all accepts every method, requests that would
+// otherwise get 405 Method Not Allowed are also sent to the fallback.
+//
+// NotFound panics if handler is nil or if "/" is already registered.
+func (m *Mux) NotFound(handler http.HandlerFunc) {
+	if handler == nil {
+		panic("hmux: nil handler passed to NotFound")
+	}
 
-	m.middleware = append(m.middleware, mw...)
+	m.handle("/", handler, m.stack, nil, m.errorHandlerFunc, nil)
+}
+
+// UseOuter appends middleware that wraps the entire dispatch of the Mux
+// rather than individual handlers. Outer middleware runs for every
+// request, including requests that end in a 404 Not Found, a 405 Method
+// Not Allowed or a trailing-slash redirect, which makes it the place for
+// logging and metrics that must cover unmatched requests.
+//
+// Outer middleware runs before routing, so r.Pattern is empty on the way
+// in. It is set on the same request once the inner dispatch returns, and
+// stays empty for unmatched requests.
+//
+// Unlike Use, UseOuter applies regardless of registration order. Outer
+// middleware wraps the middleware registered via Use: for UseOuter(A)
+// and Use(B), requests flow A → B → H → B → A.
+//
+// UseOuter panics if any middleware is nil.
+func (m *Mux) UseOuter(mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("hmux: nil middleware passed to UseOuter")
+		}
+	}
+
+	m.outer = append(m.outer, mw...)
+	m.dispatch = wrap(http.HandlerFunc(m.serve), m.outer)
 }
 
 // Group creates a new route group with the given prefix. The group
-// inherits a copy of the Mux's current middleware. Handlers registered
+// inherits a copy of the Mux's current middleware, or tracks the Mux's
+// stack if the Mux was created with Deferred. Handlers registered
 // on the group will have their patterns prefixed and will include any
 // middleware added to the group via Group.Use().
 //
@@ -127,16 +390,91 @@ func (m *Mux) Group(prefix string) Router {
 		panic("hmux: group prefix must be empty or start with /")
 	}
 
-	mw := make([]func(http.Handler) http.Handler, len(m.middleware))
-	copy(mw, m.middleware)
+	if m.deferred {
+		return &Group{
+			mux:     m,
+			prefix:  prefix,
+			inherit: m.stack,
+		}
+	}
+
+	defer m.lock()()
 
 	return &Group{
 		mux:        m,
 		prefix:     prefix,
-		middleware: mw,
+		middleware: slices.Clone(m.middleware),
 	}
 }
 
+// GroupHost creates a new route group like Group whose routes only match
+// requests for the given host. Handlers registered on the group, and on
+// groups nested in it, get the host prepended to their joined pattern:
+//
+//	v1 := mux.GroupHost("api.example.com", "/v1")
+//	v1.HandleFunc("GET /users", handler) // GET api.example.com/v1/users
+//
+// GroupHost panics if host is empty or contains "/", or if prefix is
+// non-empty and does not start with "/".
+func (m *Mux) GroupHost(host, prefix string) ExtendedRouter {
+	if host == "" || strings.Contains(host, "/") {
+		panic("hmux: group host must be non-empty and contain no /")
+	}
+
+	g := m.Group(prefix).(*Group)
+	g.host = host
+
+	return g
+}
+
+// GroupDetached creates a new route group like Group that does not
+// inherit the Mux's middleware, so routes that must skip it, such as
+// public assets on an otherwise authenticated Mux, need no change to
+// the order of registration:
+//
+//	mux.Use(auth)
+//	public := mux.GroupDetached("/public")
+//	public.Use(cacheHeaders)
+//	public.Static("/", assets) // cacheHeaders only
+//
+// Middleware added with Use on the group, and groups nested in it,
+// apply as usual. Outer middleware registered with UseOuter runs for all
+// requests, including those of detached groups.
+//
+// GroupDetached panics if prefix is non-empty and does not start with
+// "/".
+func (m *Mux) GroupDetached(prefix string) ExtendedRouter {
+	g := m.Group(prefix).(*Group)
+	g.middleware = nil
+	if g.inherit != nil {
+		g.inherit = func() []layer { return nil }
+	}
+
+	return g
+}
+
+// GroupStripped creates a new route group like Group whose handlers see
+// request paths relative to the group: the segments of prefix are
+// removed from r.URL.Path before the handler runs, so handlers written
+// against root-relative paths can be mounted anywhere:
+//
+//	admin := mux.GroupStripped("/admin")
+//	admin.Handle("/", legacyAdmin) // GET /admin/users → legacyAdmin sees /users
+//
+// Middleware sees the full path, and r.PathValue and RoutePattern are
+// unaffected. Groups nested in the group strip the same prefix. A prefix
+// with wildcards, such as "/tenants/{id}", strips as many segments as it
+// has.
+//
+// GroupStripped panics if prefix is non-empty and does not start with
+// "/".
+func (m *Mux) GroupStripped(prefix string) ExtendedRouter {
+	g := m.Group(prefix).(*Group)
+	g.strip = countSegments(g.prefix)
+
+	return g
+}
+
 // With returns a new Router with the given middleware appended to
 // the Mux's current middleware stack. The returned Router has no
 // prefix, so patterns are registered as-is. This is useful for
@@ -153,10 +491,76 @@ func (m *Mux) With(mw ...func(http.Handler) http.Handler) Router {
 }
 
 // ServeHTTP dispatches the request to the handler whose pattern most
-// closely matches the request URL. This method delegates directly to
-// the underlying http.ServeMux.
+// closely matches the request URL. Apart from running outer middleware,
+// matching Host and Version routers and applying the case-folding and
+// trailing-slash options, this method delegates directly to the
+// underlying http.ServeMux. The first call freezes named middleware; see
+// UseNamed.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	m.mux.ServeHTTP(w, r)
+	if !m.frozen.Load() {
+		m.frozen.Store(true)
+	}
+
+	m.dispatch.ServeHTTP(w, r)
+}
+
+// serve routes the request through case folding, host matching, version
+// dispatch, the trailing-slash policy and the underlying http.ServeMux.
+// It is the innermost handler of the outer middleware stack.
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request) {
+	if m.caseFold {
+		if lr := lowerPath(r); lr != r {
+			m.serve(w, lr)
+			r.Pattern = lr.Pattern
+
+			return
+		}
+	}
+
+	if len(m.hosts) > 0 {
+		if hm, hr := m.matchHost(r); hm != nil {
+			hm.serve(w, hr)
+			// Keep the matched pattern visible to outer middleware.
+			r.Pattern = hr.Pattern
+
+			return
+		}
+	}
+
+	if len(m.versions) > 0 && m.serveVersion(w, r) {
+		return
+	}
+
+	if m.trailingSlash != TrailingSlashStrict && m.redirectTrailingSlash(w, r) {
+		return
+	}
+
+	mux := m.mux.Load()
+	if nf := m.notFoundHandler(); nf != nil {
+		if h, pattern := mux.Handler(r); pattern == "" {
+			serveUnmatched(w, r, h, nf)
+			return
+		}
+	}
+
+	mux.ServeHTTP(w, r)
+}
+
+// newChild returns a Mux for the routes of a Host or Version router,
+// configured like m.
+func (m *Mux) newChild() *Mux {
+	child := &Mux{
+		trailingSlash: m.trailingSlash,
+		caseFold:      m.caseFold,
+		strict:        m.strict,
+		cacheChains:   m.cacheChains,
+		deferred:      m.deferred,
+		dynamic:       m.dynamic,
+		parent:        m,
+	}
+	child.mux.Store(http.NewServeMux())
+
+	return child
 }
 
 // Handler returns the underlying http.ServeMux. This can be useful for
@@ -168,7 +572,7 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // debugging or when you specifically need to bypass middleware. For normal
 // route registration, use Handle() or HandleFunc() instead.
 func (m *Mux) Handler() *http.ServeMux {
-	return m.mux
+	return m.mux.Load()
 }
 
 // Chain composes multiple middleware into a single middleware function.
@@ -186,10 +590,220 @@ func Chain(mw ...func(http.Handler) http.Handler) func(http.Handler) http.Handle
 	}
 }
 
-// wrap applies all registered middleware to the handler in reverse order,
-// producing the standard onion model execution pattern.
-func (m *Mux) wrap(h http.Handler) http.Handler {
-	return wrap(h, m.middleware)
+// stack returns the Mux's current middleware stack.
+func (m *Mux) stack() []layer {
+	return m.middleware
+}
+
+// handle registers handler under pattern on the underlying ServeMux,
+// inside an error boundary and wrapped with the middleware returned by
+// stack. In deferred mode stack
+// is called when the route serves its first request; otherwise it is
+// called immediately. Errors raised by the handler are rendered by the
+// handler errorHandler returns. The route is recorded for Routes.
+func (m *Mux) handle(pattern string, handler http.Handler, stack func() []layer, meta M, errorHandler func() ErrorHandlerFunc, matchers []Matcher) {
+	if handler == nil {
+		panic("http: nil handler")
+	}
+	if slices.ContainsFunc(matchers, func(fn Matcher) bool { return fn == nil }) {
+		panic("hmux: nil matcher")
+	}
+	handler = errorBoundary(handler, errorHandler)
+	pattern, constraints := parseConstraints(pattern)
+	if len(constraints) > 0 {
+		matchers = append(constraints, matchers...)
+	}
+	if m.caseFold {
+		pattern = lowerPattern(pattern)
+	}
+
+	defer m.lock()()
+
+	rt := &route{pattern: pattern, base: handler, stack: stack, meta: meta, matchers: matchers}
+
+	if !m.deferred {
+		s := stack()
+		rt.stack = func() []layer { return s }
+		m.register(rt, m.chain(rt, handler, s))
+
+		return
+	}
+
+	var (
+		once    sync.Once
+		wrapped http.Handler
+	)
+
+	m.register(rt, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			m.frozen.Store(true)
+			wrapped = compose(handler, stack())
+		})
+
+		wrapped.ServeHTTP(w, r)
+	}))
+}
+
+// chainKey identifies a middleware stack by its backing array and length.
+// Stacks only ever grow by appending, so the layers of a given key never
+// change.
+type chainKey struct {
+	first *layer
+	n     int
+}
+
+// chain returns handler wrapped with the middleware of stack. With
+// CacheChains, the middleware is composed once per stack around a
+// handler that serves the route's handler, found in the request context.
+func (m *Mux) chain(rt *route, handler http.Handler, stack []layer) http.Handler {
+	if !m.cacheChains || len(stack) == 0 {
+		return compose(handler, stack)
+	}
+
+	rt.inner = handler
+
+	key := chainKey{first: &stack[0], n: len(stack)}
+	if h, ok := m.chains[key]; ok {
+		return h
+	}
+
+	h := compose(http.HandlerFunc(serveInner), stack)
+	if m.chains == nil {
+		m.chains = make(map[chainKey]http.Handler)
+	}
+	m.chains[key] = h
+
+	return h
+}
+
+// serveInner serves the handler of the route in the request context. It
+// is the innermost handler of a cached chain. The route is missing only
+// if middleware replaced the request context instead of deriving from
+// it, which is answered with 500 Internal Server Error.
+func serveInner(w http.ResponseWriter, r *http.Request) {
+	rt, ok := r.Context().Value(routeKey).(*route)
+	if !ok || rt.inner == nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rt.inner.ServeHTTP(w, r)
+}
+
+// register adds rt to the route table, served by h. A dynamic Mux
+// registers it on a copy of the current http.ServeMux and swaps the copy
+// in, so requests being served never see a mux under modification. The
+// caller holds the registration lock.
+func (m *Mux) register(rt *route, h http.Handler) {
+	rt.handler = m.routeHandler(rt, h)
+
+	if m.dynamic {
+		m.swap(append(slices.Clip(m.routes), rt))
+		return
+	}
+
+	switch s := m.sets[rt.pattern]; {
+	case s != nil:
+		s.add(rt)
+	case len(rt.matchers) == 0:
+		m.mux.Load().Handle(rt.pattern, rt.handler)
+	case slices.ContainsFunc(m.routes, func(r *route) bool { return r.pattern == rt.pattern }):
+		// The pattern is served by a route without matchers; rebuild
+		// so that both share a match set.
+		if m.wrapped {
+			panic("hmux: pattern " + rt.pattern + " cannot be shared with matchers on a Mux created with Wrap")
+		}
+		m.swap(append(slices.Clip(m.routes), rt))
+
+		return
+	default:
+		s := m.newMatchSet()
+		s.add(rt)
+		m.mux.Load().Handle(rt.pattern, m.setHandler(rt.pattern, s))
+		if m.sets == nil {
+			m.sets = make(map[string]*matchSet)
+		}
+		m.sets[rt.pattern] = s
+	}
+
+	m.routes = append(m.routes, rt)
+}
+
+// swap replaces the route table with a new http.ServeMux serving routes.
+func (m *Mux) swap(routes []*route) {
+	mux, sets := m.newServeMux(routes)
+	m.mux.Store(mux)
+	m.routes = routes
+	m.sets = sets
+}
+
+// newServeMux returns an http.ServeMux serving routes, and the match sets
+// of patterns shared by several routes or constrained by matchers.
+func (m *Mux) newServeMux(routes []*route) (*http.ServeMux, map[string]*matchSet) {
+	shared := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		_, seen := shared[rt.pattern]
+		shared[rt.pattern] = seen || len(rt.matchers) > 0
+	}
+
+	mux := http.NewServeMux()
+	var sets map[string]*matchSet
+	for _, rt := range routes {
+		if !shared[rt.pattern] {
+			mux.Handle(rt.pattern, rt.handler)
+			continue
+		}
+
+		s, ok := sets[rt.pattern]
+		if !ok {
+			s = m.newMatchSet()
+			mux.Handle(rt.pattern, m.setHandler(rt.pattern, s))
+			if sets == nil {
+				sets = make(map[string]*matchSet)
+			}
+			sets[rt.pattern] = s
+		}
+		s.add(rt)
+	}
+
+	return mux, sets
+}
+
+// lock acquires the registration lock of a dynamic Mux and returns the
+// function releasing it. For other Muxes both are no-ops.
+func (m *Mux) lock() func() {
+	if !m.dynamic {
+		return func() {}
+	}
+
+	m.regMu.Lock()
+
+	return m.regMu.Unlock
+}
+
+// routeHandler prepares the request for the middleware of rt: it
+// attaches the route to the request context for RoutePattern and
+// MetaFromContext, recovers panics for the panic handler and, for a
+// case-insensitive Mux, restores the case of the path values. Routes with
+// matchers have them restored earlier as well, by setHandler.
+func (m *Mux) routeHandler(rt *route, h http.Handler) http.Handler {
+	next := recoverPanics(h, m.panicHandlerFunc)
+	h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey, rt)))
+	})
+	if m.caseFold {
+		h = restorePathValues(rt.pattern, h)
+	}
+
+	return h
+}
+
+// checkDeferredUse panics if middleware is added to a deferred Mux that
+// has started serving, since already composed routes would miss it.
+func (m *Mux) checkDeferredUse() {
+	if m.deferred && m.frozen.Load() {
+		panic("hmux: Use called after a deferred mux started serving")
+	}
 }
 
 // wrap applies middleware to a handler in reverse order, producing the
@@ -208,14 +822,16 @@ func wrap(h http.Handler, mw []func(http.Handler) http.Handler) http.Handler {
 }
 
 // joinPattern combines a group prefix with a handler pattern, correctly
-// handling method prefixes in Go 1.22+ routing syntax.
+// handling method and host prefixes in Go 1.22+ routing syntax.
 //
 // Examples:
 //   - prefix="/api", pattern="/users" → "/api/users"
 //   - prefix="/api", pattern="GET /users" → "GET /api/users"
 //   - prefix="/api/", pattern="/users" → "/api/users"
+//   - prefix="/api", pattern="GET example.com/users" → "GET example.com/api/users"
 func joinPattern(prefix, pattern string) string {
-	method, path := splitMethodPath(pattern)
+	method, rest := splitMethodPath(pattern)
+	host, path := splitHostPath(rest)
 
 	// Normalize: remove trailing slash from prefix, ensure path starts with /
 	prefix = strings.TrimSuffix(prefix, "/")
@@ -223,7 +839,7 @@ func joinPattern(prefix, pattern string) string {
 		path = "/" + path
 	}
 
-	joined := prefix + path
+	joined := host + prefix + path
 
 	if method != "" {
 		return method + " " + joined
@@ -232,6 +848,45 @@ func joinPattern(prefix, pattern string) string {
 	return joined
 }
 
+// withHost prepends host to the path of pattern. It panics if pattern
+// already names a different host.
+//
+// Examples:
+//   - host="api.example.com", pattern="GET /users" → "GET api.example.com/users"
+//   - host="", pattern="/users" → "/users"
+func withHost(host, pattern string) string {
+	if host == "" {
+		return pattern
+	}
+
+	method, rest := splitMethodPath(pattern)
+	patternHost, path := splitHostPath(rest)
+	if patternHost != "" && patternHost != host {
+		panic("hmux: pattern host " + patternHost + " conflicts with group host " + host)
+	}
+
+	if method != "" {
+		return method + " " + host + path
+	}
+
+	return host + path
+}
+
+// splitHostPath separates an optional host from the path portion of a
+// pattern without a method.
+//
+// Examples:
+//   - "example.com/users" → ("example.com", "/users")
+//   - "/users" → ("", "/users")
+func splitHostPath(pattern string) (host, path string) {
+	i := strings.IndexByte(pattern, '/')
+	if i <= 0 {
+		return "", pattern
+	}
+
+	return pattern[:i], pattern[i:]
+}
+
 // splitMethodPath separates an optional HTTP method prefix from the path
 // portion of a pattern.
 //
@@ -261,3 +916,39 @@ func splitMethodPath(pattern string) (method, path string) {
 		return "", pattern
 	}
 }
+
+// RouteError reports a route that could not be registered by TryHandle.
+type RouteError struct {
+	Pattern string // pattern as passed to TryHandle
+	Err     error  // reason, such as the conflict reported by http.ServeMux
+}
+
+func (e *RouteError) Error() string {
+	return "hmux: cannot register " + strconv.Quote(e.Pattern) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the reason the route could not be registered.
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// tryRegister calls register, turning a panic into a *RouteError.
+func tryRegister(pattern string, register func()) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		reason, ok := v.(error)
+		if !ok {
+			msg := fmt.Sprint(v)
+			reason = errors.New(strings.TrimPrefix(msg, "hmux: "))
+		}
+		err = &RouteError{Pattern: pattern, Err: reason}
+	}()
+
+	register()
+
+	return nil
+}
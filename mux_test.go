@@ -1,9 +1,12 @@
 package hmux
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -25,7 +28,7 @@ func TestNew(t *testing.T) {
 	if m == nil {
 		t.Fatal("New() returned nil")
 	}
-	if m.mux == nil {
+	if m.mux.Load() == nil {
 		t.Error("underlying ServeMux is nil")
 	}
 	if m.middleware != nil {
@@ -33,6 +36,47 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestWrap(t *testing.T) {
+	var record []string
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("GET /old", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("old"))
+	})
+
+	m := Wrap(legacy)
+	m.Use(recordingMiddleware("A", &record))
+	m.HandleFunc("GET /new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	})
+
+	tests := []struct {
+		path   string
+		body   string
+		record []string
+	}{
+		{"/old", "old", nil},
+		{"/new", "new", []string{"A:enter", "A:exit"}},
+	}
+	for _, tt := range tests {
+		record = nil
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+		if !slices.Equal(record, tt.record) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.record, record)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Unhandle to panic on a wrapped Mux")
+		}
+	}()
+	m.Unhandle("GET /new")
+}
+
 func TestMiddleware_ExecutionOrder(t *testing.T) {
 	var record []string
 	m := New()
@@ -232,6 +276,89 @@ func TestGroup_NestedPrefixes(t *testing.T) {
 	}
 }
 
+func TestGroupDetached(t *testing.T) {
+	for _, deferred := range []bool{false, true} {
+		var record []string
+		var opts []Option
+		if deferred {
+			opts = append(opts, Deferred())
+		}
+
+		m := New(opts...)
+		m.Use(recordingMiddleware("auth", &record))
+		public := m.GroupDetached("/public")
+		public.Use(recordingMiddleware("cache", &record))
+		public.Group("/img").HandleFunc("GET /logo", func(w http.ResponseWriter, r *http.Request) {})
+		m.Use(recordingMiddleware("late", &record))
+
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/public/img/logo", nil))
+
+		expected := []string{"cache:enter", "cache:exit"}
+		if !slices.Equal(record, expected) {
+			t.Errorf("deferred=%v: expected %v, got %v", deferred, expected, record)
+		}
+	}
+}
+
+func TestGroup_HandleAbsolute(t *testing.T) {
+	var record []string
+	m := New()
+	billing := m.Group("/billing").(*Group)
+	billing.Use(recordingMiddleware("auth", &record))
+	billing.HandleAbsolute("GET /.well-known/billing.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RoutePattern(r)))
+	}))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/billing.json", nil))
+
+	if rec.Body.String() != "GET /.well-known/billing.json" {
+		t.Errorf("expected absolute pattern, got %q", rec.Body.String())
+	}
+	if !slices.Equal(record, []string{"auth:enter", "auth:exit"}) {
+		t.Errorf("expected group middleware, got %v", record)
+	}
+}
+
+func TestGroupStripped(t *testing.T) {
+	var middlewarePath string
+	m := New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middlewarePath = r.URL.Path
+			next.ServeHTTP(w, r)
+		})
+	})
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.PathValue("id")))
+	}
+
+	admin := m.GroupStripped("/admin")
+	admin.HandleFunc("/", echo)
+	admin.Group("/users").HandleFunc("GET /{id}", echo)
+	m.Group("/tenants").(*Group).GroupStripped("/{id}").HandleFunc("GET /settings", echo)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/admin/", "/ "},
+		{"/admin/reports/daily", "/reports/daily "},
+		{"/admin/users/7", "/users/7 7"},
+		{"/tenants/acme/settings", "/settings acme"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+		if middlewarePath != tt.path {
+			t.Errorf("%s: expected middleware to see the full path, got %q", tt.path, middlewarePath)
+		}
+	}
+}
+
 func TestGroup_NestedMiddleware(t *testing.T) {
 	var record []string
 	m := New()
@@ -288,6 +415,11 @@ func TestJoinPattern(t *testing.T) {
 		{"/api", "PATCH /users/{id}", "PATCH /api/users/{id}"},
 		{"/api", "HEAD /status", "HEAD /api/status"},
 		{"/api", "OPTIONS /cors", "OPTIONS /api/cors"},
+
+		// Host-qualified patterns
+		{"/api", "example.com/users", "example.com/api/users"},
+		{"/api", "GET example.com/users", "GET example.com/api/users"},
+		{"", "GET {sub}.example.com/", "GET {sub}.example.com/"},
 	}
 
 	for _, tt := range tests {
@@ -709,6 +841,27 @@ func TestChain_SingleMiddleware(t *testing.T) {
 	}
 }
 
+func TestGroup_PatternVisibleToMiddleware(t *testing.T) {
+	var pattern string
+	m := New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern = r.Pattern
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	api := m.Group("/api")
+	api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/42", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	if pattern != "GET /api/users/{id}" {
+		t.Errorf("expected pattern %q, got %q", "GET /api/users/{id}", pattern)
+	}
+}
+
 // Benchmarks
 // These benchmarks measure hmux-specific overhead during route registration.
 // Request serving (ServeHTTP) benchmarks are omitted because hmux adds zero
@@ -739,6 +892,37 @@ func BenchmarkMux_HandleFunc_RegistrationWithMiddleware(b *testing.B) {
 	}
 }
 
+func BenchmarkMux_Registration_ManyRoutes(b *testing.B) {
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+		})
+	}
+	patterns := make([]string, 1000)
+	for i := range patterns {
+		patterns[i] = fmt.Sprintf("GET /r/%d", i)
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"PerRoute", nil},
+		{"CacheChains", []Option{CacheChains()}},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := New(tt.opts...)
+				m.Use(mw, mw, mw, mw, mw)
+				for _, p := range patterns {
+					m.HandleFunc(p, func(w http.ResponseWriter, r *http.Request) {})
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkGroup_Creation(b *testing.B) {
 	m := New()
 	m.Use(func(next http.Handler) http.Handler {
@@ -793,3 +977,401 @@ func BenchmarkChain_Composition(b *testing.B) {
 		_ = Chain(mw1, mw2, mw3)
 	}
 }
+
+func TestMux_UseOuter(t *testing.T) {
+	var record []string
+	var pattern string
+	m := New()
+	m.Use(recordingMiddleware("inner", &record))
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		record = append(record, "handler")
+	})
+	m.UseOuter(recordingMiddleware("outer", &record))
+	m.UseOuter(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			pattern = r.Pattern
+		})
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	expected := []string{"outer:enter", "inner:enter", "handler", "inner:exit", "outer:exit"}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+	if pattern != "GET /users/{id}" {
+		t.Errorf("expected pattern visible after dispatch, got %q", pattern)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound},
+		{http.MethodPost, "/users/1", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		record = nil
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.status, rec.Code)
+		}
+		if expected := []string{"outer:enter", "outer:exit"}; !slices.Equal(record, expected) {
+			t.Errorf("%s %s: expected %v, got %v", tt.method, tt.path, expected, record)
+		}
+	}
+}
+
+func TestMux_UseOuter_NilMiddleware_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	New().UseOuter(nil)
+}
+
+func TestGroup_NotFound(t *testing.T) {
+	var record []string
+	m := New()
+	m.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	api := m.Group("/api").(ExtendedRouter)
+	api.Use(recordingMiddleware("api", &record))
+	api.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {})
+	api.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("api fallback"))
+	})
+
+	tests := []struct {
+		path    string
+		status  int
+		body    string
+		applied bool
+	}{
+		{"/api/items", http.StatusOK, "", true},
+		{"/api/missing", http.StatusNotFound, "api fallback", true},
+		{"/api/items/1", http.StatusNotFound, "api fallback", true},
+		{"/missing", http.StatusNotFound, "404 page not found\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record = nil
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, rec.Body.String())
+			}
+			if applied := len(record) > 0; applied != tt.applied {
+				t.Errorf("expected middleware applied=%v, got %v", tt.applied, applied)
+			}
+		})
+	}
+}
+
+func TestMux_NotFound(t *testing.T) {
+	var record []string
+	m := New(TrailingSlash(TrailingSlashRedirectAdd))
+	m.Use(recordingMiddleware("A", &record))
+	m.HandleFunc("GET /users/", func(w http.ResponseWriter, r *http.Request) {})
+	m.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom", http.StatusNotFound)
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "custom\n" {
+		t.Errorf("expected custom 404, got %d %q", rec.Code, rec.Body.String())
+	}
+	if len(record) != 2 {
+		t.Errorf("expected middleware applied, got %v", record)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/users/" {
+		t.Errorf("expected redirect to /users/, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestNotFound_NilHandler_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	New().Group("/api").(ExtendedRouter).NotFound(nil)
+}
+
+func TestWithHost(t *testing.T) {
+	tests := []struct {
+		host, pattern, want string
+	}{
+		{"", "GET /users", "GET /users"},
+		{"api.example.com", "/users", "api.example.com/users"},
+		{"api.example.com", "GET /users", "GET api.example.com/users"},
+		{"api.example.com", "GET api.example.com/users", "GET api.example.com/users"},
+	}
+
+	for _, tt := range tests {
+		if got := withHost(tt.host, tt.pattern); got != tt.want {
+			t.Errorf("withHost(%q, %q) = %q, want %q", tt.host, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMux_GroupHost(t *testing.T) {
+	m := New()
+	v1 := m.GroupHost("api.example.com", "/v1")
+	v1.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Pattern))
+	})
+	v1.Group("/admin").HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Pattern))
+	})
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"http://api.example.com/v1/users", http.StatusOK, "GET api.example.com/v1/users"},
+		{"http://api.example.com/v1/admin/stats", http.StatusOK, "GET api.example.com/v1/admin/stats"},
+		{"http://other.example.com/v1/users", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMux_GroupHost_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"empty host", func(m *Mux) { m.GroupHost("", "/v1") }},
+		{"host with slash", func(m *Mux) { m.GroupHost("example.com/v1", "") }},
+		{"invalid prefix", func(m *Mux) { m.GroupHost("example.com", "v1") }},
+		{"conflicting host", func(m *Mux) {
+			m.GroupHost("a.example.com", "").HandleFunc("b.example.com/x", func(http.ResponseWriter, *http.Request) {})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
+
+func TestTryHandle(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	if err := m.TryHandleFunc("GET /users", noop); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	api := m.GroupHost("api.example.com", "/api")
+
+	tests := []struct {
+		name     string
+		register func() error
+		msg      string
+	}{
+		{"conflict", func() error { return m.TryHandleFunc("GET /users", noop) }, "conflicts with pattern"},
+		{"invalid", func() error { return m.TryHandleFunc("GET /{bad", noop) }, "bad wildcard"},
+		{"nil handler", func() error { return m.TryHandle("GET /nil", nil) }, "nil handler"},
+		{"group host", func() error { return api.TryHandleFunc("GET other.com/x", noop) }, "conflicts with group host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.register()
+
+			var routeErr *RouteError
+			if !errors.As(err, &routeErr) {
+				t.Fatalf("expected *RouteError, got %v", err)
+			}
+			if !strings.Contains(routeErr.Err.Error(), tt.msg) {
+				t.Errorf("expected reason containing %q, got %q", tt.msg, routeErr.Err)
+			}
+			if strings.HasPrefix(routeErr.Err.Error(), "hmux: ") {
+				t.Errorf("expected reason without prefix, got %q", routeErr.Err)
+			}
+		})
+	}
+
+	if len(m.Routes()) != 1 {
+		t.Errorf("expected failed routes not to be recorded, got %v", m.Routes())
+	}
+
+	if err := m.Matching(MatchHeader("X-Beta", "1")).TryHandleFunc("GET /beta", noop); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for header, status := range map[string]int{"1": http.StatusOK, "": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/beta", nil)
+		req.Header.Set("X-Beta", header)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("X-Beta %q: expected matchers applied by TryHandle, got %d", header, rec.Code)
+		}
+	}
+}
+
+func TestUnhandle(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.HandleFunc("GET /a", noop)
+	m.Group("/api").HandleFunc("GET /b", noop)
+
+	if !m.Unhandle("GET /api/b") {
+		t.Fatal("expected route to be removed")
+	}
+	if m.Unhandle("GET /api/b") {
+		t.Error("expected second removal to report false")
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/a", http.StatusOK},
+		{"/api/b", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.status, rec.Code)
+		}
+	}
+
+	// The pattern can be registered again.
+	m.HandleFunc("GET /api/b", noop)
+	if len(m.Routes()) != 2 {
+		t.Errorf("expected 2 routes, got %d", len(m.Routes()))
+	}
+
+	// Patterns are normalized as on registration.
+	m = New(CaseInsensitive())
+	m.HandleFunc("GET /Users/{id:[0-9]+}", noop)
+	if !m.Unhandle("GET /Users/{id:[0-9]+}") {
+		t.Error("expected route with a constraint to be removed by its registered pattern")
+	}
+}
+
+func TestReload(t *testing.T) {
+	var record []string
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	m := New()
+	m.Use(recordingMiddleware("mw", &record))
+	m.HandleFunc("GET /a", respond("old a"))
+	m.HandleFunc("GET /b", respond("old b"))
+
+	err := m.Reload(func(r Router) {
+		r.HandleFunc("GET /a", respond("new a"))
+		r.Group("/api").HandleFunc("GET /c", respond("new c"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/a", http.StatusOK, "new a"},
+		{"/b", http.StatusNotFound, "404 page not found\n"},
+		{"/api/c", http.StatusOK, "new c"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	record = nil
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	if expected := []string{"mw:enter", "mw:exit"}; !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestReload_ErrorKeepsRoutes(t *testing.T) {
+	m := New()
+	m.HandleFunc("GET /a", func(w http.ResponseWriter, r *http.Request) {})
+
+	err := m.Reload(func(r Router) {
+		r.HandleFunc("GET /x", func(w http.ResponseWriter, r *http.Request) {})
+		r.HandleFunc("GET /x", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	if err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	if info, ok := m.Match(http.MethodGet, "", "/a"); !ok || info.Pattern != "GET /a" {
+		t.Errorf("expected GET /a to remain, got %q %v", info.Pattern, ok)
+	}
+	if _, ok := m.Match(http.MethodGet, "", "/x"); ok {
+		t.Error("expected GET /x not to be registered")
+	}
+}
+
+func TestReload_WhileServing(t *testing.T) {
+	m := New(Dynamic())
+	m.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			m.Reload(func(r Router) {
+				r.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {})
+			})
+		}
+	}()
+
+	for i := range 200 {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	<-done
+}
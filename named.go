@@ -0,0 +1,159 @@
+package hmux
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// namedMiddleware is a replaceable slot in a middleware stack. A nil mw
+// means the slot was removed and passes requests through unchanged.
+// Middleware inserted with UseBefore and UseAfter surrounds the slot and
+// stays in place when mw is replaced or removed.
+type namedMiddleware struct {
+	name   string
+	mw     func(http.Handler) http.Handler
+	before []func(http.Handler) http.Handler
+	after  []func(http.Handler) http.Handler
+}
+
+// UseNamed appends middleware to the Mux under the given name. It behaves
+// like Use, except that the middleware can later be swapped out with
+// ReplaceNamed or dropped with RemoveNamed, and the change applies to
+// every handler already registered with it, including handlers of groups
+// created afterwards. This lets frameworks built on hmux expose override
+// points to applications:
+//
+//	mux.UseNamed("auth", defaultAuth)
+//	mux.HandleFunc("GET /admin", adminHandler)
+//	mux.ReplaceNamed("auth", customAuth) // GET /admin now uses customAuth
+//
+// Named middleware is resolved when a route serves its first request, at
+// which point the Mux is frozen and ReplaceNamed and RemoveNamed panic.
+//
+// UseNamed panics if name is empty or already in use, or if mw is nil.
+func (m *Mux) UseNamed(name string, mw func(http.Handler) http.Handler) {
+	if name == "" {
+		panic("hmux: empty middleware name")
+	}
+	if mw == nil {
+		panic("hmux: nil middleware passed to UseNamed")
+	}
+	if _, ok := m.named[name]; ok {
+		panic("hmux: middleware " + name + " already registered")
+	}
+	m.checkDeferredUse()
+
+	slot := &namedMiddleware{name: name, mw: mw}
+	if m.named == nil {
+		m.named = make(map[string]*namedMiddleware)
+	}
+	m.named[name] = slot
+
+	defer m.lock()()
+	m.middleware = append(m.middleware, layer{fn: m.resolveNamed(slot), slot: slot})
+}
+
+// ReplaceNamed swaps the middleware registered under name for mw.
+//
+// ReplaceNamed panics if no middleware is registered under name, if mw is
+// nil, or if the Mux has started serving requests.
+func (m *Mux) ReplaceNamed(name string, mw func(http.Handler) http.Handler) {
+	if mw == nil {
+		panic("hmux: nil middleware passed to ReplaceNamed")
+	}
+
+	m.namedSlot(name).mw = mw
+}
+
+// RemoveNamed drops the middleware registered under name. Requests pass
+// through its slot unchanged. The name stays reserved, so a removed
+// middleware can be reinstated with ReplaceNamed.
+//
+// RemoveNamed panics if no middleware is registered under name or if the
+// Mux has started serving requests.
+func (m *Mux) RemoveNamed(name string) {
+	m.namedSlot(name).mw = nil
+}
+
+// UseBefore inserts middleware immediately before the middleware
+// registered under name, so it runs first on the way in. Like
+// ReplaceNamed, it applies to every handler already registered with the
+// slot, which lets plugins position themselves relative to existing
+// middleware instead of relying on call order:
+//
+//	mux.UseNamed("auth", auth)
+//	mux.UseBefore("auth", rateLimit) // rateLimit → auth → handler
+//
+// Middleware from successive calls runs in call order, and the inserted
+// middleware is kept if the slot is later replaced or removed.
+//
+// UseBefore panics if no middleware is registered under name, if any mw
+// is nil, or if the Mux has started serving requests.
+func (m *Mux) UseBefore(name string, mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("hmux: nil middleware passed to UseBefore")
+		}
+	}
+
+	slot := m.namedSlot(name)
+	slot.before = append(slot.before, mw...)
+}
+
+// UseAfter inserts middleware immediately after the middleware registered
+// under name, so it runs once that middleware has passed the request on.
+// Middleware from successive calls runs in call order. See UseBefore.
+//
+// UseAfter panics if no middleware is registered under name, if any mw
+// is nil, or if the Mux has started serving requests.
+func (m *Mux) UseAfter(name string, mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("hmux: nil middleware passed to UseAfter")
+		}
+	}
+
+	slot := m.namedSlot(name)
+	slot.after = append(slot.after, mw...)
+}
+
+// namedSlot returns the slot registered under name, panicking if there is
+// none or if the Mux is frozen.
+func (m *Mux) namedSlot(name string) *namedMiddleware {
+	if m.frozen.Load() {
+		panic("hmux: named middleware changed after the mux started serving")
+	}
+
+	slot, ok := m.named[name]
+	if !ok {
+		panic("hmux: no middleware registered as " + name)
+	}
+
+	return slot
+}
+
+// resolveNamed returns middleware that defers to whatever slot holds when
+// the wrapped route serves its first request.
+func (m *Mux) resolveNamed(slot *namedMiddleware) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		var (
+			once sync.Once
+			h    http.Handler
+		)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() {
+				m.frozen.Store(true)
+
+				stack := slices.Clone(slot.before)
+				if slot.mw != nil {
+					stack = append(stack, slot.mw)
+				}
+				h = wrap(next, append(stack, slot.after...))
+			})
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestUseNamed_Replace(t *testing.T) {
+	var record []string
+	m := New()
+	m.UseNamed("auth", recordingMiddleware("default", &record))
+	m.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {})
+	api := m.Group("/api")
+	api.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.ReplaceNamed("auth", recordingMiddleware("custom", &record))
+
+	for _, path := range []string{"/a", "/api/b"} {
+		record = nil
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		expected := []string{"custom:enter", "custom:exit"}
+		if !slices.Equal(record, expected) {
+			t.Errorf("%s: expected %v, got %v", path, expected, record)
+		}
+	}
+}
+
+func TestUseNamed_RemoveKeepsOrder(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.UseNamed("B", recordingMiddleware("B", &record))
+	m.Use(recordingMiddleware("C", &record))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.RemoveNamed("B")
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := []string{"A:enter", "C:enter", "C:exit", "A:exit"}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestUseNamed_FrozenAfterServe(t *testing.T) {
+	m := New()
+	m.UseNamed("auth", Chain())
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	m.ReplaceNamed("auth", Chain())
+}
+
+func TestUseNamed_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"empty name", func(m *Mux) { m.UseNamed("", Chain()) }},
+		{"nil middleware", func(m *Mux) { m.UseNamed("x", nil) }},
+		{"duplicate", func(m *Mux) { m.UseNamed("x", Chain()); m.UseNamed("x", Chain()) }},
+		{"replace unknown", func(m *Mux) { m.ReplaceNamed("x", Chain()) }},
+		{"replace nil", func(m *Mux) { m.UseNamed("x", Chain()); m.ReplaceNamed("x", nil) }},
+		{"remove unknown", func(m *Mux) { m.RemoveNamed("x") }},
+		{"before unknown", func(m *Mux) { m.UseBefore("x", Chain()) }},
+		{"after nil", func(m *Mux) { m.UseNamed("x", Chain()); m.UseAfter("x", nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
+
+func TestUseBeforeAfter(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.UseNamed("auth", recordingMiddleware("auth", &record))
+	m.Use(recordingMiddleware("Z", &record))
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.UseBefore("auth", recordingMiddleware("b1", &record))
+	m.UseBefore("auth", recordingMiddleware("b2", &record))
+	m.UseAfter("auth", recordingMiddleware("a1", &record))
+	m.ReplaceNamed("auth", recordingMiddleware("custom", &record))
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := []string{
+		"A:enter", "b1:enter", "b2:enter", "custom:enter", "a1:enter", "Z:enter",
+		"Z:exit", "a1:exit", "custom:exit", "b2:exit", "b1:exit", "A:exit",
+	}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestUseBefore_SurvivesRemove(t *testing.T) {
+	var record []string
+	m := New()
+	m.UseNamed("auth", recordingMiddleware("auth", &record))
+	m.UseBefore("auth", recordingMiddleware("before", &record))
+	m.RemoveNamed("auth")
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := []string{"before:enter", "before:exit"}
+	if !slices.Equal(record, expected) {
+		t.Errorf("expected %v, got %v", expected, record)
+	}
+}
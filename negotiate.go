@@ -0,0 +1,172 @@
+package hmux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Negotiator dispatches a request to one of several handlers based on the
+// media types the client accepts. It is an http.Handler, registered like
+// any other:
+//
+//	mux.Handle("GET /users", hmux.Negotiate().
+//	    JSON(listUsersJSON).
+//	    HTML(listUsersHTML))
+//
+// A Negotiator must be fully configured before it serves requests.
+type Negotiator struct {
+	offers []offer
+}
+
+type offer struct {
+	mediaType string
+	handler   http.Handler
+}
+
+// Negotiate returns an empty Negotiator.
+func Negotiate() *Negotiator {
+	return &Negotiator{}
+}
+
+// Offer adds a handler producing the given media type, such as
+// "application/json". Offers added first win ties.
+//
+// Offer panics if mediaType is not of the form "type/subtype" or if h is
+// nil.
+func (n *Negotiator) Offer(mediaType string, h http.HandlerFunc) *Negotiator {
+	typ, sub, ok := strings.Cut(mediaType, "/")
+	if !ok || typ == "" || sub == "" || typ == "*" || sub == "*" {
+		panic("hmux: invalid media type " + mediaType + " offered to Negotiator")
+	}
+	if h == nil {
+		panic("hmux: nil handler offered to Negotiator")
+	}
+
+	n.offers = append(n.offers, offer{mediaType: strings.ToLower(mediaType), handler: h})
+
+	return n
+}
+
+// JSON offers h for application/json.
+func (n *Negotiator) JSON(h http.HandlerFunc) *Negotiator {
+	return n.Offer("application/json", h)
+}
+
+// HTML offers h for text/html.
+func (n *Negotiator) HTML(h http.HandlerFunc) *Negotiator {
+	return n.Offer("text/html", h)
+}
+
+// XML offers h for application/xml.
+func (n *Negotiator) XML(h http.HandlerFunc) *Negotiator {
+	return n.Offer("application/xml", h)
+}
+
+// Text offers h for text/plain.
+func (n *Negotiator) Text(h http.HandlerFunc) *Negotiator {
+	return n.Offer("text/plain", h)
+}
+
+// ServeHTTP serves the request with the offer the client prefers, as
+// weighted by the q-values of its Accept header, and adds Accept to the
+// Vary header. A request without an Accept header gets the first offer.
+// If no offer is acceptable, the route is aborted with 406 Not
+// Acceptable, rendered by the Mux's error handler.
+func (n *Negotiator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	AddVary(w.Header(), "Accept")
+
+	if h := n.match(r.Header.Values("Accept")); h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	raise(Errorf(http.StatusNotAcceptable, "none of the available media types is acceptable"))
+}
+
+// match returns the handler of the best offer for the Accept headers.
+func (n *Negotiator) match(accept []string) http.Handler {
+	if len(n.offers) == 0 {
+		return nil
+	}
+
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return n.offers[0].handler
+	}
+
+	var (
+		best  http.Handler
+		bestQ float64
+	)
+	for _, o := range n.offers {
+		if q := acceptQuality(ranges, o.mediaType); q > bestQ {
+			best, bestQ = o.handler, q
+		}
+	}
+
+	return best
+}
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	typ, sub string
+	q        float64
+}
+
+// parseAccept parses Accept header values into media ranges. Malformed
+// entries are skipped.
+func parseAccept(values []string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			mt, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			typ, sub, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mt)), "/")
+			if !ok || typ == "" || sub == "" {
+				continue
+			}
+
+			q := 1.0
+			for _, p := range strings.Split(params, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(p), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+					if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && f >= 0 && f <= 1 {
+						q = f
+					}
+				}
+			}
+
+			ranges = append(ranges, mediaRange{typ: typ, sub: sub, q: q})
+		}
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the q-value the most specific matching media
+// range assigns to mediaType, or 0 if none matches.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	typ, sub, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		var s int
+		switch {
+		case mr.typ == typ && mr.sub == sub:
+			s = 2
+		case mr.typ == typ && mr.sub == "*":
+			s = 1
+		case mr.typ == "*" && mr.sub == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,75 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiator(t *testing.T) {
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	m := New()
+	m.Handle("GET /users", Negotiate().JSON(respond("json")).HTML(respond("html")))
+
+	tests := []struct {
+		accept string
+		status int
+		body   string
+	}{
+		{"", http.StatusOK, "json"},
+		{"text/html", http.StatusOK, "html"},
+		{"application/json", http.StatusOK, "json"},
+		{"text/html;q=0.5, application/json;q=0.9", http.StatusOK, "json"},
+		{"text/*, application/json;q=0.1", http.StatusOK, "html"},
+		{"*/*", http.StatusOK, "json"},
+		{"*/*;q=0.1, text/html", http.StatusOK, "html"},
+		{"application/*;q=0, */*", http.StatusOK, "html"},
+		{"image/png", http.StatusNotAcceptable, "none of the available media types is acceptable\n"},
+		{"application/json;q=0", http.StatusNotAcceptable, "none of the available media types is acceptable\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestNegotiator_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"invalid media type", func() { Negotiate().Offer("json", func(http.ResponseWriter, *http.Request) {}) }},
+		{"wildcard", func() { Negotiate().Offer("text/*", func(http.ResponseWriter, *http.Request) {}) }},
+		{"nil handler", func() { Negotiate().JSON(nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package hmux
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures a Mux. Options are passed to New.
+type Option func(*Mux)
+
+// Deferred returns an Option that composes middleware lazily. Instead of
+// wrapping each handler when it is registered, a deferred Mux wraps it
+// with the full middleware stack of its Mux or group when the route
+// serves its first request. Registration order then no longer matters:
+// Use after HandleFunc still applies to earlier routes, and a group sees
+// middleware added to its parent after the group was created.
+//
+// Patterns are still validated and registered immediately, so conflicts
+// panic at registration as usual. Calling Use after the Mux has started
+// serving panics, since already composed routes would not pick it up.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.Deferred())
+//	mux.HandleFunc("GET /users", listUsers)
+//	mux.Use(logging) // applies to GET /users
+func Deferred() Option {
+	return func(m *Mux) {
+		m.deferred = true
+	}
+}
+
+// Dynamic returns an Option that makes route registration safe while
+// the Mux is serving requests, for plugin systems that load routes after
+// startup. Handle, HandleFunc, Use and Group may then be called
+// concurrently with ServeHTTP and with each other.
+//
+// A dynamic Mux keeps its routes in a copy-on-write table: each
+// registration builds a new http.ServeMux holding every route and
+// atomically swaps it in, so a request is always matched against a
+// complete table, before or after the change. Registration costs time
+// proportional to the number of routes. Handler returns the table
+// current at the time of the call.
+//
+// A Group is not safe for concurrent modification, and Host and Version
+// routers must be created before serving. Dynamic cannot be combined
+// with Deferred.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.Dynamic())
+//	go http.ListenAndServe(":8080", mux)
+//	plugin.Register(mux) // safe while serving
+func Dynamic() Option {
+	return func(m *Mux) {
+		m.dynamic = true
+	}
+}
+
+// CacheChains returns an Option that composes each distinct middleware
+// stack once instead of once per route. Routes registered on the same
+// Mux or group with no Use in between share a stack, so a service
+// registering thousands of routes behind a few stacks allocates a
+// middleware chain per stack rather than per route.
+//
+// A shared chain calls each middleware once for all routes of the stack
+// and finds the route's handler at request time, so middleware that
+// keeps per-route state when it wraps a handler, such as a rate limiter
+// created per route, shares that state across the routes instead.
+// Middleware added with With or to a nested group forms a new stack.
+// CacheChains has no effect on a Mux created with Deferred, which
+// composes each route at its first request.
+func CacheChains() Option {
+	return func(m *Mux) {
+		m.cacheChains = true
+	}
+}
+
+// NotFoundHandler returns an Option that serves requests matching no
+// route with h instead of the default 404 Not Found response. Unlike
+// Mux.NotFound, it does not register a catch-all route: requests that
+// match a route's path but not its method still get 405 Method Not
+// Allowed, and h runs without the Mux's Use middleware, though outer
+// middleware applies. Host and Version routers use it too.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.NotFoundHandler(http.HandlerFunc(notFoundPage)))
+//
+// NotFoundHandler panics if h is nil.
+func NotFoundHandler(h http.Handler) Option {
+	if h == nil {
+		panic("hmux: nil handler passed to NotFoundHandler")
+	}
+
+	return func(m *Mux) {
+		m.notFound = h
+	}
+}
+
+// Strict returns an Option that makes Handle and HandleFunc panic for
+// patterns that do not name a method, such as "/users", which would
+// otherwise silently accept every method. Routes registered by NotFound,
+// which accept every method by design, are exempt.
+func Strict() Option {
+	return func(m *Mux) {
+		m.strict = true
+	}
+}
+
+// checkStrict panics if the Mux is strict and pattern names no method.
+func (m *Mux) checkStrict(pattern string) {
+	if !m.strict {
+		return
+	}
+
+	if method, _, ok := strings.Cut(pattern, " "); !ok || method == "" || strings.Contains(method, "/") {
+		panic("hmux: pattern " + pattern + " must name a method in strict mode")
+	}
+}
+
+// notFoundHandler returns the handler set with NotFoundHandler for the
+// Mux or the Mux its routers belong to, or nil if there is none.
+func (m *Mux) notFoundHandler() http.Handler {
+	for ; m != nil; m = m.parent {
+		if m.notFound != nil {
+			return m.notFound
+		}
+	}
+
+	return nil
+}
+
+// serveUnmatched serves r, which matched no route, with h, the handler
+// http.ServeMux returned for it. h answers 405 Method Not Allowed when
+// the path matches a route of another method and 404 Not Found
+// otherwise; a 404 is replaced by the response of notFound. h runs once
+// and the request is not routed again.
+func serveUnmatched(w http.ResponseWriter, r *http.Request, h, notFound http.Handler) {
+	uw := &unmatchedWriter{w: w, header: make(http.Header)}
+	h.ServeHTTP(uw, r)
+
+	if uw.status == http.StatusNotFound {
+		notFound.ServeHTTP(w, r)
+	}
+}
+
+// unmatchedWriter holds back the headers of http.ServeMux's response to
+// an unmatched request until its status is known, and drops the response
+// if it is 404 Not Found.
+type unmatchedWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+}
+
+func (uw *unmatchedWriter) Header() http.Header {
+	return uw.header
+}
+
+func (uw *unmatchedWriter) Write(b []byte) (int, error) {
+	if uw.status == 0 {
+		uw.WriteHeader(http.StatusOK)
+	}
+	if uw.status == http.StatusNotFound {
+		return len(b), nil
+	}
+
+	return uw.w.Write(b)
+}
+
+func (uw *unmatchedWriter) WriteHeader(status int) {
+	if uw.status != 0 {
+		return
+	}
+	uw.status = status
+
+	if status != http.StatusNotFound {
+		maps.Copy(uw.w.Header(), uw.header)
+		uw.w.WriteHeader(status)
+	}
+}
+
+// TrailingSlashPolicy controls how a Mux treats a request path that
+// differs from a registered route only by a trailing slash.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashStrict leaves trailing slashes to http.ServeMux
+	// semantics: "/users" and "/users/" are different routes, and a
+	// pattern ending in "/" matches a whole subtree. This is the default.
+	TrailingSlashStrict TrailingSlashPolicy = iota
+
+	// TrailingSlashRedirectStrip redirects "/users/" to "/users" when
+	// only the latter resolves to a registered route.
+	TrailingSlashRedirectStrip
+
+	// TrailingSlashRedirectAdd redirects "/users" to "/users/" when only
+	// the latter resolves to a registered route.
+	TrailingSlashRedirectAdd
+)
+
+// TrailingSlash returns an Option that sets the trailing-slash policy.
+//
+// A redirect is only issued when the alternative path resolves to a
+// route and the original path does not resolve to an exact route of its
+// own, so explicitly registered variants and subtree patterns such as
+// "/static/" keep working. Redirects use 301 Moved Permanently for GET
+// and HEAD requests and 308 Permanent Redirect for other methods, so the
+// request method and body are preserved. The query string is kept.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.TrailingSlash(hmux.TrailingSlashRedirectStrip))
+//	mux.HandleFunc("GET /users", listUsers) // GET /users/ → 301 /users
+func TrailingSlash(policy TrailingSlashPolicy) Option {
+	return func(m *Mux) {
+		m.trailingSlash = policy
+	}
+}
+
+// redirectTrailingSlash applies the trailing-slash policy. It reports
+// whether a redirect was written.
+//
+// The redirect always points to a path on the same host: leading slashes
+// of the target are collapsed, so a request for "//evil.com/" cannot
+// redirect to "//evil.com".
+func (m *Mux) redirectTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
+	path := r.URL.Path
+	if path == "/" || path == "" {
+		return false
+	}
+
+	var alt string
+	switch m.trailingSlash {
+	case TrailingSlashRedirectStrip:
+		if path[len(path)-1] != '/' {
+			return false
+		}
+		alt = path[:len(path)-1]
+	case TrailingSlashRedirectAdd:
+		if path[len(path)-1] == '/' {
+			return false
+		}
+		alt = path + "/"
+	default:
+		return false
+	}
+	alt = localPath(alt)
+
+	altReq := r.WithContext(r.Context())
+	u := *r.URL
+	u.Path, u.RawPath = alt, ""
+	altReq.URL = &u
+
+	mux := m.mux.Load()
+	_, current := mux.Handler(r)
+	_, target := mux.Handler(altReq)
+
+	switch {
+	case target == "":
+		return false
+	case target == current:
+		// Both paths resolve to the same pattern. If it matches the
+		// alternative exactly, http.ServeMux is already redirecting one
+		// to the other, and taking over that redirect only makes sense
+		// in the direction of the policy. Otherwise both paths fall into
+		// the same subtree pattern, such as a "/" fallback.
+		if m.trailingSlash != TrailingSlashRedirectAdd || !exactMatch(current, alt) {
+			return false
+		}
+	case exactPattern(current):
+		return false
+	}
+
+	code := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	http.Redirect(w, r, u.RequestURI(), code)
+
+	return true
+}
+
+// localPath collapses the leading slashes and backslashes of path into
+// one slash, so a redirect to it cannot be read as a scheme-relative URL
+// such as "//evil.com" pointing at another host.
+func localPath(path string) string {
+	return "/" + strings.TrimLeft(path, "/\\")
+}
+
+// exactPattern reports whether pattern matches a single path rather than
+// a subtree. Subtree patterns end in "/" or a "{name...}" wildcard.
+func exactPattern(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	_, path := splitMethodPath(pattern)
+	if len(path) > 4 && path[len(path)-4:] == "...}" {
+		return false
+	}
+
+	return path[len(path)-1] != '/'
+}
+
+// exactMatch reports whether pattern matches path exactly rather than as
+// part of its subtree, mirroring the check http.ServeMux uses to decide
+// on trailing-slash redirects.
+func exactMatch(pattern, path string) bool {
+	if exactPattern(pattern) {
+		return true
+	}
+
+	_, p := splitMethodPath(pattern)
+
+	return strings.HasSuffix(path, "/") && strings.Count(path, "/") == strings.Count(p, "/")
+}
+
+// CaseInsensitive returns an Option that matches request paths without
+// regard to case, so "/Users" and "/users" resolve to the same route.
+//
+// Literal segments of registered patterns are lowercased, and so is the
+// path of each request before it is matched; r.Pattern and Routes report
+// the lowercased pattern. Wildcards keep the case of the request:
+// r.PathValue("name") for "/Users/Alice" matched against
+// "/users/{name}" returns "Alice". Middleware and handlers see
+// r.URL.Path in lowercase, and redirects issued by http.ServeMux or the
+// trailing-slash policy point to the lowercased path. Outer middleware
+// sees the request as received.
+//
+// Example:
+//
+//	mux := hmux.New(hmux.CaseInsensitive())
+//	mux.HandleFunc("GET /Users/{name}", showUser) // GET /USERS/Alice → showUser, name "Alice"
+func CaseInsensitive() Option {
+	return func(m *Mux) {
+		m.caseFold = true
+	}
+}
+
+// lowerPattern lowercases the literal path segments of pattern, leaving
+// its method, host and wildcards unchanged.
+func lowerPattern(pattern string) string {
+	method, rest := splitMethodPath(pattern)
+	host, path := splitHostPath(rest)
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") {
+			segments[i] = strings.ToLower(seg)
+		}
+	}
+	path = host + strings.Join(segments, "/")
+
+	if method != "" {
+		return method + " " + path
+	}
+
+	return path
+}
+
+// lowerPath returns a shallow copy of r with its path lowercased and the
+// original escaped path in its context, or r itself if the path has no
+// uppercase letters.
+func lowerPath(r *http.Request) *http.Request {
+	path := strings.ToLower(r.URL.Path)
+	rawPath := strings.ToLower(r.URL.RawPath)
+	if path == r.URL.Path && rawPath == r.URL.RawPath {
+		return r
+	}
+
+	lr := r.WithContext(context.WithValue(r.Context(), originalPathKey, r.URL.EscapedPath()))
+	u := *r.URL
+	u.Path, u.RawPath = path, rawPath
+	lr.URL = &u
+
+	return lr
+}
+
+// restorePathValues returns h with the wildcards of pattern set from the
+// original, unlowered request path recorded by lowerPath.
+func restorePathValues(pattern string, h http.Handler) http.Handler {
+	type wildcard struct {
+		index int
+		name  string
+		rest  bool
+	}
+
+	_, rest := splitMethodPath(pattern)
+	_, path := splitHostPath(rest)
+
+	var wildcards []wildcard
+	for i, seg := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(seg, "{")
+		if !ok || name == "$}" {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		name, multi := strings.CutSuffix(name, "...")
+		wildcards = append(wildcards, wildcard{index: i, name: name, rest: multi})
+	}
+	if len(wildcards) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if orig, ok := r.Context().Value(originalPathKey).(string); ok {
+			segments := strings.Split(orig, "/")
+			for _, wc := range wildcards {
+				if wc.index >= len(segments) {
+					continue
+				}
+
+				v := segments[wc.index]
+				if wc.rest {
+					v = strings.Join(segments[wc.index:], "/")
+				}
+				if u, err := url.PathUnescape(v); err == nil {
+					r.SetPathValue(wc.name, u)
+				}
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
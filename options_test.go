@@ -0,0 +1,489 @@
+package hmux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestTrailingSlash(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		name     string
+		policy   TrailingSlashPolicy
+		patterns []string
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{
+			name:     "strict leaves slash alone",
+			policy:   TrailingSlashStrict,
+			patterns: []string{"GET /users"},
+			method:   http.MethodGet,
+			target:   "/users/",
+			code:     http.StatusNotFound,
+		},
+		{
+			name:     "strip redirects GET with 301",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /users"},
+			method:   http.MethodGet,
+			target:   "/users/?page=2",
+			code:     http.StatusMovedPermanently,
+			location: "/users?page=2",
+		},
+		{
+			name:     "strip redirects POST with 308",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"POST /users"},
+			method:   http.MethodPost,
+			target:   "/users/",
+			code:     http.StatusPermanentRedirect,
+			location: "/users",
+		},
+		{
+			name:     "strip redirects past catch-all",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /", "GET /users"},
+			method:   http.MethodGet,
+			target:   "/users/",
+			code:     http.StatusMovedPermanently,
+			location: "/users",
+		},
+		{
+			name:     "strip keeps explicit slash route",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /users", "GET /users/{$}"},
+			method:   http.MethodGet,
+			target:   "/users/",
+			code:     http.StatusOK,
+		},
+		{
+			name:     "strip keeps subtree route",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"/static/"},
+			method:   http.MethodGet,
+			target:   "/static/",
+			code:     http.StatusOK,
+		},
+		{
+			name:     "strip without target is 404",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /users"},
+			method:   http.MethodGet,
+			target:   "/posts/",
+			code:     http.StatusNotFound,
+		},
+		{
+			name:     "strip collapses leading slashes",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /{name}"},
+			method:   http.MethodGet,
+			target:   "//evil.com/",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com",
+		},
+		{
+			name:     "strip collapses leading backslashes",
+			policy:   TrailingSlashRedirectStrip,
+			patterns: []string{"GET /{name}"},
+			method:   http.MethodGet,
+			target:   "/%5Cevil.com/",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com",
+		},
+		{
+			name:     "add redirects",
+			policy:   TrailingSlashRedirectAdd,
+			patterns: []string{"GET /users/{$}"},
+			method:   http.MethodGet,
+			target:   "/users",
+			code:     http.StatusMovedPermanently,
+			location: "/users/",
+		},
+		{
+			name:     "add collapses leading slashes",
+			policy:   TrailingSlashRedirectAdd,
+			patterns: []string{"GET /{name}/{$}"},
+			method:   http.MethodGet,
+			target:   "//evil.com",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com/",
+		},
+		{
+			name:     "add keeps exact route",
+			policy:   TrailingSlashRedirectAdd,
+			patterns: []string{"GET /users", "GET /users/{$}"},
+			method:   http.MethodGet,
+			target:   "/users",
+			code:     http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(TrailingSlash(tt.policy))
+			for _, p := range tt.patterns {
+				m.HandleFunc(p, noop)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, rec.Code)
+			}
+			if loc := rec.Header().Get("Location"); loc != tt.location {
+				t.Errorf("expected Location %q, got %q", tt.location, loc)
+			}
+		})
+	}
+}
+
+func TestExactPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected bool
+	}{
+		{"", false},
+		{"/", false},
+		{"GET /users", true},
+		{"GET /users/", false},
+		{"/users/{$}", true},
+		{"/users/{id}", true},
+		{"/files/{path...}", false},
+	}
+
+	for _, tt := range tests {
+		if got := exactPattern(tt.pattern); got != tt.expected {
+			t.Errorf("exactPattern(%q) = %v, expected %v", tt.pattern, got, tt.expected)
+		}
+	}
+}
+
+func TestExactMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"/", "/", true},
+		{"/", "/missing/", false},
+		{"GET /users/", "/users/", true},
+		{"GET /users/", "/users/1/", false},
+		{"/files/{path...}", "/files/", true},
+		{"/files/{path...}", "/files/a/", false},
+		{"/users/{id}", "/users/1", true},
+	}
+
+	for _, tt := range tests {
+		if got := exactMatch(tt.pattern, tt.path); got != tt.expected {
+			t.Errorf("exactMatch(%q, %q) = %v, expected %v", tt.pattern, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestDeferred(t *testing.T) {
+	var record []string
+	m := New(Deferred())
+	m.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {})
+	api := m.Group("/api")
+	api.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {})
+	admin := api.With(recordingMiddleware("C", &record))
+	admin.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.Use(recordingMiddleware("A", &record))
+	api.Use(recordingMiddleware("B", &record))
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/a", []string{"A:enter", "A:exit"}},
+		{"/api/b", []string{"A:enter", "B:enter", "B:exit", "A:exit"}},
+		{"/api/c", []string{"A:enter", "B:enter", "C:enter", "C:exit", "B:exit", "A:exit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record = nil
+			m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if !slices.Equal(record, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, record)
+			}
+		})
+	}
+}
+
+func TestDeferred_UseAfterServe_Panics(t *testing.T) {
+	m := New(Deferred())
+	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	m.Use(Chain())
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	m := New(CaseInsensitive())
+	m.HandleFunc("GET /Users/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + r.PathValue("name") + " " + r.Pattern))
+	})
+	m.HandleFunc("GET /files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file " + r.PathValue("path")))
+	})
+	m.Host("{tenant}.example.com").HandleFunc("GET /Home", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	})
+	m.HandleFunc("GET /codes/{code:[A-Z]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("code " + r.PathValue("code")))
+	})
+	m.HandleFunc("GET /slugs/{slug:[a-z]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("slug " + r.PathValue("slug")))
+	})
+
+	tests := []struct {
+		host, path string
+		status     int
+		body       string
+	}{
+		{"", "/users/alice", http.StatusOK, "user alice GET /users/{name}"},
+		{"", "/USERS/Alice", http.StatusOK, "user Alice GET /users/{name}"},
+		{"", "/Files/Docs/Read%20Me.TXT", http.StatusOK, "file Docs/Read Me.TXT"},
+		{"acme.example.com", "/HOME", http.StatusOK, "home"},
+		{"", "/Groups", http.StatusNotFound, "404 page not found\n"},
+		{"", "/Codes/ABC", http.StatusOK, "code ABC"},
+		{"", "/codes/abc", http.StatusNotFound, "404 page not found\n"},
+		{"", "/SLUGS/abc", http.StatusOK, "slug abc"},
+		{"", "/slugs/ABC", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if info, ok := m.Match(http.MethodGet, "", "/USERS/Bob"); !ok || info.Pattern != "GET /users/{name}" {
+		t.Errorf("expected GET /users/{name}, got %q %v", info.Pattern, ok)
+	}
+}
+
+func TestCaseInsensitive_PatternVisibleToOuter(t *testing.T) {
+	var pattern string
+	m := New(CaseInsensitive())
+	m.UseOuter(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			pattern = r.Pattern
+		})
+	})
+	m.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/Users", nil))
+	if pattern != "GET /users" {
+		t.Errorf("expected GET /users, got %q", pattern)
+	}
+}
+
+func TestDynamic_RegisterWhileServing(t *testing.T) {
+	m := New(Dynamic())
+	m.HandleFunc("GET /static", func(w http.ResponseWriter, r *http.Request) {})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 50 {
+			m.Use(func(next http.Handler) http.Handler { return next })
+			m.HandleFunc(fmt.Sprintf("GET /plugin/%d", i), func(w http.ResponseWriter, r *http.Request) {})
+		}
+	}()
+
+	for i := range 200 {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	<-done
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plugin/49", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if len(m.Routes()) != 51 {
+		t.Errorf("expected 51 routes, got %d", len(m.Routes()))
+	}
+}
+
+func TestDynamic_ConflictKeepsTable(t *testing.T) {
+	m := New(Dynamic())
+	m.HandleFunc("GET /a", func(w http.ResponseWriter, r *http.Request) {})
+	before := m.Handler()
+
+	if err := m.TryHandleFunc("GET /a", func(w http.ResponseWriter, r *http.Request) {}); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if m.Handler() != before {
+		t.Error("expected route table to be unchanged")
+	}
+
+	m.HandleFunc("GET /b", func(w http.ResponseWriter, r *http.Request) {})
+	if len(m.Routes()) != 2 {
+		t.Errorf("expected 2 routes, got %d", len(m.Routes()))
+	}
+}
+
+func TestDynamic_WithDeferred_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	New(Dynamic(), Deferred())
+}
+
+func TestCacheChains(t *testing.T) {
+	var wraps int
+	counting := func(next http.Handler) http.Handler {
+		wraps++
+		return next
+	}
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body + " " + RoutePattern(r)))
+		}
+	}
+
+	m := New(CacheChains())
+	m.Use(counting)
+	m.HandleFunc("GET /a", respond("a"))
+	m.HandleFunc("GET /b", respond("b"))
+	api := m.Group("/api")
+	api.HandleFunc("GET /c", respond("c"))
+	api.HandleFunc("GET /d", respond("d"))
+	m.Use(counting)
+	m.HandleFunc("GET /e", respond("e"))
+
+	if wraps != 4 {
+		t.Errorf("expected 4 wraps for 3 stacks, got %d", wraps)
+	}
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/a", "a GET /a"},
+		{"/b", "b GET /b"},
+		{"/api/c", "c GET /api/c"},
+		{"/api/d", "d GET /api/d"},
+		{"/e", "e GET /e"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+	}
+
+	// Middleware dropping the request context loses the route.
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.Background()))
+		})
+	})
+	m.HandleFunc("GET /f", respond("f"))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/f", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 without the route in the context, got %d", rec.Code)
+	}
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	m := New(NotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom"))
+	})))
+	m.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, "users"},
+		{http.MethodGet, "/missing", http.StatusNotFound, "custom"},
+		{http.MethodPost, "/users", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %d %q, got %d %q", tt.method, tt.path, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if h := rec.Header(); h.Get("X-Content-Type-Options") != "" {
+		t.Errorf("expected only the custom handler's headers, got %v", h)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("expected Allow on 405, got %q", allow)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		wantPanic bool
+	}{
+		{"GET /users", false},
+		{"PURGE /cache", false},
+		{"GET example.com/users", false},
+		{"/users", true},
+		{"example.com/users", true},
+	}
+	for _, tt := range tests {
+		m := New(Strict())
+		err := m.TryHandleFunc(tt.pattern, func(w http.ResponseWriter, r *http.Request) {})
+		if (err != nil) != tt.wantPanic {
+			t.Errorf("%q: expected error %v, got %v", tt.pattern, tt.wantPanic, err)
+		}
+	}
+
+	m := New(Strict())
+	m.Group("/api").(ExtendedRouter).NotFound(http.NotFound)
+	m.NotFound(http.NotFound)
+}
@@ -0,0 +1,25 @@
+module github.com/nikita-shtimenko/hmux/otelhmux
+
+go 1.23
+
+require (
+	github.com/nikita-shtimenko/hmux v1.0.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/metric v1.35.0
+	go.opentelemetry.io/otel/sdk/metric v1.35.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
+
+// otelhmux needs hmux.StatusWriter, which no hmux release has yet, so it
+// builds against the hmux in the parent directory. Replace this with a
+// requirement on the first release that has it before tagging otelhmux.
+replace github.com/nikita-shtimenko/hmux => ../
@@ -0,0 +1,169 @@
+// Package otelhmux provides OpenTelemetry metrics instrumentation for
+// hmux. It lives in its own module so that the core hmux module keeps
+// depending on the standard library only.
+//
+//	mux := hmux.New()
+//	mux.Use(otelhmux.Metrics())
+package otelhmux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// ScopeName is the instrumentation scope name used to obtain a Meter.
+const ScopeName = "github.com/nikita-shtimenko/hmux/otelhmux"
+
+// durationBuckets are the explicit bucket boundaries recommended by the
+// HTTP semantic conventions for http.server.request.duration.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Option configures the Metrics middleware.
+type Option func(*config)
+
+type config struct {
+	provider metric.MeterProvider
+}
+
+// WithMeterProvider sets the MeterProvider used to create instruments.
+// The global provider from otel.GetMeterProvider is used by default.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.provider = mp
+	}
+}
+
+// Metrics returns middleware recording the stable HTTP server metrics
+// defined by the OpenTelemetry semantic conventions:
+//
+//   - http.server.request.duration (histogram, seconds)
+//   - http.server.active_requests (up-down counter)
+//   - http.server.response.body.size (histogram, bytes)
+//
+// Measurements carry http.request.method, url.scheme and, where a route
+// matched, http.route set to the path template of the registered pattern
+// (for example "/users/{id}"). Duration and body size additionally carry
+// http.response.status_code, network.protocol.version and, for 5xx
+// responses, error.type.
+//
+// Metrics panics if an instrument cannot be created.
+func Metrics(opts ...Option) func(http.Handler) http.Handler {
+	cfg := config{provider: otel.GetMeterProvider()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.provider.Meter(ScopeName)
+
+	duration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		panic("hmux: creating http.server.request.duration: " + err.Error())
+	}
+
+	active, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of active HTTP server requests."))
+	if err != nil {
+		panic("hmux: creating http.server.active_requests: " + err.Error())
+	}
+
+	bodySize, err := meter.Int64Histogram("http.server.response.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server response bodies."))
+	if err != nil {
+		panic("hmux: creating http.server.response.body.size: " + err.Error())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			base := []attribute.KeyValue{
+				attribute.String("http.request.method", method(r.Method)),
+				attribute.String("url.scheme", scheme(r)),
+			}
+
+			activeAttrs := metric.WithAttributeSet(attribute.NewSet(base...))
+			active.Add(ctx, 1, activeAttrs)
+			defer active.Add(ctx, -1, activeAttrs)
+
+			start := time.Now()
+			sw := hmux.NewStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			status := sw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			attrs := append(base,
+				attribute.Int("http.response.status_code", status),
+				attribute.String("network.protocol.version", protocolVersion(r)),
+			)
+			if route := route(r.Pattern); route != "" {
+				attrs = append(attrs, attribute.String("http.route", route))
+			}
+			if status >= http.StatusInternalServerError {
+				attrs = append(attrs, attribute.String("error.type", strconv.Itoa(status)))
+			}
+
+			set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+			duration.Record(ctx, time.Since(start).Seconds(), set)
+			bodySize.Record(ctx, sw.BytesWritten(), set)
+		})
+	}
+}
+
+// method normalizes the request method as required by the semantic
+// conventions, mapping unknown methods to "_OTHER".
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return m
+	default:
+		return "_OTHER"
+	}
+}
+
+// route returns the path template of a ServeMux pattern, dropping the
+// method and host parts: "GET example.com/users/{id}" → "/users/{id}".
+func route(pattern string) string {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(rest, " \t")
+	}
+
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	return pattern
+}
+
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func protocolVersion(r *http.Request) string {
+	switch r.ProtoMajor {
+	case 1:
+		return "1." + strconv.Itoa(r.ProtoMinor)
+	default:
+		return strconv.Itoa(r.ProtoMajor)
+	}
+}
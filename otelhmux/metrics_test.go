@@ -0,0 +1,121 @@
+package otelhmux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	m := hmux.New()
+	m.Use(Metrics(WithMeterProvider(provider)))
+	api := m.Group("/api")
+	api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user"))
+	})
+	api.HandleFunc("POST /fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/fail", nil))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, md := range sm.Metrics {
+			metrics[md.Name] = md.Data
+		}
+	}
+
+	duration, ok := metrics["http.server.request.duration"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("expected duration histogram, got %T", metrics["http.server.request.duration"])
+	}
+	if len(duration.DataPoints) != 2 {
+		t.Fatalf("expected 2 data points, got %d", len(duration.DataPoints))
+	}
+
+	var sawRoute, sawError bool
+	for _, dp := range duration.DataPoints {
+		if v, ok := dp.Attributes.Value("http.route"); ok && v.AsString() == "/api/users/{id}" {
+			sawRoute = true
+			if code, _ := dp.Attributes.Value("http.response.status_code"); code.AsInt64() != 200 {
+				t.Errorf("expected status 200, got %d", code.AsInt64())
+			}
+		}
+		if v, ok := dp.Attributes.Value(attribute.Key("error.type")); ok && v.AsString() == "502" {
+			sawError = true
+		}
+	}
+	if !sawRoute {
+		t.Error("expected http.route attribute with path template")
+	}
+	if !sawError {
+		t.Error("expected error.type attribute for 5xx response")
+	}
+
+	size, ok := metrics["http.server.response.body.size"].(metricdata.Histogram[int64])
+	if !ok {
+		t.Fatalf("expected body size histogram, got %T", metrics["http.server.response.body.size"])
+	}
+	var total int64
+	for _, dp := range size.DataPoints {
+		total += dp.Sum
+	}
+	if total != 4 {
+		t.Errorf("expected 4 body bytes, got %d", total)
+	}
+
+	active, ok := metrics["http.server.active_requests"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("expected active requests sum, got %T", metrics["http.server.active_requests"])
+	}
+	for _, dp := range active.DataPoints {
+		if dp.Value != 0 {
+			t.Errorf("expected no active requests, got %d", dp.Value)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"", ""},
+		{"/users", "/users"},
+		{"GET /users/{id}", "/users/{id}"},
+		{"GET example.com/users", "/users"},
+		{"example.com/", "/"},
+	}
+
+	for _, tt := range tests {
+		if got := route(tt.pattern); got != tt.expected {
+			t.Errorf("route(%q) = %q, expected %q", tt.pattern, got, tt.expected)
+		}
+	}
+}
+
+func TestMethod(t *testing.T) {
+	if got := method("PURGE"); got != "_OTHER" {
+		t.Errorf("expected _OTHER, got %q", got)
+	}
+	if got := method(http.MethodPatch); got != http.MethodPatch {
+		t.Errorf("expected PATCH, got %q", got)
+	}
+}
@@ -0,0 +1,141 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// errMissingParam is reported for path parameters with no value.
+var errMissingParam = errors.New("missing value")
+
+// ParamInt parses the named path parameter as a base-10 int. Errors are
+// *Error values with status 400 Bad Request.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    id, err := hmux.ParamInt(r, "id")
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusBadRequest)
+//	        return
+//	    }
+//	    ...
+//	})
+func ParamInt(r *http.Request, name string) (int, error) {
+	v, err := parseParam(r, name, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, strconv.IntSize)
+	})
+
+	return int(v), err
+}
+
+// ParamInt64 parses the named path parameter as a base-10 int64. Errors
+// are *Error values with status 400 Bad Request.
+func ParamInt64(r *http.Request, name string) (int64, error) {
+	return parseParam(r, name, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// ParamBool parses the named path parameter as a boolean, accepting the
+// values understood by strconv.ParseBool. Errors are *Error values with
+// status 400 Bad Request.
+func ParamBool(r *http.Request, name string) (bool, error) {
+	return parseParam(r, name, strconv.ParseBool)
+}
+
+// ParamUUID validates the named path parameter as a UUID in canonical
+// 8-4-4-4-12 hexadecimal form and returns it in lower case. Errors are
+// *Error values with status 400 Bad Request.
+func ParamUUID(r *http.Request, name string) (string, error) {
+	return parseParam(r, name, parseUUID)
+}
+
+// MustParamInt is like ParamInt but aborts the route on error, handing
+// the error to the Mux's error handler. It must only be called from
+// handlers registered with hmux.
+//
+// Example:
+//
+//	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    user := store.Get(hmux.MustParamInt(r, "id")) // 400 on /users/abc
+//	    ...
+//	})
+func MustParamInt(r *http.Request, name string) int {
+	return must(ParamInt(r, name))
+}
+
+// MustParamInt64 is like ParamInt64 but aborts the route on error; see
+// MustParamInt.
+func MustParamInt64(r *http.Request, name string) int64 {
+	return must(ParamInt64(r, name))
+}
+
+// MustParamBool is like ParamBool but aborts the route on error; see
+// MustParamInt.
+func MustParamBool(r *http.Request, name string) bool {
+	return must(ParamBool(r, name))
+}
+
+// MustParamUUID is like ParamUUID but aborts the route on error; see
+// MustParamInt.
+func MustParamUUID(r *http.Request, name string) string {
+	return must(ParamUUID(r, name))
+}
+
+// parseParam reads the named path parameter and converts it with parse,
+// reporting failures as 400 errors that name the parameter.
+func parseParam[T any](r *http.Request, name string, parse func(string) (T, error)) (T, error) {
+	s := r.PathValue(name)
+	if s == "" {
+		var zero T
+		return zero, Errorf(http.StatusBadRequest, "path parameter %q: %w", name, errMissingParam)
+	}
+
+	v, err := parse(s)
+	if err != nil {
+		return v, Errorf(http.StatusBadRequest, "path parameter %q: invalid value %q: %w", name, s, numError(err))
+	}
+
+	return v, nil
+}
+
+// must returns v, or aborts the route if err is non-nil.
+func must[T any](v T, err error) T {
+	if err != nil {
+		raise(err)
+	}
+
+	return v
+}
+
+// errInvalidUUID is reported for malformed UUIDs.
+var errInvalidUUID = errors.New("not a UUID")
+
+// parseUUID validates s as a canonical UUID and lower-cases it.
+func parseUUID(s string) (string, error) {
+	if len(s) != 36 {
+		return "", errInvalidUUID
+	}
+
+	b := []byte(s)
+	for i, c := range b {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return "", errInvalidUUID
+			}
+		default:
+			switch {
+			case c >= '0' && c <= '9', c >= 'a' && c <= 'f':
+			case c >= 'A' && c <= 'F':
+				b[i] = c + 'a' - 'A'
+			default:
+				return "", errInvalidUUID
+			}
+		}
+	}
+
+	return string(b), nil
+}
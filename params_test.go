@@ -0,0 +1,83 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamAccessors(t *testing.T) {
+	var (
+		i    int
+		i64  int64
+		b    bool
+		uuid string
+		err  error
+	)
+
+	m := New()
+	m.HandleFunc("GET /int/{v}", func(w http.ResponseWriter, r *http.Request) { i, err = ParamInt(r, "v") })
+	m.HandleFunc("GET /int64/{v}", func(w http.ResponseWriter, r *http.Request) { i64, err = ParamInt64(r, "v") })
+	m.HandleFunc("GET /bool/{v}", func(w http.ResponseWriter, r *http.Request) { b, err = ParamBool(r, "v") })
+	m.HandleFunc("GET /uuid/{v}", func(w http.ResponseWriter, r *http.Request) { uuid, err = ParamUUID(r, "v") })
+	m.HandleFunc("GET /missing", func(w http.ResponseWriter, r *http.Request) { i, err = ParamInt(r, "v") })
+
+	serve := func(path string) {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	serve("/int/-42")
+	if err != nil || i != -42 {
+		t.Errorf("expected -42, got %d, %v", i, err)
+	}
+
+	serve("/int64/9007199254740993")
+	if err != nil || i64 != 9007199254740993 {
+		t.Errorf("expected 9007199254740993, got %d, %v", i64, err)
+	}
+
+	serve("/bool/true")
+	if err != nil || !b {
+		t.Errorf("expected true, got %v, %v", b, err)
+	}
+
+	serve("/uuid/6BA7B810-9DAD-11D1-80B4-00C04FD430C8")
+	if err != nil || uuid != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("expected lower-case UUID, got %q, %v", uuid, err)
+	}
+
+	for _, path := range []string{"/int/abc", "/int64/1.5", "/bool/maybe", "/uuid/6ba7b810", "/missing"} {
+		serve(path)
+		if StatusCode(err) != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 error, got %v", path, err)
+		}
+	}
+}
+
+func TestMustParam(t *testing.T) {
+	m := New()
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := MustParamInt(r, "id")
+		w.Write([]byte{byte('0' + id)})
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/7", http.StatusOK, "7"},
+		{"/users/abc", http.StatusBadRequest, "path parameter \"id\": invalid value \"abc\": invalid syntax\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package pprofhmux mounts the net/http/pprof profiling endpoints on an
+// hmux Router. It is a separate package because importing net/http/pprof
+// registers the endpoints on http.DefaultServeMux as a side effect, which
+// programs importing hmux should not get unless they ask for it.
+//
+//	pprofhmux.Mount(mux, "/debug/pprof", adminOnly)
+package pprofhmux
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+// Mount registers the pprof endpoints under prefix on r, wrapped with mw,
+// which typically restricts access to operators:
+//
+//	GET  {prefix}/              index of the available profiles
+//	GET  {prefix}/cmdline       command line of the program
+//	GET  {prefix}/profile       CPU profile
+//	GET  {prefix}/symbol        symbol lookup, also POST
+//	GET  {prefix}/trace         execution trace
+//	GET  {prefix}/{name}        named profiles, such as heap and goroutine
+//
+// Unlike the handlers net/http/pprof registers on http.DefaultServeMux,
+// the endpoints work under any prefix.
+//
+// Mount panics if prefix does not start with "/".
+func Mount(r hmux.Router, prefix string, mw ...func(http.Handler) http.Handler) {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("hmux: pprof prefix must start with /")
+	}
+
+	g := r.Group(strings.TrimSuffix(prefix, "/"))
+	g.Use(mw...)
+
+	g.HandleFunc("GET /{$}", pprof.Index)
+	g.HandleFunc("GET /cmdline", pprof.Cmdline)
+	g.HandleFunc("GET /profile", pprof.Profile)
+	g.HandleFunc("GET /symbol", pprof.Symbol)
+	g.HandleFunc("POST /symbol", pprof.Symbol)
+	g.HandleFunc("GET /trace", pprof.Trace)
+	g.HandleFunc("GET /{name}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(r.PathValue("name")).ServeHTTP(w, r)
+	})
+}
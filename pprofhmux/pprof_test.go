@@ -0,0 +1,48 @@
+package pprofhmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestMount(t *testing.T) {
+	var guarded int
+	guard := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			guarded++
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	m := hmux.New()
+	Mount(m, "/admin/pprof/", guard)
+
+	tests := []struct {
+		path     string
+		code     int
+		contains string
+	}{
+		{"/admin/pprof/", http.StatusOK, "goroutine"},
+		{"/admin/pprof/cmdline", http.StatusOK, ""},
+		{"/admin/pprof/goroutine?debug=1", http.StatusOK, "goroutine profile"},
+		{"/admin/pprof/unknown", http.StatusNotFound, "Unknown profile"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.code, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%s: expected body containing %q", tt.path, tt.contains)
+		}
+	}
+
+	if guarded != len(tests) {
+		t.Errorf("expected middleware on %d requests, got %d", len(tests), guarded)
+	}
+}
@@ -0,0 +1,231 @@
+// Package problem writes error responses as RFC 9457 problem details,
+// the application/problem+json format that supersedes RFC 7807:
+//
+//	mux.ErrorHandler(problem.ErrorHandler)
+//
+//	mux.HandleFunc("POST /transfers", func(w http.ResponseWriter, r *http.Request) {
+//	    if balance < amount {
+//	        problem.Write(w, problem.New(http.StatusForbidden, "Your balance is 30, but the transfer costs 50.").
+//	            WithType("https://example.com/probs/out-of-credit").
+//	            With("balance", 30))
+//	        return
+//	    }
+//	    ...
+//	})
+//
+// As an hmux error handler, ErrorHandler renders every error raised by a
+// route, mapping typed errors to problem documents: a *Problem in the
+// error chain is written as is, an error implementing Provider supplies
+// its own, and an *hmux.Error becomes a problem with its status code.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+
+	"github.com/nikita-shtimenko/hmux"
+	"github.com/nikita-shtimenko/hmux/render"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// Problem is a problem details document. It is also an error, so it can
+// be returned or raised and rendered by ErrorHandler.
+type Problem struct {
+	// Type is a URI identifying the problem type. Empty means
+	// "about:blank", a problem described by its status code alone.
+	Type string
+
+	// Title is a short summary of the problem type. For problems without
+	// a Type, it defaults to the status text when the problem is written.
+	Title string
+
+	// Status is the HTTP status code. It defaults to 500 when the problem
+	// is written.
+	Status int
+
+	// Detail explains this occurrence of the problem to the client.
+	Detail string
+
+	// Instance is a URI identifying this occurrence of the problem.
+	Instance string
+
+	// Extensions holds additional members, such as a list of invalid
+	// fields. They cannot override the members above.
+	Extensions map[string]any
+}
+
+// New returns a Problem with the given status code and detail.
+func New(status int, detail string) *Problem {
+	return &Problem{Status: status, Detail: detail}
+}
+
+// WithType sets the problem type URI and title and returns p.
+func (p *Problem) WithType(uri string, title ...string) *Problem {
+	p.Type = uri
+	if len(title) > 0 {
+		p.Title = title[0]
+	}
+
+	return p
+}
+
+// With sets the extension member key to value and returns p.
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+
+	return p
+}
+
+// Error returns the detail of the problem, or its title or status text
+// if it has none.
+func (p *Problem) Error() string {
+	switch {
+	case p.Detail != "":
+		return p.Detail
+	case p.Title != "":
+		return p.Title
+	}
+
+	return http.StatusText(p.status())
+}
+
+// MarshalJSON encodes p as a problem document, with the extension
+// members alongside the standard ones.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	doc := maps.Clone(p.Extensions)
+	if doc == nil {
+		doc = make(map[string]any, 5)
+	}
+	for _, member := range standardMembers {
+		delete(doc, member)
+	}
+
+	if p.Type != "" {
+		doc["type"] = p.Type
+	}
+	if p.Title != "" {
+		doc["title"] = p.Title
+	}
+	if p.Status != 0 {
+		doc["status"] = p.Status
+	}
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a problem document, such as one received by a
+// client, keeping unknown members in Extensions. Standard members of the
+// wrong type are ignored, as RFC 9457 requires.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	*p = Problem{}
+	p.Type, _ = doc["type"].(string)
+	p.Title, _ = doc["title"].(string)
+	if status, ok := doc["status"].(float64); ok {
+		p.Status = int(status)
+	}
+	p.Detail, _ = doc["detail"].(string)
+	p.Instance, _ = doc["instance"].(string)
+
+	for _, member := range standardMembers {
+		delete(doc, member)
+	}
+	if len(doc) > 0 {
+		p.Extensions = doc
+	}
+
+	return nil
+}
+
+// standardMembers are the members defined by RFC 9457.
+var standardMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// status returns the status code of p, or 500 if it has none.
+func (p *Problem) status() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return p.Status
+}
+
+// Provider is implemented by errors that describe themselves as problem
+// documents, so ErrorHandler renders them with their own type and
+// extension members:
+//
+//	func (e *ValidationError) Problem() *problem.Problem {
+//	    return problem.New(http.StatusUnprocessableEntity, e.Error()).
+//	        WithType("https://example.com/probs/validation", "Invalid request").
+//	        With("errors", e.Fields)
+//	}
+type Provider interface {
+	Problem() *Problem
+}
+
+// From returns the problem document describing err: the first *Problem
+// in its chain, the Problem of the first Provider, or a problem with the
+// status code reported by hmux.StatusCode. The message of such an error
+// becomes the detail for 4xx codes only, so internal details of server
+// errors are not leaked to clients.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var provider Provider
+	if errors.As(err, &provider) {
+		if p := provider.Problem(); p != nil {
+			return p
+		}
+	}
+
+	p = &Problem{Status: hmux.StatusCode(err)}
+	if p.Status < http.StatusInternalServerError {
+		p.Detail = err.Error()
+	}
+
+	return p
+}
+
+// Write writes p as an application/problem+json response with its status
+// code. If p cannot be encoded, Write responds with 500 Internal Server
+// Error and returns the encoding error.
+func Write(w http.ResponseWriter, p *Problem) error {
+	out := *p
+	out.Status = p.status()
+	if out.Title == "" && out.Type == "" {
+		out.Title = http.StatusText(out.Status)
+	}
+
+	body, err := json.Marshal(&out)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	return render.Blob(w, out.Status, ContentType, append(body, '\n'))
+}
+
+// ErrorHandler is an hmux.ErrorHandlerFunc writing errors as problem
+// documents, as described by From.
+func ErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	_ = Write(w, From(err))
+}
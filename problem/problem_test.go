@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+type validationError struct {
+	fields []string
+}
+
+func (e *validationError) Error() string { return "invalid request" }
+
+func (e *validationError) Problem() *Problem {
+	return New(http.StatusUnprocessableEntity, "2 fields are invalid").
+		WithType("https://example.com/probs/validation", "Invalid request").
+		With("fields", e.fields)
+}
+
+func TestErrorHandler(t *testing.T) {
+	m := hmux.New()
+	m.ErrorHandler(ErrorHandler)
+	m.HandleFunc("GET /param", func(w http.ResponseWriter, r *http.Request) {
+		hmux.MustParamInt(r, "missing") // raises a 400
+	})
+	m.HandleFunc("GET /write", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, New(http.StatusForbidden, "Your balance is 30, but the transfer costs 50.").
+			WithType("https://example.com/probs/out-of-credit", "You do not have enough credit.").
+			With("balance", 30).
+			With("status", "ignored"))
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/write", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("expected Content-Type %q, got %q", ContentType, ct)
+	}
+	expected := map[string]any{
+		"type":    "https://example.com/probs/out-of-credit",
+		"title":   "You do not have enough credit.",
+		"status":  float64(403),
+		"detail":  "Your balance is 30, but the transfer costs 50.",
+		"balance": float64(30),
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusForbidden || !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected 403 %v, got %d %v", expected, rec.Code, got)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/param", nil))
+
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Detail == "" {
+		t.Errorf("expected a 400 problem with a detail, got %d %+v", rec.Code, p)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected *Problem
+	}{
+		{"problem", fmt.Errorf("wrapped: %w", New(http.StatusConflict, "taken")), New(http.StatusConflict, "taken")},
+		{"provider", &validationError{fields: []string{"name"}}, (&validationError{fields: []string{"name"}}).Problem()},
+		{"hmux error", hmux.Errorf(http.StatusNotFound, "no user 7"), New(http.StatusNotFound, "no user 7")},
+		{"server error", hmux.Errorf(http.StatusBadGateway, "upstream at 10.0.0.1 down"), &Problem{Status: http.StatusBadGateway}},
+		{"plain error", errors.New("db password wrong"), &Problem{Status: http.StatusInternalServerError}},
+	}
+	for _, tt := range tests {
+		if got := From(tt.err); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestProblem_JSON(t *testing.T) {
+	data := []byte(`{"type":"https://example.com/probs/x","status":"bad","detail":"d","trace":"abc"}`)
+
+	var p Problem
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	expected := Problem{Type: "https://example.com/probs/x", Detail: "d", Extensions: map[string]any{"trace": "abc"}}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("expected %+v, got %+v", expected, p)
+	}
+
+	if p.Error() != "d" || (&Problem{Status: 404}).Error() != "Not Found" {
+		t.Errorf("unexpected error messages %q and %q", p.Error(), (&Problem{Status: 404}).Error())
+	}
+}
@@ -0,0 +1,151 @@
+package hmux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+var (
+	handlerType     = reflect.TypeFor[http.Handler]()
+	handlerFuncType = reflect.TypeFor[func(http.ResponseWriter, *http.Request)]()
+	errorType       = reflect.TypeFor[error]()
+)
+
+// Provide registers dependencies, such as a database handle or a logger,
+// for the constructors of handlers registered with HandleCtor. Each value
+// is provided under its dynamic type; constructors taking an interface
+// receive the one provided value implementing it. Host and Version
+// routers see the dependencies of their Mux.
+//
+// Provide panics if a value is nil or a value of its type is already
+// provided.
+func (m *Mux) Provide(deps ...any) {
+	defer m.lock()()
+
+	for _, dep := range deps {
+		if dep == nil {
+			panic("hmux: nil dependency passed to Provide")
+		}
+
+		v := reflect.ValueOf(dep)
+		if _, ok := m.providers[v.Type()]; ok {
+			panic(fmt.Sprintf("hmux: dependency of type %s already provided", v.Type()))
+		}
+		if m.providers == nil {
+			m.providers = make(map[reflect.Type]reflect.Value)
+		}
+		m.providers[v.Type()] = v
+	}
+}
+
+// HandleCtor registers the handler built by ctor for pattern, like
+// Handle. ctor is a constructor whose parameters are dependencies
+// registered with Provide, and which returns an http.Handler or a
+// handler function, optionally followed by an error:
+//
+//	func NewUsersHandler(db *sql.DB, log *slog.Logger) http.Handler { ... }
+//
+//	mux.Provide(db, logger)
+//	mux.HandleCtor("GET /users", NewUsersHandler)
+//
+// ctor is called once, at registration, so wiring mistakes surface at
+// startup and tests can provide fakes instead of the real dependencies.
+//
+// HandleCtor panics if ctor is not such a function, a dependency is not
+// provided, ctor returns an error, or pattern cannot be registered.
+func (m *Mux) HandleCtor(pattern string, ctor any) {
+	m.Handle(pattern, m.construct(pattern, ctor))
+}
+
+// HandleCtor registers the handler built by ctor for pattern on this
+// group, with dependencies provided to the group's Mux. See
+// Mux.HandleCtor.
+func (g *Group) HandleCtor(pattern string, ctor any) {
+	g.Handle(pattern, g.mux.construct(pattern, ctor))
+}
+
+// construct calls ctor with its dependencies and returns the handler it
+// builds.
+func (m *Mux) construct(pattern string, ctor any) http.Handler {
+	fn := reflect.ValueOf(ctor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		panic(fmt.Sprintf("hmux: constructor for %q is not a function", pattern))
+	}
+	t := fn.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) ||
+		(!t.Out(0).Implements(handlerType) && !t.Out(0).ConvertibleTo(handlerFuncType)) {
+		panic(fmt.Sprintf("hmux: constructor for %q must return a handler and optionally an error, not %s", pattern, t))
+	}
+
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		dep, err := m.dependency(t.In(i))
+		if err != nil {
+			panic(fmt.Sprintf("hmux: constructor for %q: %v", pattern, err))
+		}
+		args[i] = dep
+	}
+
+	out := fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		panic(fmt.Sprintf("hmux: constructor for %q: %v", pattern, out[1].Interface()))
+	}
+
+	if t.Out(0).Implements(handlerType) {
+		h, _ := out[0].Interface().(http.Handler)
+		if h == nil {
+			panic(fmt.Sprintf("hmux: constructor for %q returned a nil handler", pattern))
+		}
+		return h
+	}
+
+	f := out[0].Convert(handlerFuncType).Interface().(func(http.ResponseWriter, *http.Request))
+	if f == nil {
+		panic(fmt.Sprintf("hmux: constructor for %q returned a nil handler", pattern))
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// dependency returns the provided value of type t, looking it up on the
+// Mux and then on its parents.
+func (m *Mux) dependency(t reflect.Type) (reflect.Value, error) {
+	for mux := m; mux != nil; mux = mux.parent {
+		v, err := mux.provided(t)
+		if err != nil || v.IsValid() {
+			return v, err
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("no dependency of type %s provided", t)
+}
+
+// provided returns the value of type t provided to the Mux, if any. An
+// interface type matches the one value implementing it.
+func (m *Mux) provided(t reflect.Type) (reflect.Value, error) {
+	defer m.lock()()
+
+	if v, ok := m.providers[t]; ok {
+		return v, nil
+	}
+	if t.Kind() != reflect.Interface {
+		return reflect.Value{}, nil
+	}
+
+	var found reflect.Value
+	for pt, v := range m.providers {
+		if !pt.Implements(t) {
+			continue
+		}
+		if found.IsValid() {
+			return reflect.Value{}, fmt.Errorf("several dependencies implement %s", t)
+		}
+		found = v
+	}
+	if found.IsValid() {
+		return found.Convert(t), nil
+	}
+
+	return reflect.Value{}, nil
+}
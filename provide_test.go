@@ -0,0 +1,103 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type english struct{}
+
+func (english) Greet(name string) string { return "hello " + name }
+
+type store struct {
+	users []string
+}
+
+func TestHandleCtor(t *testing.T) {
+	m := New()
+	m.Provide(&store{users: []string{"ada", "grace"}}, english{})
+
+	m.HandleCtor("GET /users", func(s *store, g greeter) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, g.Greet(strings.Join(s.users, ",")))
+		})
+	})
+	m.Group("/api").(*Group).HandleCtor("GET /count", func(s *store) (http.HandlerFunc, error) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, len(s.users))
+		}, nil
+	})
+	m.Version("2024-06").(*Group).HandleCtor("GET /v", func(s *store) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "v", len(s.users))
+		}
+	})
+
+	tests := []struct {
+		path, version, body string
+	}{
+		{"/users", "", "hello ada,grace"},
+		{"/api/count", "", "2"},
+		{"/v", "2024-06", "v2"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.version != "" {
+			req.Header.Set(VersionHeader, tt.version)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+	}
+}
+
+type french struct{}
+
+func (french) Greet(name string) string { return "bonjour " + name }
+
+func TestHandleCtor_Panics(t *testing.T) {
+	m := New()
+	m.Provide(english{}, french{})
+
+	tests := []struct {
+		name string
+		ctor any
+		msg  string
+	}{
+		{"nil", nil, "not a function"},
+		{"not a function", 42, "not a function"},
+		{"no handler", func() string { return "" }, "must return a handler"},
+		{"missing dependency", func(*store) http.Handler { return nil }, "no dependency of type *hmux.store"},
+		{"ambiguous interface", func(greeter) http.Handler { return nil }, "several dependencies implement hmux.greeter"},
+		{"error", func() (http.Handler, error) { return nil, errors.New("bad config") }, "bad config"},
+		{"nil handler", func() http.Handler { return nil }, "nil handler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), tt.msg) {
+					t.Errorf("expected panic containing %q, got %v", tt.msg, r)
+				}
+			}()
+			m.HandleCtor("GET /x", tt.ctor)
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a duplicate dependency")
+		}
+	}()
+	m.Provide(english{})
+}
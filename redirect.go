@@ -0,0 +1,186 @@
+package hmux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// maxAliasHops bounds how many aliases a request may pass through, so
+// aliases pointing at each other fail instead of looping.
+const maxAliasHops = 8
+
+var aliasHopsKey = &contextKey{"alias-hops"}
+
+// Redirect registers a route redirecting requests matching pattern to
+// target with the given status code, so legacy URLs keep working:
+//
+//	mux.Redirect("GET /blog/{slug}", "/posts/{slug}", http.StatusMovedPermanently)
+//	mux.Redirect("GET /invoice/{id}", "invoice", http.StatusMovedPermanently)
+//
+// target is a path, an absolute URL, or the name of a route, as set
+// with the RouteName metadata key. Wildcards in a target path or in the
+// pattern of a named route are filled with the values of the same
+// wildcards of the request, and the query string of the request is kept
+// unless target has its own. A request whose wildcards would make a
+// target path start with "//" gets 400 Bad Request rather than a
+// redirect to another host. The redirect runs through the Mux's
+// middleware.
+//
+// Redirect panics if code is not a 3xx status or pattern cannot be
+// registered.
+func (m *Mux) Redirect(pattern, target string, code int) {
+	if code < 300 || code > 399 {
+		panic(fmt.Sprintf("hmux: invalid redirect code %d", code))
+	}
+
+	t := newRedirectTarget(target)
+	m.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		u, err := m.resolveTarget(t, r)
+		if err != nil {
+			raise(err)
+		}
+
+		http.Redirect(w, r, u, code)
+	})
+}
+
+// Alias registers pattern as another path of target, a path or the name
+// of a route: requests matching pattern are served as if they were for
+// target, without a redirect. Wildcards and the query string are carried
+// over as for Redirect:
+//
+//	mux.Alias("GET /u/{id}", "user") // serves GET /users/{id}
+//
+// The request runs through the middleware of the target route only, and
+// r.URL.Path holds the target path. Aliases may point at other aliases,
+// up to eight hops.
+//
+// Alias panics if pattern cannot be registered.
+func (m *Mux) Alias(pattern, target string) {
+	t := newRedirectTarget(target)
+	m.GroupDetached("").HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		hops, _ := r.Context().Value(aliasHopsKey).(int)
+		if hops >= maxAliasHops {
+			raise(Errorf(http.StatusLoopDetected, "alias %q loops", pattern))
+		}
+
+		u, err := m.resolveTarget(t, r)
+		if err != nil {
+			raise(err)
+		}
+		path, query, _ := strings.Cut(u, "?")
+
+		r2 := r.Clone(context.WithValue(r.Context(), aliasHopsKey, hops+1))
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		r2.URL.RawQuery = query
+		r2.RequestURI = r2.URL.RequestURI()
+		r2.Pattern = ""
+
+		m.serve(w, r2)
+	})
+}
+
+// redirectTarget is the target of a Redirect or Alias, with the route
+// pattern it resolves to cached so that requests do not scan Routes.
+type redirectTarget struct {
+	target   string
+	resolved atomic.Pointer[resolvedTarget]
+}
+
+// resolvedTarget is the pattern a target resolves to and its wildcards.
+type resolvedTarget struct {
+	// mux is the route table a named target was looked up in, so the
+	// lookup is repeated once Unhandle or Reload replaces it. It is nil
+	// for path targets, which never change.
+	mux       *http.ServeMux
+	pattern   string
+	wildcards []string
+}
+
+func newRedirectTarget(target string) *redirectTarget {
+	t := &redirectTarget{target: target}
+	if strings.HasPrefix(target, "/") {
+		t.resolved.Store(&resolvedTarget{pattern: target, wildcards: wildcardNames(target)})
+	}
+
+	return t
+}
+
+// resolve returns the pattern t refers to. A route name is looked up on
+// the first request rather than at registration, so the named route may
+// be registered after the Redirect or Alias.
+func (m *Mux) resolve(t *redirectTarget) (*resolvedTarget, error) {
+	mux := m.mux.Load()
+	if rt := t.resolved.Load(); rt != nil && (rt.mux == nil || rt.mux == mux) {
+		return rt, nil
+	}
+
+	for _, rt := range m.Routes() {
+		if n, _ := rt.Meta[RouteName].(string); n == t.target {
+			resolved := &resolvedTarget{mux: mux, pattern: rt.Pattern, wildcards: wildcardNames(rt.Pattern)}
+			t.resolved.Store(resolved)
+
+			return resolved, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrRouteNotFound, t.target)
+}
+
+// resolveTarget returns the URL a request for Redirect or Alias is sent
+// to.
+func (m *Mux) resolveTarget(t *redirectTarget, r *http.Request) (string, error) {
+	u := t.target
+	if !strings.Contains(u, "://") {
+		resolved, err := m.resolve(t)
+		if err != nil {
+			return "", err
+		}
+
+		var params []string
+		for _, name := range resolved.wildcards {
+			params = append(params, name, r.PathValue(name))
+		}
+
+		if u, err = buildPath(resolved.pattern, params); err != nil {
+			return "", err
+		}
+
+		// A remainder wildcard may hold slashes, which must not turn the
+		// path into a scheme-relative URL such as "//evil.com".
+		if strings.HasPrefix(u, "//") || strings.HasPrefix(u, "/\\") {
+			return "", Errorf(http.StatusBadRequest, "target %q is not a local path", u)
+		}
+	}
+
+	if r.URL.RawQuery != "" && !strings.Contains(u, "?") {
+		u += "?" + r.URL.RawQuery
+	}
+
+	return u, nil
+}
+
+// wildcardNames returns the names of the wildcards of pattern.
+func wildcardNames(pattern string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return names
+		}
+
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		if name != "$" {
+			names = append(names, name)
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
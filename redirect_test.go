@@ -0,0 +1,119 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	m := New()
+	m.Meta(M{RouteName: "post"}).HandleFunc("GET /posts/{slug}", func(w http.ResponseWriter, r *http.Request) {})
+	m.Redirect("GET /blog/{slug}", "/posts/{slug}", http.StatusMovedPermanently)
+	m.Redirect("GET /articles/{slug}", "post", http.StatusFound)
+	m.Redirect("GET /docs/", "https://docs.example.com/", http.StatusPermanentRedirect)
+	m.Redirect("GET /broken", "missing", http.StatusFound)
+	m.Redirect("GET /x/{p...}", "/{p...}", http.StatusMovedPermanently)
+
+	tests := []struct {
+		target   string
+		code     int
+		location string
+	}{
+		{"/blog/hello%20world", http.StatusMovedPermanently, "/posts/hello%20world"},
+		{"/blog/hi?ref=feed", http.StatusMovedPermanently, "/posts/hi?ref=feed"},
+		{"/articles/hi", http.StatusFound, "/posts/hi"},
+		{"/docs/", http.StatusPermanentRedirect, "https://docs.example.com/"},
+		{"/broken", http.StatusInternalServerError, ""},
+		{"/x/a/b", http.StatusMovedPermanently, "/a/b"},
+		{"/x/%2Fevil.com", http.StatusBadRequest, ""},
+		{"/x/%5Cevil.com", http.StatusMovedPermanently, "/%5Cevil.com"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != tt.code || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.target, tt.code, tt.location, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a non-redirect code")
+		}
+	}()
+	m.Redirect("GET /x", "/y", http.StatusOK)
+}
+
+func TestRedirect_NamedTargetChanges(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	m := New()
+	m.Redirect("GET /u/{id}", "user", http.StatusFound)
+	m.Meta(M{RouteName: "user"}).HandleFunc("GET /users/{id}", noop)
+
+	location := func() string {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/u/7", nil))
+		return rec.Header().Get("Location")
+	}
+
+	if got := location(); got != "/users/7" {
+		t.Errorf("expected target registered after the redirect, got %q", got)
+	}
+
+	m.Unhandle("GET /users/{id}")
+	m.Meta(M{RouteName: "user"}).HandleFunc("GET /people/{id}", noop)
+	if got := location(); got != "/people/7" {
+		t.Errorf("expected the target to follow the route table, got %q", got)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	m := New()
+	var record []string
+	m.Use(recordingMiddleware("mw", &record))
+	m.Meta(M{RouteName: "user"}).HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.PathValue("id") + " " + r.URL.Query().Get("tab")))
+	})
+	m.Alias("GET /u/{id}", "user")
+	m.Alias("GET /me", "/users/self")
+	m.Alias("GET /profile", "/me")
+	m.Alias("GET /loop-a", "/loop-b")
+	m.Alias("GET /loop-b", "/loop-a")
+	m.Alias("GET /a/{p...}", "/{p...}")
+
+	tests := []struct {
+		target string
+		code   int
+		body   string
+	}{
+		{"/u/7?tab=posts", http.StatusOK, "/users/7 7 posts"},
+		{"/me", http.StatusOK, "/users/self self "},
+		{"/profile", http.StatusOK, "/users/self self "},
+	}
+	for _, tt := range tests {
+		record = nil
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.target, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+		if len(record) != 2 {
+			t.Errorf("%s: expected the target's middleware to run once, got %v", tt.target, record)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loop-a", nil))
+	if rec.Code != http.StatusLoopDetected {
+		t.Errorf("expected 508 for an alias loop, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/a/%2Fevil.com", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an alias to a non-local path, got %d", rec.Code)
+	}
+}
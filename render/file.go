@@ -0,0 +1,111 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+)
+
+// File writes the file name of fsys, such as a generated export or an
+// uploaded document, in response to r. The Content-Type is detected from
+// the file extension or, for files that can seek, from the contents:
+//
+//	mux.HandleFunc("GET /exports/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    render.File(w, r, exports, r.PathValue("id")+".csv")
+//	})
+//
+// Files that can seek, as those of os.DirFS and embed.FS can, are served
+// with http.ServeContent, which answers Range requests, so downloads can
+// resume, and conditional requests with 304 Not Modified. They get a
+// Last-Modified header and an ETag derived from their size and
+// modification time, unless the handler set one. Other files are
+// streamed as is.
+//
+// If the file does not exist or is a directory, File responds with 404
+// Not Found, and with 403 Forbidden if it cannot be read for lack of
+// permission; File returns the error in these cases and when the file
+// cannot be read.
+func File(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		fileError(w, err)
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		fileError(w, err)
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		err := fmt.Errorf("render: %s is not a regular file: %w", name, fs.ErrNotExist)
+		fileError(w, err)
+		return err
+	}
+
+	h := w.Header()
+	if h.Get("ETag") == "" && !info.ModTime().IsZero() {
+		h.Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().Unix(), info.Size()))
+	}
+
+	if content, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+		return nil
+	}
+
+	if !info.ModTime().IsZero() {
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !info.ModTime().Truncate(time.Second).After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+		h.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return Stream(w, http.StatusOK, contentType, f)
+}
+
+// Attachment is like File but sets a Content-Disposition header that
+// makes browsers download the file and save it as filename, or under its
+// own name if filename is empty:
+//
+//	render.Attachment(w, r, exports, "2024-06.csv", "Invoices June 2024.csv")
+//
+// Non-ASCII file names are encoded as described in RFC 6266.
+func Attachment(w http.ResponseWriter, r *http.Request, fsys fs.FS, name, filename string) error {
+	if filename == "" {
+		filename = path.Base(name)
+	}
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+
+	return File(w, r, fsys, name)
+}
+
+// fileError responds to a failure to open or stat a file. The response
+// is not downloaded as an attachment.
+func fileError(w http.ResponseWriter, err error) {
+	w.Header().Del("Content-Disposition")
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
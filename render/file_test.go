@@ -0,0 +1,96 @@
+package render
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// streamFS opens files that cannot seek.
+type streamFS struct {
+	fstest.MapFS
+}
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestFile(t *testing.T) {
+	modTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	files := fstest.MapFS{
+		"report.csv": {Data: []byte("id,total\n1,30\n"), ModTime: modTime},
+		"blob":       {Data: []byte("%PDF-1.7 ..."), ModTime: modTime},
+		"dir/a.txt":  {Data: []byte("a")},
+	}
+	etag := `"665b0d40-e"`
+
+	tests := []struct {
+		name        string
+		fsys        fs.FS
+		file        string
+		header      map[string]string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"full", files, "report.csv", nil, http.StatusOK, "text/csv; charset=utf-8", "id,total\n1,30\n"},
+		{"sniffed", files, "blob", nil, http.StatusOK, "application/pdf", "%PDF-1.7 ..."},
+		{"range", files, "report.csv", map[string]string{"Range": "bytes=0-1"}, http.StatusPartialContent, "text/csv; charset=utf-8", "id"},
+		{"if-none-match", files, "report.csv", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "", ""},
+		{"if-modified-since", files, "report.csv", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, http.StatusNotModified, "", ""},
+		{"missing", files, "nope.csv", nil, http.StatusNotFound, "text/plain; charset=utf-8", "Not Found\n"},
+		{"directory", files, "dir", nil, http.StatusNotFound, "text/plain; charset=utf-8", "Not Found\n"},
+		{"stream", streamFS{files}, "report.csv", nil, http.StatusOK, "text/csv; charset=utf-8", "id,total\n1,30\n"},
+		{"stream not modified", streamFS{files}, "report.csv", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, http.StatusNotModified, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			err := File(rec, req, tt.fsys, tt.file)
+
+			if rec.Code != tt.code || rec.Header().Get("Content-Type") != tt.contentType || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q %q, got %d %q %q", tt.code, tt.contentType, tt.body, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+			}
+			if (tt.code == http.StatusNotFound) != errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.code == http.StatusOK && tt.file == "report.csv" && rec.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %q", etag, rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	files := fstest.MapFS{"2024-06.csv": {Data: []byte("id\n")}}
+
+	tests := []struct {
+		file, filename string
+		disposition    string
+	}{
+		{"2024-06.csv", "", `attachment; filename=2024-06.csv`},
+		{"2024-06.csv", "Invoices June.csv", `attachment; filename="Invoices June.csv"`},
+		{"2024-06.csv", "Rechnungen Mär.csv", `attachment; filename*=utf-8''Rechnungen%20M%C3%A4r.csv`},
+		{"missing.csv", "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Attachment(rec, httptest.NewRequest(http.MethodGet, "/", nil), files, tt.file, tt.filename)
+
+		if got := rec.Header().Get("Content-Disposition"); got != tt.disposition {
+			t.Errorf("%s %q: expected %q, got %q", tt.file, tt.filename, tt.disposition, got)
+		}
+	}
+}
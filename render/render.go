@@ -0,0 +1,121 @@
+// Package render provides helpers for writing HTTP responses. Each helper
+// sets the Content-Type, writes the status code and serializes the body:
+//
+//	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    user, err := store.Get(r.PathValue("id"))
+//	    if err != nil {
+//	        render.Text(w, http.StatusNotFound, "user not found")
+//	        return
+//	    }
+//	    render.JSON(w, http.StatusOK, user)
+//	})
+//
+// JSON and XML values are encoded into a buffer before anything is
+// written, so an encoding error results in a clean 500 Internal Server
+// Error instead of a truncated body with a success status.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+var pretty atomic.Bool
+
+// SetPretty turns indentation of JSON and XML output on or off. It is
+// off by default and is typically enabled in development:
+//
+//	render.SetPretty(os.Getenv("APP_ENV") == "dev")
+func SetPretty(on bool) {
+	pretty.Store(on)
+}
+
+// JSON writes v encoded as JSON with the given status code and an
+// application/json Content-Type. HTML characters are not escaped. If v
+// cannot be encoded, JSON responds with 500 Internal Server Error and
+// returns the encoding error.
+func JSON(w http.ResponseWriter, status int, v any) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if pretty.Load() {
+		enc.SetIndent("", "  ")
+	}
+
+	if err := enc.Encode(v); err != nil {
+		encodeError(w)
+		return err
+	}
+
+	return Blob(w, status, "application/json; charset=utf-8", buf.Bytes())
+}
+
+// XML writes v encoded as XML, preceded by the standard XML header, with
+// the given status code and an application/xml Content-Type. If v cannot
+// be encoded, XML responds with 500 Internal Server Error and returns the
+// encoding error.
+func XML(w http.ResponseWriter, status int, v any) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	if pretty.Load() {
+		enc.Indent("", "  ")
+	}
+
+	if err := enc.Encode(v); err != nil {
+		encodeError(w)
+		return err
+	}
+	buf.WriteByte('\n')
+
+	return Blob(w, status, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+// Text writes s with the given status code and a text/plain Content-Type.
+func Text(w http.ResponseWriter, status int, s string) error {
+	return Blob(w, status, "text/plain; charset=utf-8", []byte(s))
+}
+
+// Blob writes b with the given status code and Content-Type, and sets
+// Content-Length. It returns any error from writing the body.
+func Blob(w http.ResponseWriter, status int, contentType string, b []byte) error {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+
+	_, err := w.Write(b)
+
+	return err
+}
+
+// Stream copies r to the response with the given status code and
+// Content-Type. The length is not known in advance, so the response is
+// sent chunked unless it is small enough to be buffered by the server.
+// Stream returns any error from reading r or writing the body; by then
+// the status code has been sent.
+func Stream(w http.ResponseWriter, status int, contentType string, r io.Reader) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	_, err := io.Copy(w, r)
+
+	return err
+}
+
+// NoContent writes a 204 No Content response.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// encodeError responds with a plain 500 after an encoding failure.
+func encodeError(w http.ResponseWriter) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
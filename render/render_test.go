@@ -0,0 +1,116 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type user struct {
+	ID   int    `json:"id" xml:"id,attr"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		fn          func(w http.ResponseWriter) error
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			"json",
+			func(w http.ResponseWriter) error { return JSON(w, http.StatusCreated, user{1, "<ann>"}) },
+			http.StatusCreated, "application/json; charset=utf-8", "{\"id\":1,\"name\":\"<ann>\"}\n",
+		},
+		{
+			"xml",
+			func(w http.ResponseWriter) error { return XML(w, http.StatusOK, user{1, "ann"}) },
+			http.StatusOK, "application/xml; charset=utf-8",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<user id=\"1\"><name>ann</name></user>\n",
+		},
+		{
+			"text",
+			func(w http.ResponseWriter) error { return Text(w, http.StatusAccepted, "queued") },
+			http.StatusAccepted, "text/plain; charset=utf-8", "queued",
+		},
+		{
+			"blob",
+			func(w http.ResponseWriter) error { return Blob(w, http.StatusOK, "image/png", []byte{0x89, 'P'}) },
+			http.StatusOK, "image/png", "\x89P",
+		},
+		{
+			"stream",
+			func(w http.ResponseWriter) error {
+				return Stream(w, http.StatusOK, "text/csv", strings.NewReader("a,b\n"))
+			},
+			http.StatusOK, "text/csv", "a,b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := tt.fn(rec); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.contentType, ct)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJSON_EncodeError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := JSON(rec, http.StatusOK, map[string]any{"ch": make(chan int)})
+
+	if err == nil {
+		t.Fatal("expected encoding error")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestJSON_Pretty(t *testing.T) {
+	SetPretty(true)
+	defer SetPretty(false)
+
+	rec := httptest.NewRecorder()
+	JSON(rec, http.StatusOK, user{1, "ann"})
+
+	expected := "{\n  \"id\": 1,\n  \"name\": \"ann\"\n}\n"
+	if rec.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, rec.Body.String())
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestStream_ReadError(t *testing.T) {
+	if err := Stream(httptest.NewRecorder(), http.StatusOK, "text/plain", failingReader{}); err == nil {
+		t.Error("expected read error")
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NoContent(rec)
+
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("expected empty 204, got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,105 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ResourceID is the name of the path wildcard holding the resource ID in
+// routes registered by Resource, read with r.PathValue(hmux.ResourceID).
+const ResourceID = "id"
+
+// Controller methods recognized by Resource. A controller implements any
+// subset of them.
+type (
+	// Indexer lists a collection: GET /prefix.
+	Indexer interface {
+		Index(w http.ResponseWriter, r *http.Request)
+	}
+
+	// Creator creates a member of a collection: POST /prefix.
+	Creator interface {
+		Create(w http.ResponseWriter, r *http.Request)
+	}
+
+	// Shower shows a single member: GET /prefix/{id}.
+	Shower interface {
+		Show(w http.ResponseWriter, r *http.Request)
+	}
+
+	// Updater updates a single member: PUT and PATCH /prefix/{id}.
+	Updater interface {
+		Update(w http.ResponseWriter, r *http.Request)
+	}
+
+	// Deleter deletes a single member: DELETE /prefix/{id}.
+	Deleter interface {
+		Delete(w http.ResponseWriter, r *http.Request)
+	}
+)
+
+// Resource registers the conventional routes for a RESTful resource at
+// prefix, for each controller method that controller implements:
+//
+//	GET    /users       → Index
+//	POST   /users       → Create
+//	GET    /users/{id}  → Show
+//	PUT    /users/{id}  → Update
+//	PATCH  /users/{id}  → Update
+//	DELETE /users/{id}  → Delete
+//
+// The routes are wrapped with the Mux's middleware like any other route.
+// Handlers read the ID with r.PathValue(hmux.ResourceID).
+//
+// Resource panics if prefix does not start with "/" or if controller
+// implements none of the controller interfaces.
+func (m *Mux) Resource(prefix string, controller any) {
+	registerResource(m, prefix, controller)
+}
+
+// Resource registers the conventional routes for a RESTful resource at
+// prefix, joined with the group's prefix and wrapped with the group's
+// middleware. See Mux.Resource.
+func (g *Group) Resource(prefix string, controller any) {
+	registerResource(g, prefix, controller)
+}
+
+// registerResource registers the routes of controller on router.
+func registerResource(router Router, prefix string, controller any) {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("hmux: resource prefix must start with /")
+	}
+
+	collection := strings.TrimSuffix(prefix, "/")
+	if collection == "" {
+		collection = "/{$}"
+	}
+	member := strings.TrimSuffix(prefix, "/") + "/{" + ResourceID + "}"
+
+	registered := false
+	register := func(method, path string, h http.HandlerFunc) {
+		router.Handle(method+" "+path, h)
+		registered = true
+	}
+
+	if c, ok := controller.(Indexer); ok {
+		register(http.MethodGet, collection, c.Index)
+	}
+	if c, ok := controller.(Creator); ok {
+		register(http.MethodPost, collection, c.Create)
+	}
+	if c, ok := controller.(Shower); ok {
+		register(http.MethodGet, member, c.Show)
+	}
+	if c, ok := controller.(Updater); ok {
+		register(http.MethodPut, member, c.Update)
+		register(http.MethodPatch, member, c.Update)
+	}
+	if c, ok := controller.(Deleter); ok {
+		register(http.MethodDelete, member, c.Delete)
+	}
+
+	if !registered {
+		panic("hmux: resource controller implements no controller methods")
+	}
+}
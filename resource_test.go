@@ -0,0 +1,101 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type userController struct{}
+
+func (userController) Index(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("index"))
+}
+
+func (userController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("create"))
+}
+
+func (userController) Show(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("show " + r.PathValue(ResourceID)))
+}
+
+func (userController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("update " + r.PathValue(ResourceID)))
+}
+
+func (userController) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("delete " + r.PathValue(ResourceID)))
+}
+
+type readOnlyController struct{}
+
+func (readOnlyController) Index(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("index"))
+}
+
+func TestGroup_Resource(t *testing.T) {
+	var record []string
+	m := New()
+	api := m.Group("/api").(ExtendedRouter)
+	api.Use(recordingMiddleware("api", &record))
+	api.Resource("/users", userController{})
+	api.Resource("/reports", readOnlyController{})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodGet, "/api/users", http.StatusOK, "index"},
+		{http.MethodPost, "/api/users", http.StatusOK, "create"},
+		{http.MethodGet, "/api/users/7", http.StatusOK, "show 7"},
+		{http.MethodPut, "/api/users/7", http.StatusOK, "update 7"},
+		{http.MethodPatch, "/api/users/7", http.StatusOK, "update 7"},
+		{http.MethodDelete, "/api/users/7", http.StatusOK, "delete 7"},
+		{http.MethodGet, "/api/reports", http.StatusOK, "index"},
+		{http.MethodPost, "/api/reports", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/api/reports/1", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			record = nil
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK {
+				if rec.Body.String() != tt.body {
+					t.Errorf("expected body %q, got %q", tt.body, rec.Body.String())
+				}
+				if len(record) != 2 {
+					t.Errorf("expected group middleware applied, got %v", record)
+				}
+			}
+		})
+	}
+}
+
+func TestResource_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"relative prefix", func(m *Mux) { m.Resource("users", userController{}) }},
+		{"no methods", func(m *Mux) { m.Resource("/users", struct{}{}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
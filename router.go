@@ -1,6 +1,9 @@
 package hmux
 
-import "net/http"
+import (
+	"io/fs"
+	"net/http"
+)
 
 // Router is the interface implemented by both Mux and Group. It provides
 // methods for registering handlers, adding middleware, and creating
@@ -26,3 +29,44 @@ type Router interface {
 	// to a single route without creating a named group.
 	With(mw ...func(http.Handler) http.Handler) Router
 }
+
+// ExtendedRouter is implemented by Mux and Group in addition to Router,
+// and so by every Router they return. It holds the registration features
+// beyond those of Router, which stays as it is so that existing
+// implementations and mocks of Router keep compiling. A Router returned
+// by Group or With can be asserted to it:
+//
+//	api := mux.Group("/api").(hmux.ExtendedRouter)
+//	api.NotFound(jsonNotFound)
+type ExtendedRouter interface {
+	Router
+
+	// TryHandle is like Handle but returns an error instead of panicking.
+	TryHandle(pattern string, handler http.Handler) error
+
+	// TryHandleFunc is like HandleFunc but returns an error instead of
+	// panicking.
+	TryHandleFunc(pattern string, handler http.HandlerFunc) error
+
+	// NotFound registers a fallback handler for requests under the
+	// router's prefix that match no more specific route.
+	NotFound(handler http.HandlerFunc)
+
+	// Static serves the files of fsys under the given path prefix.
+	Static(prefix string, fsys fs.FS, opts ...StaticOption)
+
+	// Resource registers the conventional RESTful routes of controller
+	// under the given path prefix.
+	Resource(prefix string, controller any)
+
+	// ErrorHandler sets the error handler for the router's routes.
+	ErrorHandler(fn ErrorHandlerFunc)
+
+	// Meta returns a new router whose routes carry the given metadata in
+	// addition to the current router's.
+	Meta(meta M) ExtendedRouter
+
+	// Matching returns a new router whose routes are constrained by the
+	// given matchers in addition to the current router's.
+	Matching(matchers ...Matcher) ExtendedRouter
+}
@@ -0,0 +1,418 @@
+package hmux
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Pattern is the pattern the route was registered with, including
+	// any group prefix, as reported by http.ServeMux.
+	Pattern string
+
+	// Host is the host pattern of the Router created with Host that the
+	// route belongs to, or "" for other routes.
+	Host string
+
+	// Version is the API version of the Router created with Version that
+	// the route belongs to, or "" for other routes.
+	Version string
+
+	// Middleware names the route's middleware from the outermost in. Named
+	// middleware is listed under its name together with any middleware
+	// inserted around it; other middleware is named after its function,
+	// such as "middleware.Logger". Outer middleware is not included.
+	Middleware []string
+
+	// Meta is the metadata attached to the route with Meta, or nil. It
+	// must not be modified.
+	Meta M
+}
+
+// route is a route recorded for Routes.
+type route struct {
+	pattern string
+	handler http.Handler
+	inner   http.Handler // handler served by a cached chain
+	base    http.Handler // handler with its error boundary, for Clone
+	stack   func() []layer
+	meta    M
+
+	// matchers constrain the route within the match set of its pattern.
+	matchers []Matcher
+}
+
+// layer is an entry of a middleware stack. Named middleware carries its
+// slot so that Routes can report it by name.
+type layer struct {
+	fn   func(http.Handler) http.Handler
+	slot *namedMiddleware
+}
+
+// layersOf returns the layers for plain middleware.
+func layersOf(mw []func(http.Handler) http.Handler) []layer {
+	layers := make([]layer, len(mw))
+	for i, fn := range mw {
+		layers[i] = layer{fn: fn}
+	}
+
+	return layers
+}
+
+// compose wraps h with the middleware of stack, the first layer being
+// the outermost. See wrap.
+func compose(h http.Handler, stack []layer) http.Handler {
+	for i := len(stack) - 1; i >= 0; i-- {
+		h = stack[i].fn(h)
+	}
+
+	return h
+}
+
+// names returns the display names of the layer's middleware.
+func (l layer) names() []string {
+	if l.slot == nil {
+		return []string{funcName(l.fn)}
+	}
+
+	var names []string
+	for _, fn := range l.slot.before {
+		names = append(names, funcName(fn))
+	}
+	names = append(names, l.slot.name)
+	for _, fn := range l.slot.after {
+		names = append(names, funcName(fn))
+	}
+
+	return names
+}
+
+// funcName returns the package-qualified name of the function fn was
+// created by, such as "middleware.Logger" for middleware.Logger or for a
+// closure returned by it.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "?"
+	}
+
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+
+	// Strip the ".funcN" suffixes of closures, and of closures nested in
+	// them, to name the function that created them.
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 || strings.Trim(name[i+len(".func"):], "0123456789.") != "" {
+			break
+		}
+		name = name[:i]
+	}
+
+	return name
+}
+
+// info returns the RouteInfo describing rt.
+func (rt *route) info(host, version string) RouteInfo {
+	var names []string
+	for _, l := range rt.stack() {
+		names = append(names, l.names()...)
+	}
+
+	return RouteInfo{Pattern: rt.pattern, Host: host, Version: version, Middleware: names, Meta: rt.meta}
+}
+
+// Routes returns the registered routes in registration order, followed
+// by the routes of each Router created with Host and Version. Routes is
+// meant for documentation, debugging and tooling such as PrintRoutes.
+func (m *Mux) Routes() []RouteInfo {
+	return m.appendRoutes(nil, "", "")
+}
+
+// appendRoutes appends the routes of m and its child routers to routes.
+func (m *Mux) appendRoutes(routes []RouteInfo, host, version string) []RouteInfo {
+	unlock := m.lock()
+	for _, rt := range m.routes {
+		routes = append(routes, rt.info(host, version))
+	}
+	unlock()
+
+	for _, hr := range m.hosts {
+		routes = hr.mux.appendRoutes(routes, hr.pattern, version)
+	}
+	for _, v := range m.versions {
+		routes = v.mux.appendRoutes(routes, host, v.version)
+	}
+
+	return routes
+}
+
+// Match reports which registered route would handle a request with the
+// given method, host and path, without running any handler or
+// middleware. It is meant for tests, for debugging pattern precedence
+// and for tooling:
+//
+//	info, ok := mux.Match("GET", "api.example.com", "/users/42")
+//	// info.Pattern == "GET /users/{id}"
+//
+// Routes registered with Host are matched against host, and when a
+// default API version is configured its routes are matched. A
+// case-insensitive Mux lowercases path first. The ok
+// result is false if the request would get a 404 or 405 response. A
+// request that http.ServeMux would redirect, for example from "/docs"
+// to "/docs/", reports the pattern of the redirect target.
+func (m *Mux) Match(method, host, path string) (RouteInfo, bool) {
+	if m.caseFold {
+		path = strings.ToLower(path)
+	}
+
+	r := &http.Request{
+		Method: method,
+		Host:   host,
+		URL:    &url.URL{Path: path},
+		Header: make(http.Header),
+	}
+
+	return m.match(r, "", "")
+}
+
+// match resolves r the way serve would dispatch it.
+func (m *Mux) match(r *http.Request, host, version string) (RouteInfo, bool) {
+	if len(m.hosts) > 0 {
+		if hm, hr := m.matchHost(r); hm != nil {
+			for _, h := range m.hosts {
+				if h.mux == hm {
+					host = h.pattern
+				}
+			}
+
+			return hm.match(hr, host, version)
+		}
+	}
+
+	if m.defaultVersion != "" {
+		for _, v := range m.versions {
+			if v.version == m.defaultVersion {
+				return v.mux.match(r, host, v.version)
+			}
+		}
+	}
+
+	_, pattern := m.mux.Load().Handler(r)
+	if pattern == "" {
+		return RouteInfo{}, false
+	}
+
+	defer m.lock()()
+
+	for _, rt := range slices.Backward(m.routes) {
+		if rt.pattern == pattern {
+			return rt.info(host, version), true
+		}
+	}
+
+	return RouteInfo{Pattern: pattern, Host: host, Version: version}, true
+}
+
+// Snapshot returns a deterministic JSON description of the routes
+// reported by Routes, with their middleware and metadata, for golden-file
+// tests that make route changes visible in code review:
+//
+//	func TestRoutes(t *testing.T) {
+//	    got, err := newMux().Snapshot()
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    want, _ := os.ReadFile("testdata/routes.golden.json")
+//	    if !bytes.Equal(got, want) {
+//	        t.Errorf("routes changed; update testdata/routes.golden.json:\n%s", got)
+//	    }
+//	}
+//
+// Routes are sorted by host, version and pattern, so reordering
+// registrations does not change the snapshot, and metadata keys are
+// sorted. Snapshot returns an error if metadata cannot be encoded as
+// JSON.
+func (m *Mux) Snapshot() ([]byte, error) {
+	type snapshotRoute struct {
+		Pattern    string   `json:"pattern"`
+		Host       string   `json:"host,omitempty"`
+		Version    string   `json:"version,omitempty"`
+		Middleware []string `json:"middleware,omitempty"`
+		Meta       M        `json:"meta,omitempty"`
+	}
+
+	infos := m.Routes()
+	slices.SortStableFunc(infos, func(a, b RouteInfo) int {
+		return cmp.Or(
+			cmp.Compare(a.Host, b.Host),
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.Pattern, b.Pattern),
+		)
+	})
+
+	routes := make([]snapshotRoute, len(infos))
+	for i, info := range infos {
+		routes[i] = snapshotRoute(info)
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Routes []snapshotRoute `json:"routes"`
+	}{routes}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("hmux: snapshot: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// PrintRoutes writes the routes reported by Routes to w as a tree of
+// path segments, with the method and middleware of each route:
+//
+//	/                 GET     middleware.Logger
+//	/api
+//	  /users          GET     middleware.Logger, auth
+//	                  POST    middleware.Logger, auth
+//	    /{id}         GET     middleware.Logger, auth
+//
+// Routes of Host and Version routers, and routes whose pattern names a
+// host, are printed under a heading line per host and version. Patterns
+// without a method are listed with "*". A call from the OnStart hook of
+// Serve gives a startup overview:
+//
+//	hmux.Serve(ctx, ":8080", mux, hmux.WithOnStart(func(addr net.Addr) {
+//	    log.Printf("listening on %s", addr)
+//	    mux.PrintRoutes(os.Stderr)
+//	}))
+func (m *Mux) PrintRoutes(w io.Writer) {
+	type section struct {
+		heading string
+		root    *routeNode
+	}
+
+	var sections []*section
+	for _, info := range m.Routes() {
+		method, rest := splitMethodPath(info.Pattern)
+		if method == "" {
+			method = "*"
+		}
+		host, path := splitHostPath(rest)
+		if info.Host != "" {
+			host = info.Host
+		}
+
+		heading := host
+		if info.Version != "" {
+			heading = strings.TrimSpace(host + " (" + VersionHeader + ": " + info.Version + ")")
+		}
+
+		i := slices.IndexFunc(sections, func(s *section) bool { return s.heading == heading })
+		if i < 0 {
+			i = len(sections)
+			sections = append(sections, &section{heading: heading, root: &routeNode{}})
+		}
+
+		n := sections[i].root
+		for _, seg := range strings.Split(path[1:], "/") {
+			n = n.child(seg)
+		}
+		n.routes = append(n.routes, routeLine{method: method, middleware: strings.Join(info.Middleware, ", ")})
+	}
+
+	var rows [][3]string
+	for _, s := range sections {
+		indent := ""
+		if s.heading != "" {
+			rows = append(rows, [3]string{s.heading})
+			indent = "  "
+		}
+		rows = s.root.appendRows(rows, indent)
+	}
+
+	var labelWidth, methodWidth int
+	for _, row := range rows {
+		if row[1] != "" {
+			labelWidth = max(labelWidth, len(row[0]))
+			methodWidth = max(methodWidth, len(row[1]))
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		if row[1] == "" {
+			b.WriteString(row[0])
+		} else {
+			b.WriteString(strings.TrimRight(pad(row[0], labelWidth)+"  "+pad(row[1], methodWidth)+"  "+row[2], " "))
+		}
+		b.WriteByte('\n')
+	}
+
+	io.WriteString(w, b.String())
+}
+
+// routeNode is a path segment in the tree printed by PrintRoutes.
+type routeNode struct {
+	segment  string
+	routes   []routeLine
+	children []*routeNode
+}
+
+// routeLine is a route printed on a routeNode.
+type routeLine struct {
+	method     string
+	middleware string
+}
+
+// child returns the child node for segment, creating it if needed.
+func (n *routeNode) child(segment string) *routeNode {
+	for _, c := range n.children {
+		if c.segment == segment {
+			return c
+		}
+	}
+
+	c := &routeNode{segment: segment}
+	n.children = append(n.children, c)
+
+	return c
+}
+
+// appendRows appends a row per route of the children of n, in segment
+// order, and a row without method for children that have none.
+func (n *routeNode) appendRows(rows [][3]string, indent string) [][3]string {
+	slices.SortFunc(n.children, func(a, b *routeNode) int { return strings.Compare(a.segment, b.segment) })
+
+	for _, c := range n.children {
+		label := indent + "/" + c.segment
+		if len(c.routes) == 0 {
+			rows = append(rows, [3]string{label})
+		}
+		for i, rl := range c.routes {
+			if i > 0 {
+				label = ""
+			}
+			rows = append(rows, [3]string{label, rl.method, rl.middleware})
+		}
+
+		rows = c.appendRows(rows, indent+"  ")
+	}
+
+	return rows
+}
+
+// pad right-pads s with spaces to width.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,178 @@
+package hmux
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.HandleFunc("GET /users/{id}", noop)
+	m.HandleFunc("GET /users/me", noop)
+	m.HandleFunc("/docs/", noop)
+	api := m.Group("/api")
+	api.HandleFunc("POST /items", noop)
+	tenant := m.Host("{tenant}.example.com")
+	tenant.HandleFunc("GET /", noop)
+
+	tests := []struct {
+		method, host, path string
+		pattern            string
+		ok                 bool
+	}{
+		{"GET", "", "/users/42", "GET /users/{id}", true},
+		{"GET", "", "/users/me", "GET /users/me", true},
+		{"HEAD", "", "/users/42", "GET /users/{id}", true},
+		{"DELETE", "", "/users/42", "", false},
+		{"GET", "", "/docs/intro", "/docs/", true},
+		{"GET", "", "/docs", "/docs/", true},
+		{"POST", "", "/api/items", "POST /api/items", true},
+		{"GET", "", "/missing", "", false},
+		{"GET", "acme.example.com", "/anything", "GET /", true},
+		{"GET", "acme.example.com:8080", "/users/42", "GET /", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.host+tt.path, func(t *testing.T) {
+			info, ok := m.Match(tt.method, tt.host, tt.path)
+			if ok != tt.ok || info.Pattern != tt.pattern {
+				t.Errorf("expected %q %v, got %q %v", tt.pattern, tt.ok, info.Pattern, ok)
+			}
+		})
+	}
+}
+
+func TestMatch_DefaultVersion(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.Version("v1").HandleFunc("GET /old", noop)
+	m.Version("v2", VersionDefault()).HandleFunc("GET /new", noop)
+
+	if info, ok := m.Match("GET", "", "/new"); !ok || info.Pattern != "GET /new" {
+		t.Errorf("expected GET /new, got %q %v", info.Pattern, ok)
+	}
+	if _, ok := m.Match("GET", "", "/old"); ok {
+		t.Error("expected no match outside the default version")
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	var record []string
+
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.UseNamed("auth", recordingMiddleware("auth", &record))
+	m.HandleFunc("GET /", noop)
+	api := m.Group("/api")
+	api.Use(recordingMiddleware("B", &record))
+	api.HandleFunc("POST /items", noop)
+	m.Host("{tenant}.example.com").HandleFunc("GET /home", noop)
+	m.Version("v1").HandleFunc("GET /old", noop)
+
+	m.UseBefore("auth", recordingMiddleware("C", &record))
+
+	expected := []RouteInfo{
+		{Pattern: "GET /", Middleware: []string{"hmux.recordingMiddleware", "hmux.recordingMiddleware", "auth"}},
+		{Pattern: "POST /api/items", Middleware: []string{"hmux.recordingMiddleware", "hmux.recordingMiddleware", "auth", "hmux.recordingMiddleware"}},
+		{Pattern: "GET /home", Host: "{tenant}.example.com", Middleware: []string{"hmux.recordingMiddleware", "hmux.recordingMiddleware", "auth"}},
+		{Pattern: "GET /old", Version: "v1", Middleware: []string{"hmux.recordingMiddleware", "hmux.recordingMiddleware", "auth"}},
+	}
+
+	routes := m.Routes()
+	if len(routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %d: %v", len(expected), len(routes), routes)
+	}
+	for i, info := range routes {
+		e := expected[i]
+		if info.Pattern != e.Pattern || info.Host != e.Host || info.Version != e.Version || !slices.Equal(info.Middleware, e.Middleware) {
+			t.Errorf("expected %+v, got %+v", e, info)
+		}
+	}
+}
+
+func TestPrintRoutes(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.UseNamed("auth", Chain())
+	m.HandleFunc("GET /", noop)
+	m.HandleFunc("GET /users/{id}", noop)
+	m.HandleFunc("GET /users", noop)
+	m.HandleFunc("POST /users", noop)
+	m.HandleFunc("GET /docs/", noop)
+	m.HandleFunc("GET api.example.com/status", noop)
+
+	var b strings.Builder
+	m.PrintRoutes(&b)
+
+	expected := `/          GET   auth
+/docs
+  /        GET   auth
+/users     GET   auth
+           POST  auth
+  /{id}    GET   auth
+api.example.com
+  /status  GET   auth
+`
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	m := New()
+	m.UseNamed("auth", func(next http.Handler) http.Handler { return next })
+	m.Meta(M{"tag": "users", "public": false}).HandleFunc("GET /users", noop)
+	m.HandleFunc("DELETE /users/{id}", noop)
+	m.Host("admin.example.com").HandleFunc("GET /", noop)
+
+	got, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "routes": [
+    {
+      "pattern": "DELETE /users/{id}",
+      "middleware": [
+        "auth"
+      ]
+    },
+    {
+      "pattern": "GET /users",
+      "middleware": [
+        "auth"
+      ],
+      "meta": {
+        "public": false,
+        "tag": "users"
+      }
+    },
+    {
+      "pattern": "GET /",
+      "host": "admin.example.com",
+      "middleware": [
+        "auth"
+      ]
+    }
+  ]
+}
+`
+	if string(got) != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	m.Meta(M{"bad": func() {}}).HandleFunc("GET /bad", noop)
+	if _, err := m.Snapshot(); err == nil {
+		t.Error("expected error for metadata that cannot be encoded")
+	}
+}
@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequireScopes returns middleware that only lets requests through whose
+// token grants all the given scopes, as listed by Claims.Scopes. It
+// enforces scopes per route on top of an authentication middleware such
+// as middleware.JWTAuth or middleware.Introspection, which must run
+// first:
+//
+//	api.Use(auth.Handler)
+//	api.With(hmux.RequireScopes("billing:write")).
+//	    HandleFunc("POST /invoices", createInvoice)
+//
+// Rejections follow RFC 6750: requests without claims get 401
+// Unauthorized with a Bearer challenge, and requests lacking a scope get
+// 403 Forbidden with an insufficient_scope error naming the scopes
+// required.
+//
+// RequireScopes panics if no scope is given.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	if len(scopes) == 0 {
+		panic("hmux: RequireScopes needs at least one scope")
+	}
+
+	challenge := `Bearer error="insufficient_scope", scope="` + strings.Join(scopes, " ") + `"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+			if claims == nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					w.Header().Set("WWW-Authenticate", challenge)
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireScopes(t *testing.T) {
+	h := RequireScopes("billing:read", "billing:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name      string
+		claims    Claims
+		code      int
+		challenge string
+	}{
+		{"granted", Claims{"scope": "billing:write profile billing:read"}, http.StatusOK, ""},
+		{"anonymous", nil, http.StatusUnauthorized, "Bearer"},
+		{"missing scope", Claims{"scope": "billing:read"}, http.StatusForbidden, `Bearer error="insufficient_scope", scope="billing:read billing:write"`},
+		{"no scope claim", Claims{"sub": "u1"}, http.StatusForbidden, `Bearer error="insufficient_scope", scope="billing:read billing:write"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/invoices", nil)
+			if tt.claims != nil {
+				req = req.WithContext(ContextWithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected challenge %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package hmux
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Default server settings used by Serve.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 60 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultDrainTimeout      = 15 * time.Second
+)
+
+// ServerOption configures the server started by Serve.
+type ServerOption func(*serverConfig)
+
+type serverConfig struct {
+	server   *http.Server
+	drain    time.Duration
+	signals  []os.Signal
+	listener net.Listener
+	onStart  func(addr net.Addr)
+}
+
+// WithTimeouts returns a ServerOption that overrides the read, write and
+// idle timeouts of the server. A zero value disables the timeout, which
+// is useful for streaming endpoints that outlive the write timeout.
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(c *serverConfig) {
+		c.server.ReadTimeout = read
+		c.server.WriteTimeout = write
+		c.server.IdleTimeout = idle
+	}
+}
+
+// WithDrainTimeout returns a ServerOption that sets how long Serve waits
+// for in-flight requests to finish during shutdown before closing the
+// remaining connections.
+func WithDrainTimeout(d time.Duration) ServerOption {
+	return func(c *serverConfig) {
+		c.drain = d
+	}
+}
+
+// WithSignals returns a ServerOption that replaces the signals triggering
+// a graceful shutdown. No arguments disables signal handling, leaving
+// shutdown to context cancellation.
+func WithSignals(sig ...os.Signal) ServerOption {
+	return func(c *serverConfig) {
+		c.signals = sig
+	}
+}
+
+// WithServer returns a ServerOption that calls fn with the http.Server
+// before it starts, for settings without a dedicated option such as
+// TLSConfig, ErrorLog or MaxHeaderBytes.
+func WithServer(fn func(*http.Server)) ServerOption {
+	return func(c *serverConfig) {
+		fn(c.server)
+	}
+}
+
+// WithListener returns a ServerOption that serves on l instead of
+// listening on addr.
+func WithListener(l net.Listener) ServerOption {
+	return func(c *serverConfig) {
+		c.listener = l
+	}
+}
+
+// WithOnStart returns a ServerOption that calls fn with the listening
+// address once the server accepts connections, which is useful with
+// ":0" addresses.
+func WithOnStart(fn func(addr net.Addr)) ServerOption {
+	return func(c *serverConfig) {
+		c.onStart = fn
+	}
+}
+
+// Serve runs an HTTP server for handler on addr until ctx is cancelled
+// or the process receives SIGINT or SIGTERM, then shuts it down
+// gracefully: the listener closes, in-flight requests get the drain
+// period to finish, and remaining connections are closed.
+//
+// The server uses timeouts suitable for most services: 10s to read
+// headers, 30s to read the request, 60s to write the response and 120s
+// for idle keep-alive connections. Options override them.
+//
+// Serve returns nil after a clean shutdown. It returns the error from
+// listening or serving, or context.DeadlineExceeded if requests were
+// still running when the drain period ended.
+//
+// Example:
+//
+//	func main() {
+//	    mux := hmux.New()
+//	    mux.HandleFunc("GET /", home)
+//
+//	    if err := hmux.Serve(context.Background(), ":8080", mux); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func Serve(ctx context.Context, addr string, handler http.Handler, opts ...ServerOption) error {
+	cfg := &serverConfig{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: DefaultReadHeaderTimeout,
+			ReadTimeout:       DefaultReadTimeout,
+			WriteTimeout:      DefaultWriteTimeout,
+			IdleTimeout:       DefaultIdleTimeout,
+		},
+		drain:   DefaultDrainTimeout,
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if len(cfg.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, cfg.signals...)
+		defer stop()
+	}
+
+	l := cfg.listener
+	if l == nil {
+		var err error
+		if l, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+	if cfg.onStart != nil {
+		cfg.onStart(l.Addr())
+	}
+
+	srv := cfg.server
+	srv.BaseContext = func(net.Listener) context.Context {
+		// Requests outlive the shutdown signal while draining.
+		return context.WithoutCancel(ctx)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ServeTLS(l, "", "")
+		} else {
+			errc <- srv.Serve(l)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.drain)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		srv.Close()
+	}
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+
+	return err
+}
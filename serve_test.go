@@ -0,0 +1,95 @@
+package hmux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	started := make(chan net.Addr, 1)
+	inFlight := make(chan struct{})
+
+	m := New()
+	m.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(inFlight)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, "127.0.0.1:0", m,
+			WithSignals(),
+			WithDrainTimeout(time.Second),
+			WithOnStart(func(addr net.Addr) { started <- addr }),
+		)
+	}()
+
+	addr := <-started
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr.String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-inFlight
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Errorf("expected in-flight request to finish, got %q", got)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestServe_DrainTimeout(t *testing.T) {
+	started := make(chan net.Addr, 1)
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	m := New()
+	m.HandleFunc("GET /stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(inFlight)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, "127.0.0.1:0", m,
+			WithSignals(),
+			WithDrainTimeout(20*time.Millisecond),
+			WithOnStart(func(addr net.Addr) { started <- addr }),
+		)
+	}()
+
+	addr := <-started
+	go http.Get("http://" + addr.String() + "/stuck")
+
+	<-inFlight
+	cancel()
+
+	if err := <-errc; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestServe_ListenError(t *testing.T) {
+	if err := Serve(context.Background(), "256.0.0.1:0", New(), WithSignals()); err == nil {
+		t.Error("expected listen error")
+	}
+}
@@ -0,0 +1,84 @@
+package hmux
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// SPA serves a single-page application from fsys under the given path
+// prefix. Existing files are served as by Static. Any other GET request
+// under the prefix receives the application's index.html, so client-side
+// routes such as "/settings/profile" load the app, with two exceptions
+// that respond with 404 Not Found:
+//
+//   - paths matching one of the exclude patterns, which use the path
+//     syntax of Skip, so unmatched API requests do not get HTML back;
+//   - paths whose last segment has a file extension, which are missing
+//     assets rather than client-side routes.
+//
+// The route is registered as "GET prefix/{path...}", which http.ServeMux
+// ranks below every more specific route, so API groups registered on the
+// same Mux keep precedence regardless of registration order:
+//
+//	api := mux.Group("/api")
+//	api.HandleFunc("GET /users", listUsers)
+//
+//	mux.SPA("/", dist, "/api/") // GET /settings → index.html, GET /api/nope → 404
+//
+// index.html is sent with "Cache-Control: no-cache" so clients pick up
+// new deployments, while hashed assets get long-lived caching.
+//
+// SPA panics if prefix does not start with "/", fsys is nil, or an
+// exclude pattern does not start with "/".
+func (m *Mux) SPA(prefix string, fsys fs.FS, exclude ...string) {
+	for _, p := range exclude {
+		if len(p) == 0 || p[0] != '/' {
+			panic("hmux: SPA exclude pattern must start with /")
+		}
+	}
+
+	m.Handle(staticPattern(prefix), &spaHandler{
+		static:  newStaticHandler(fsys, nil),
+		exclude: exclude,
+	})
+}
+
+// spaHandler serves files from an fs.FS and falls back to index.html.
+type spaHandler struct {
+	static  *staticHandler
+	exclude []string
+}
+
+func (s *spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, p := range s.exclude {
+		if matchPath(p, r.URL.Path) {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	name := fileName(r)
+	if f, info, err := s.static.open(name); err == nil {
+		f.Close()
+		if !info.IsDir() {
+			s.static.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if name != "." && path.Ext(name) != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, info, err := s.static.open("index.html")
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", "no-cache")
+	s.static.serveFile(w, r, "index.html", f, info)
+}
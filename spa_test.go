@@ -0,0 +1,54 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMux_SPA(t *testing.T) {
+	dist := fstest.MapFS{
+		"index.html":             {Data: []byte("<app>")},
+		"assets/app.1a2b3c4d.js": {Data: []byte("js")},
+	}
+
+	m := New()
+	m.SPA("/", dist, "/api/")
+	api := m.Group("/api")
+	api.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	tests := []struct {
+		path         string
+		status       int
+		body         string
+		cacheControl string
+	}{
+		{"/", http.StatusOK, "<app>", "no-cache"},
+		{"/settings/profile", http.StatusOK, "<app>", "no-cache"},
+		{"/assets/app.1a2b3c4d.js", http.StatusOK, "js", immutableCacheControl},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+		{"/assets", http.StatusOK, "<app>", "no-cache"},
+		{"/api/users", http.StatusOK, "users", ""},
+		{"/api/missing", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, rec.Body.String())
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != tt.cacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", tt.cacheControl, cc)
+			}
+		})
+	}
+}
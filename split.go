@@ -0,0 +1,105 @@
+package hmux
+
+import (
+	"math/rand/v2"
+	"net/http"
+)
+
+// Split variants, as stored in the stickiness cookie.
+const (
+	splitPrimary = "primary"
+	splitCanary  = "canary"
+)
+
+// Splitter sends a share of the requests for a route to a canary
+// handler and the rest to the primary handler, for canary rollouts of a
+// new implementation behind the same pattern. It is an http.Handler,
+// registered like any other:
+//
+//	mux.Handle("POST /checkout", hmux.Split(checkoutV1, checkoutV2, 10).
+//	    Header("X-Canary", "always").
+//	    Sticky("checkout_variant"))
+//
+// A Splitter must be fully configured before it serves requests.
+type Splitter struct {
+	primary, canary http.Handler
+	percent         int
+
+	header, value string
+	cookie        string
+
+	intN func(n int) int // rand.IntN, replaced in tests
+}
+
+// Split returns a Splitter sending percent of the requests, chosen at
+// random, to canary and the others to primary.
+//
+// Split panics if either handler is nil or percent is not between 0 and
+// 100.
+func Split(primary, canary http.Handler, percent int) *Splitter {
+	if primary == nil || canary == nil {
+		panic("hmux: nil handler passed to Split")
+	}
+	if percent < 0 || percent > 100 {
+		panic("hmux: split percent must be between 0 and 100")
+	}
+
+	return &Splitter{primary: primary, canary: canary, percent: percent, intN: rand.IntN}
+}
+
+// Header sends requests whose named header equals value to the canary,
+// regardless of the percentage, so testers can opt in explicitly.
+func (s *Splitter) Header(name, value string) *Splitter {
+	s.header, s.value = name, value
+	return s
+}
+
+// Sticky keeps each client on the variant it was first assigned by
+// recording the assignment in the named cookie. A client presenting the
+// cookie is served by the variant it names; others are assigned by
+// percentage and receive the cookie with the response.
+func (s *Splitter) Sticky(cookie string) *Splitter {
+	s.cookie = cookie
+	return s
+}
+
+// ServeHTTP serves the request with the canary or primary handler.
+func (s *Splitter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.pick(w, r) == splitCanary {
+		s.canary.ServeHTTP(w, r)
+		return
+	}
+
+	s.primary.ServeHTTP(w, r)
+}
+
+// pick chooses the variant for r, setting the stickiness cookie on w
+// when a sticky client is assigned for the first time.
+func (s *Splitter) pick(w http.ResponseWriter, r *http.Request) string {
+	if s.header != "" && r.Header.Get(s.header) == s.value {
+		return splitCanary
+	}
+
+	if s.cookie != "" {
+		if c, err := r.Cookie(s.cookie); err == nil && (c.Value == splitPrimary || c.Value == splitCanary) {
+			return c.Value
+		}
+	}
+
+	variant := splitPrimary
+	if s.intN(100) < s.percent {
+		variant = splitCanary
+	}
+
+	if s.cookie != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     s.cookie,
+			Value:    variant,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	return variant
+}
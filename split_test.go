@@ -0,0 +1,103 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	tests := []struct {
+		name    string
+		percent int
+		roll    int
+		header  string
+		cookie  string
+		body    string
+		setCook string
+	}{
+		{"below percent", 10, 9, "", "", "canary", ""},
+		{"at percent", 10, 10, "", "", "primary", ""},
+		{"zero percent", 0, 0, "", "", "primary", ""},
+		{"header opt-in", 0, 99, "always", "", "canary", ""},
+		{"header mismatch", 0, 99, "never", "", "primary", ""},
+		{"sticky cookie wins", 100, 0, "", "primary", "primary", ""},
+		{"invalid cookie reassigned", 0, 0, "", "bogus", "primary", "variant=primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Split(respond("primary"), respond("canary"), tt.percent).Header("X-Canary", "always")
+			if tt.cookie != "" || tt.setCook != "" {
+				s.Sticky("variant")
+			}
+			s.intN = func(int) int { return tt.roll }
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Canary", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "variant", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("expected %q, got %q", tt.body, rec.Body.String())
+			}
+			if got := rec.Result().Cookies(); tt.setCook != "" && (len(got) != 1 || got[0].Name+"="+got[0].Value != tt.setCook) {
+				t.Errorf("expected cookie %s, got %v", tt.setCook, got)
+			}
+		})
+	}
+}
+
+func TestSplit_StickyAssignsOnce(t *testing.T) {
+	s := Split(http.NotFoundHandler(), http.NotFoundHandler(), 50).Sticky("variant")
+	s.intN = func(int) int { return 0 }
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "canary" {
+		t.Fatalf("expected canary cookie, got %v", cookies)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no cookie for an assigned client")
+	}
+}
+
+func TestSplit_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"nil handler", func() { Split(nil, http.NotFoundHandler(), 10) }},
+		{"negative", func() { Split(http.NotFoundHandler(), http.NotFoundHandler(), -1) }},
+		{"over 100", func() { Split(http.NotFoundHandler(), http.NotFoundHandler(), 101) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
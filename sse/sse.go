@@ -0,0 +1,258 @@
+// Package sse implements Server-Sent Events streams for hmux handlers.
+//
+// A handler upgrades its response to an event stream and sends events
+// until the client goes away:
+//
+//	mux.Handle("GET /events", sse.Handler(func(s *sse.Stream, r *http.Request) error {
+//	    for {
+//	        select {
+//	        case <-s.Done():
+//	            return nil
+//	        case n := <-notifications:
+//	            if err := s.SendJSON("notification", n); err != nil {
+//	                return err
+//	            }
+//	        }
+//	    }
+//	}, sse.WithHeartbeat(15*time.Second)))
+//
+// Streams flush through http.ResponseController, so they work behind
+// middleware whose response writer wrappers implement Flush or Unwrap.
+// Middleware that buffers the whole response, such as a timeout, cannot
+// be used in front of a stream; Upgrade reports an error in that case.
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidField is returned when an event ID or type contains a line
+// break, which would corrupt the stream.
+var ErrInvalidField = errors.New("sse: event field contains a line break")
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	ID    string        // sets the client's last event ID, if non-empty
+	Type  string        // event type; empty means "message"
+	Data  string        // payload; may span multiple lines
+	Retry time.Duration // reconnection delay hint, if positive
+}
+
+// Option configures a Stream.
+type Option func(*Stream)
+
+// WithHeartbeat returns an Option that sends a comment line every
+// interval while the stream is open, keeping proxies from closing idle
+// connections.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(s *Stream) {
+		s.heartbeat = interval
+	}
+}
+
+// WithRetry returns an Option that tells the client how long to wait
+// before reconnecting once the stream ends.
+func WithRetry(d time.Duration) Option {
+	return func(s *Stream) {
+		s.retry = d
+	}
+}
+
+// Stream writes Server-Sent Events to a response. Its methods are safe
+// for concurrent use.
+type Stream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	done        <-chan struct{}
+	lastEventID string
+	heartbeat   time.Duration
+	retry       time.Duration
+
+	mu     sync.Mutex
+	closed bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Upgrade turns the response into an event stream. It sets the
+// text/event-stream headers, writes a 200 status and flushes it, so the
+// client sees the stream open immediately. The caller must call Close
+// before the handler returns; Handler does this automatically.
+//
+// Upgrade returns an error if the response cannot be flushed, for
+// example because a middleware buffers it. The status has been written
+// by then, so the handler can only return.
+func Upgrade(w http.ResponseWriter, r *http.Request, opts ...Option) (*Stream, error) {
+	s := &Stream{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		done:        r.Context().Done(),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		stop:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	h.Del("Content-Length")
+
+	w.WriteHeader(http.StatusOK)
+
+	if s.retry > 0 {
+		if _, err := w.Write([]byte("retry: " + strconv.FormatInt(s.retry.Milliseconds(), 10) + "\n\n")); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.rc.Flush(); err != nil {
+		return nil, err
+	}
+
+	if s.heartbeat > 0 {
+		s.wg.Add(1)
+		go s.beat()
+	}
+
+	return s, nil
+}
+
+// Handler returns an http.Handler that upgrades each request to a stream
+// and calls fn with it. The stream is closed when fn returns, which ends
+// the response. Once the stream is open, errors can no longer be sent to
+// the client as a status code, so an error from the upgrade or from fn
+// only ends the stream; fn should log errors it cares about.
+func Handler(fn func(s *Stream, r *http.Request) error, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := Upgrade(w, r, opts...)
+		if err != nil {
+			return
+		}
+		defer s.Close()
+
+		_ = fn(s, r)
+	})
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting
+// client, or an empty string on the first connection. Handlers use it to
+// resume the stream after the last event the client received.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send writes e to the stream and flushes it.
+func (s *Stream) Send(e Event) error {
+	if strings.ContainsAny(e.ID, "\r\n") || strings.ContainsAny(e.Type, "\r\n") {
+		return ErrInvalidField
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: ")
+		b.WriteString(e.ID)
+		b.WriteByte('\n')
+	}
+	if e.Type != "" {
+		b.WriteString("event: ")
+		b.WriteString(e.Type)
+		b.WriteByte('\n')
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: ")
+		b.WriteString(strconv.FormatInt(e.Retry.Milliseconds(), 10))
+		b.WriteByte('\n')
+	}
+
+	data := strings.ReplaceAll(e.Data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	return s.write(b.String())
+}
+
+// SendJSON sends an event of the given type whose data is v encoded as
+// JSON.
+func (s *Stream) SendJSON(eventType string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(Event{Type: eventType, Data: string(data)})
+}
+
+// Comment writes a comment line, which clients ignore.
+func (s *Stream) Comment(text string) error {
+	return s.write(": " + strings.ReplaceAll(text, "\n", " ") + "\n\n")
+}
+
+// Close stops the heartbeat. The stream must not be used afterwards. It
+// does not end the response, which happens when the handler returns.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// write sends raw stream text and flushes it.
+func (s *Stream) write(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("sse: write to closed stream")
+	}
+
+	if _, err := s.w.Write([]byte(text)); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// beat sends heartbeat comments until the stream is closed or the client
+// disconnects.
+func (s *Stream) beat() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(s.heartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.done:
+			return
+		case <-t.C:
+			if s.Comment("heartbeat") != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikita-shtimenko/hmux"
+)
+
+func TestStream_Send(t *testing.T) {
+	m := hmux.New()
+	m.Handle("GET /events", Handler(func(s *Stream, r *http.Request) error {
+		if err := s.Send(Event{ID: "7", Type: "update", Data: "line1\nline2"}); err != nil {
+			return err
+		}
+		if err := s.SendJSON("user", map[string]int{"id": 1}); err != nil {
+			return err
+		}
+
+		return s.Send(Event{Data: "plain", Retry: 2 * time.Second})
+	}, WithRetry(3*time.Second)))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("expected no-cache, got %q", cc)
+	}
+	if !rec.Flushed {
+		t.Error("expected response to be flushed")
+	}
+
+	expected := "retry: 3000\n\n" +
+		"id: 7\nevent: update\ndata: line1\ndata: line2\n\n" +
+		"event: user\ndata: {\"id\":1}\n\n" +
+		"retry: 2000\ndata: plain\n\n"
+	if rec.Body.String() != expected {
+		t.Errorf("expected body:\n%q\ngot:\n%q", expected, rec.Body.String())
+	}
+}
+
+func TestStream_InvalidField(t *testing.T) {
+	s, err := Upgrade(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Send(Event{ID: "1\ndata: injected"}); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField, got %v", err)
+	}
+}
+
+func TestStream_LastEventID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Last-Event-ID", "42")
+
+	s, err := Upgrade(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.LastEventID() != "42" {
+		t.Errorf("expected 42, got %q", s.LastEventID())
+	}
+}
+
+// lockedRecorder is a ResponseRecorder safe to read while a heartbeat
+// goroutine writes to it.
+type lockedRecorder struct {
+	mu  sync.Mutex
+	rec *httptest.ResponseRecorder
+}
+
+func (l *lockedRecorder) Header() http.Header { return l.rec.Header() }
+
+func (l *lockedRecorder) WriteHeader(code int) { l.rec.WriteHeader(code) }
+
+func (l *lockedRecorder) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rec.Write(b)
+}
+
+func (l *lockedRecorder) Flush() {}
+
+func (l *lockedRecorder) body() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rec.Body.String()
+}
+
+func TestStream_Heartbeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &lockedRecorder{rec: httptest.NewRecorder()}
+	s, err := Upgrade(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx), WithHeartbeat(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(w.body(), ": heartbeat\n\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("no heartbeat sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Close()
+	if err := s.Send(Event{Data: "late"}); err == nil {
+		t.Error("expected error after Close")
+	}
+}
+
+// bufferingWriter hides the Flush method of the underlying writer.
+type bufferingWriter struct {
+	http.ResponseWriter
+}
+
+func TestUpgrade_FlushUnsupported(t *testing.T) {
+	_, err := Upgrade(bufferingWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("expected http.ErrNotSupported, got %v", err)
+	}
+}
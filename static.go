@@ -0,0 +1,338 @@
+package hmux
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// immutableCacheControl is sent for files whose name carries a content
+// hash, since their contents never change under the same name.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// StaticOption configures a static file route registered with Static.
+type StaticOption func(*staticHandler)
+
+// StaticListDirectories returns a StaticOption that enables directory
+// listings for directories without an index.html. Listings are disabled
+// by default, so such directories respond with 404 Not Found.
+func StaticListDirectories() StaticOption {
+	return func(s *staticHandler) {
+		s.listDirs = true
+	}
+}
+
+// StaticPrecompressed returns a StaticOption that serves precompressed
+// sidecar files, such as "app.js.br" and "app.js.gz" next to "app.js",
+// to clients accepting their encoding, preferring Brotli. Responses for
+// files with sidecars carry "Vary: Accept-Encoding". Build tools emit
+// such files, so large assets are served compressed at the highest
+// level without compressing them per request.
+func StaticPrecompressed() StaticOption {
+	return func(s *staticHandler) {
+		s.precompressed = true
+	}
+}
+
+// precompressedEncodings are the content codings of the sidecar files
+// served by StaticPrecompressed, in order of preference.
+var precompressedEncodings = []struct{ coding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Static serves the files of fsys, which may be an embed.FS, under the
+// given path prefix. The route is registered as "GET prefix/{path...}"
+// and is wrapped with the Mux's middleware like any other route:
+//
+//	//go:embed assets
+//	var assets embed.FS
+//
+//	sub, _ := fs.Sub(assets, "assets")
+//	mux.Static("/assets", sub) // GET /assets/app.css → assets/app.css
+//
+// Files are served with http.ServeContent, which handles content types,
+// Range requests and conditional requests, so media and large downloads
+// can be resumed and revalidated. Files get an ETag derived from their
+// size and modification time or, if they have none, as the files of an
+// embed.FS do, from their contents. Files whose name carries a
+// content hash, such as "app.3f9a1c2e.js" or "index-BXk3Qw9a.css", are
+// sent with a one-year immutable Cache-Control header. A request for a
+// directory serves its index.html; directory listings are disabled
+// unless StaticListDirectories is given. StaticPrecompressed serves
+// precompressed variants of the files.
+//
+// Static panics if prefix does not start with "/" or fsys is nil.
+func (m *Mux) Static(prefix string, fsys fs.FS, opts ...StaticOption) {
+	m.Handle(staticPattern(prefix), newStaticHandler(fsys, opts))
+}
+
+// staticPattern returns the route pattern serving files under prefix.
+func staticPattern(prefix string) string {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("hmux: static prefix must start with /")
+	}
+
+	return "GET " + strings.TrimSuffix(prefix, "/") + "/{path...}"
+}
+
+// staticHandler serves files from an fs.FS, taking the file name from
+// the "path" wildcard of its route.
+type staticHandler struct {
+	fsys          fs.FS
+	listDirs      bool
+	precompressed bool
+
+	// etags caches the content-derived ETags of files without a
+	// modification time, by name and size.
+	etags sync.Map
+}
+
+func newStaticHandler(fsys fs.FS, opts []StaticOption) *staticHandler {
+	if fsys == nil {
+		panic("hmux: nil file system passed to Static")
+	}
+
+	s := &staticHandler{fsys: fsys}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := fileName(r)
+
+	f, info, err := s.open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	if info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		index, indexInfo, err := s.open(path.Join(name, "index.html"))
+		if err != nil {
+			if s.listDirs {
+				http.ServeFileFS(w, r, s.fsys, name)
+			} else {
+				http.NotFound(w, r)
+			}
+
+			return
+		}
+		defer index.Close()
+
+		f, info, name = index, indexInfo, path.Join(name, "index.html")
+	}
+
+	if hashedName(info.Name()) {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	}
+
+	if s.precompressed && s.serveSidecar(w, r, name) {
+		return
+	}
+
+	s.serveFile(w, r, name, f, info)
+}
+
+// serveSidecar serves the preferred precompressed variant of name that
+// the client accepts, and reports whether there was one.
+func (s *staticHandler) serveSidecar(w http.ResponseWriter, r *http.Request, name string) bool {
+	accept := r.Header.Values("Accept-Encoding")
+
+	for _, enc := range precompressedEncodings {
+		f, info, err := s.open(name + enc.ext)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		h := w.Header()
+		AddVary(h, "Accept-Encoding")
+		if info.IsDir() || !acceptsEncoding(accept, enc.coding) {
+			continue
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Encoding", enc.coding)
+
+		s.serveFile(w, r, name+enc.ext, f, info)
+
+		return true
+	}
+
+	return false
+}
+
+// acceptsEncoding reports whether Accept-Encoding header values accept
+// the content coding, explicitly or through "*", with a non-zero q-value.
+func acceptsEncoding(values []string, coding string) bool {
+	q := -1.0
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			token = strings.TrimSpace(token)
+			exact := strings.EqualFold(token, coding)
+			if !exact && (token != "*" || q >= 0) {
+				continue
+			}
+
+			weight := 1.0
+			k, val, ok := strings.Cut(strings.TrimSpace(params), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+					weight = f
+				}
+			}
+			if exact {
+				return weight > 0
+			}
+			q = weight
+		}
+	}
+
+	return q > 0
+}
+
+// fileName returns the cleaned file system name addressed by the "path"
+// wildcard of the request's route.
+func fileName(r *http.Request) string {
+	name := strings.TrimPrefix(path.Clean("/"+r.PathValue("path")), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+// serveFile writes the contents of f, the file name, with
+// http.ServeContent, along with its ETag.
+func (s *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, f fs.File, info fs.FileInfo) {
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+}
+
+// etag returns the ETag of the file name, derived from its modification
+// time and size or, if it has no modification time, from its contents.
+// content is left at its start.
+func (s *staticHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().Unix(), info.Size()), nil
+	}
+
+	key := name + "\x00" + strconv.FormatInt(info.Size(), 10)
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etag)
+
+	return etag, nil
+}
+
+// open opens name and returns it along with its file info.
+func (s *staticHandler) open(name string) (fs.File, fs.FileInfo, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		f.Close()
+		return nil, nil, errors.New("not a regular file")
+	}
+
+	return f, info, nil
+}
+
+// hashedName reports whether a file name carries a content hash: a
+// segment of at least eight letters, digits or underscores, including at
+// least one digit, separated by "." or "-" and followed by an extension.
+//
+// Examples:
+//   - "app.3f9a1c2e.js" → true
+//   - "index-BXk3Qw9a.css" → true
+//   - "app.js" → false
+//   - "jquery-datepicker.js" → false
+func hashedName(name string) bool {
+	ext := path.Ext(name)
+	if ext == "" {
+		return false
+	}
+
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexAny(stem, ".-")
+	if i < 0 {
+		return false
+	}
+
+	hash := stem[i+1:]
+	if len(hash) < 8 {
+		return false
+	}
+
+	digit := false
+	for _, c := range hash {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		default:
+			return false
+		}
+	}
+
+	return digit
+}
@@ -0,0 +1,201 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func staticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"app.js":             {Data: []byte("console.log(1)")},
+		"app.3f9a1c2e.js":    {Data: []byte("hashed")},
+		"css/site.css":       {Data: []byte("body{}")},
+		"docs/index.html":    {Data: []byte("<h1>docs</h1>")},
+		"private/secret.txt": {Data: []byte("s")},
+	}
+}
+
+func TestMux_Static(t *testing.T) {
+	var record []string
+	m := New()
+	m.Use(recordingMiddleware("A", &record))
+	m.Static("/assets", staticFS())
+
+	tests := []struct {
+		path         string
+		status       int
+		body         string
+		contentType  string
+		cacheControl string
+	}{
+		{"/assets/app.js", http.StatusOK, "console.log(1)", "text/javascript; charset=utf-8", ""},
+		{"/assets/app.3f9a1c2e.js", http.StatusOK, "hashed", "text/javascript; charset=utf-8", immutableCacheControl},
+		{"/assets/css/site.css", http.StatusOK, "body{}", "text/css; charset=utf-8", ""},
+		{"/assets/docs/", http.StatusOK, "<h1>docs</h1>", "text/html; charset=utf-8", ""},
+		{"/assets/docs", http.StatusMovedPermanently, "", "", ""},
+		{"/assets/private/", http.StatusNotFound, "", "", ""},
+		{"/assets/", http.StatusNotFound, "", "", ""},
+		{"/assets/missing.js", http.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK {
+				if rec.Body.String() != tt.body {
+					t.Errorf("expected body %q, got %q", tt.body, rec.Body.String())
+				}
+				if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+					t.Errorf("expected Content-Type %q, got %q", tt.contentType, ct)
+				}
+				if len(record) != 2 {
+					t.Errorf("expected middleware applied, got %v", record)
+				}
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != tt.cacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", tt.cacheControl, cc)
+			}
+		})
+	}
+}
+
+func TestGroup_Static_ListDirectories(t *testing.T) {
+	m := New()
+	m.Group("/public").(ExtendedRouter).Static("/files", staticFS(), StaticListDirectories())
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/files/private/", nil))
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "secret.txt") {
+		t.Errorf("expected directory listing, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/files/css/site.css", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "body{}" {
+		t.Errorf("expected file, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHashedName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"app.3f9a1c2e.js", true},
+		{"index-BXk3Qw9a.css", true},
+		{"chunk.a1b2c3d4e5f6.min.js", false},
+		{"app.js", false},
+		{"jquery-datepicker.js", false},
+		{"logo-12345678", false},
+		{"font-1234567.woff2", false},
+	}
+
+	for _, tt := range tests {
+		if got := hashedName(tt.name); got != tt.expected {
+			t.Errorf("hashedName(%q) = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestStatic_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"relative prefix", func(m *Mux) { m.Static("assets", staticFS()) }},
+		{"nil fs", func(m *Mux) { m.Static("/assets", nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
+
+func TestStatic_Conditional(t *testing.T) {
+	modTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := New()
+	m.Static("/media", fstest.MapFS{
+		"clip.mp4":           {Data: []byte("0123456789"), ModTime: modTime},
+		"logo.svg":           {Data: []byte("<svg/>")},
+		"app.js":             {Data: []byte("console.log(1)")},
+		"app.js.br":          {Data: []byte("br-bytes")},
+		"app.js.gz":          {Data: []byte("gz-bytes")},
+		"docs/index.html":    {Data: []byte("<h1>docs</h1>")},
+		"docs/index.html.gz": {Data: []byte("gz-docs")},
+	}, StaticPrecompressed())
+
+	serve := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		return rec
+	}
+
+	svgETag := serve("/media/logo.svg", nil).Header().Get("ETag")
+	if svgETag == "" {
+		t.Fatal("expected a content ETag for a file without a modification time")
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		header   map[string]string
+		code     int
+		body     string
+		encoding string
+	}{
+		{"range", "/media/clip.mp4", map[string]string{"Range": "bytes=2-4"}, http.StatusPartialContent, "234", ""},
+		{"if-modified-since", "/media/clip.mp4", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, http.StatusNotModified, "", ""},
+		{"modified", "/media/clip.mp4", map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, "0123456789", ""},
+		{"if-none-match", "/media/logo.svg", map[string]string{"If-None-Match": svgETag}, http.StatusNotModified, "", ""},
+		{"brotli", "/media/app.js", map[string]string{"Accept-Encoding": "gzip, br"}, http.StatusOK, "br-bytes", "br"},
+		{"gzip", "/media/app.js", map[string]string{"Accept-Encoding": "gzip, br;q=0"}, http.StatusOK, "gz-bytes", "gzip"},
+		{"wildcard", "/media/app.js", map[string]string{"Accept-Encoding": "*"}, http.StatusOK, "br-bytes", "br"},
+		{"identity", "/media/app.js", nil, http.StatusOK, "console.log(1)", ""},
+		{"index", "/media/docs/", map[string]string{"Accept-Encoding": "gzip"}, http.StatusOK, "gz-docs", "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.path, tt.header)
+
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+			if enc := rec.Header().Get("Content-Encoding"); enc != tt.encoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tt.encoding, enc)
+			}
+		})
+	}
+
+	rec := serve("/media/app.js", map[string]string{"Accept-Encoding": "br"})
+	if rec.Header().Get("Content-Type") != "text/javascript; charset=utf-8" || rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+	if rec.Header().Get("ETag") == serve("/media/app.js", nil).Header().Get("ETag") {
+		t.Error("expected compressed and identity responses to have different ETags")
+	}
+}
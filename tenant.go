@@ -0,0 +1,324 @@
+package hmux
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrTenantNotFound is returned by a TenantConfigProvider for tenants it
+// does not know. Tenants answers their requests with 404 Not Found.
+var ErrTenantNotFound = errors.New("hmux: tenant not found")
+
+// TenantConfigProvider supplies the configuration of a tenant, such as
+// its rate limit or the features it has enabled. It is called for every
+// request, so implementations backed by a database should cache.
+type TenantConfigProvider interface {
+	TenantConfig(ctx context.Context, tenant string) (M, error)
+}
+
+// TenantConfigFunc adapts a function to the TenantConfigProvider
+// interface.
+type TenantConfigFunc func(ctx context.Context, tenant string) (M, error)
+
+// TenantConfig implements TenantConfigProvider.
+func (f TenantConfigFunc) TenantConfig(ctx context.Context, tenant string) (M, error) {
+	return f(ctx, tenant)
+}
+
+// TenantResolver identifies the tenant a request belongs to. It returns
+// the tenant ID, or an empty string if the request names no tenant, and
+// the number of leading path segments naming the tenant, which Tenants
+// removes before routing the request.
+type TenantResolver func(r *http.Request) (tenant string, strip int)
+
+// TenantFromSubdomain returns a TenantResolver reading the tenant from
+// the subdomain of domain in the request host, as "acme" in
+// "acme.example.com" for the domain "example.com". Deeper subdomains and
+// the domain itself name no tenant.
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	return func(r *http.Request) (string, int) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+		label, ok := strings.CutSuffix(host, suffix)
+		if !ok || label == "" || strings.Contains(label, ".") {
+			return "", 0
+		}
+
+		return label, 0
+	}
+}
+
+// TenantFromPath returns a TenantResolver reading the tenant from the
+// first segment of the URL path, as "acme" in "/acme/invoices". The
+// segment is removed before routing, so tenant routes are registered
+// without it, as "GET /invoices".
+func TenantFromPath() TenantResolver {
+	return func(r *http.Request) (string, int) {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if segment == "" {
+			return "", 0
+		}
+
+		return segment, 1
+	}
+}
+
+// TenantFromHeader returns a TenantResolver reading the tenant from the
+// named request header. Only trust such a header when it is set by a
+// gateway that authenticates the client.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) (string, int) {
+		return r.Header.Get(name), 0
+	}
+}
+
+// Tenants routes the requests of a multi-tenant application. It resolves
+// the tenant of each request, stores its ID in the request context, where
+// TenantFromContext returns it, and serves the request with the tenant's
+// own router, if it has one, or with the shared router:
+//
+//	tenants := hmux.NewTenants(hmux.TenantFromSubdomain("example.com"))
+//	app := tenants.Shared()
+//	app.Use(loadTenant)
+//	app.HandleFunc("GET /invoices", listInvoices)
+//
+//	// acme gets its own routes and middleware.
+//	acme := tenants.Tenant("acme")
+//	acme.Use(acmeSSO)
+//	acme.Merge(app)
+//
+//	http.ListenAndServe(":8080", tenants)
+//
+// A tenant router is isolated from the shared router: it serves only the
+// routes and middleware registered on it, so routes shared with other
+// tenants are imported with Merge. Requests naming no tenant get 404 Not
+// Found, or are served by the handler set with Unresolved.
+//
+// With a TenantConfigProvider set by SetConfig, the configuration of the
+// tenant is stored in the request context too, where
+// TenantConfigFromContext and PerTenant find it.
+type Tenants struct {
+	resolve    TenantResolver
+	opts       []Option
+	shared     *Mux
+	unresolved http.Handler
+	config     TenantConfigProvider
+
+	mu      sync.RWMutex
+	routers map[string]*Mux
+}
+
+// NewTenants returns a Tenants resolving tenants with resolve. Its
+// routers are created with opts.
+//
+// NewTenants panics if resolve is nil.
+func NewTenants(resolve TenantResolver, opts ...Option) *Tenants {
+	if resolve == nil {
+		panic("hmux: nil TenantResolver passed to NewTenants")
+	}
+
+	return &Tenants{
+		resolve: resolve,
+		opts:    opts,
+		shared:  New(opts...),
+		routers: make(map[string]*Mux),
+	}
+}
+
+// Shared returns the router serving the tenants that have no router of
+// their own.
+func (t *Tenants) Shared() *Mux {
+	return t.shared
+}
+
+// Tenant returns the router of the tenant with the given ID, creating it
+// on first use. Tenants can be added while requests are being served,
+// for example when a customer signs up for a dedicated setup.
+func (t *Tenants) Tenant(id string) *Mux {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.routers[id]
+	if !ok {
+		m = New(t.opts...)
+		t.routers[id] = m
+	}
+
+	return m
+}
+
+// RemoveTenant removes the router of the tenant with the given ID, so
+// its requests are served by the shared router again.
+func (t *Tenants) RemoveTenant(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.routers, id)
+}
+
+// Unresolved sets the handler serving requests that name no tenant, such
+// as a landing page on the bare domain.
+func (t *Tenants) Unresolved(h http.Handler) {
+	t.unresolved = h
+}
+
+// SetConfig sets the provider of tenant configurations. Requests of
+// tenants for which it returns ErrTenantNotFound get 404 Not Found, and
+// requests for which it fails otherwise get 503 Service Unavailable. It
+// must be called before serving requests.
+func (t *Tenants) SetConfig(p TenantConfigProvider) {
+	t.config = p
+}
+
+// ServeHTTP resolves the tenant of r and serves r with its router.
+func (t *Tenants) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, strip := t.resolve(r)
+	if id == "" {
+		if t.unresolved != nil {
+			t.unresolved.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx := ContextWithTenant(r.Context(), id)
+	if t.config != nil {
+		cfg, err := t.config.TenantConfig(ctx, id)
+		switch {
+		case errors.Is(err, ErrTenantNotFound):
+			http.NotFound(w, r)
+			return
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		ctx = context.WithValue(ctx, tenantConfigKey, cfg)
+	}
+
+	t.mu.RLock()
+	m, ok := t.routers[id]
+	t.mu.RUnlock()
+
+	var h http.Handler = t.shared
+	if ok {
+		h = m
+	}
+	if strip > 0 {
+		h = stripSegments(strip, h)
+	}
+
+	h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+var tenantConfigKey = &contextKey{"tenant-config"}
+
+// TenantConfigFromContext returns the configuration of the tenant the
+// request belongs to, as supplied by the TenantConfigProvider of Tenants,
+// or nil if there is none. The returned M must not be modified.
+func TenantConfigFromContext(ctx context.Context) M {
+	cfg, _ := ctx.Value(tenantConfigKey).(M)
+	return cfg
+}
+
+// maxPerTenant bounds the number of tenants whose middleware PerTenant
+// keeps. The least recently served tenants are evicted first.
+const maxPerTenant = 10000
+
+// PerTenant returns middleware that applies, to the requests of each
+// tenant, the middleware build returns for the tenant and its
+// configuration, so middleware can be parameterized per tenant at
+// request time:
+//
+//	app.Use(hmux.PerTenant(func(tenant string, cfg hmux.M) func(http.Handler) http.Handler {
+//	    limit, _ := cfg["rate_limit"].(int)
+//	    return middleware.RateLimit(limit, time.Minute, middleware.KeyByIP)
+//	}))
+//
+// The middleware built for a tenant is kept, along with any state it
+// holds such as rate-limit buckets, until the tenant's configuration
+// changes or the tenant is among the least recently served once 10000
+// tenants are kept. Configurations are compared by identity first, so
+// providers that return the same M until it changes, as caching
+// providers do, avoid a deep comparison per request. build may return
+// nil to apply no middleware. Requests without a tenant pass through
+// unchanged.
+func PerTenant(build func(tenant string, cfg M) func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return perTenant(build, maxPerTenant)
+}
+
+func perTenant(build func(tenant string, cfg M) func(http.Handler) http.Handler, max int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		type built struct {
+			id  string
+			cfg M
+			h   http.Handler
+		}
+
+		var (
+			mu    sync.Mutex
+			order = list.New() // front is most recently served
+			cache = make(map[string]*list.Element)
+		)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := TenantFromContext(r.Context())
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cfg := TenantConfigFromContext(r.Context())
+
+			mu.Lock()
+			var b *built
+			if el, ok := cache[id]; ok {
+				order.MoveToFront(el)
+				b = el.Value.(*built)
+				switch {
+				case sameM(b.cfg, cfg):
+				case reflect.DeepEqual(b.cfg, cfg):
+					b.cfg = cfg
+				default:
+					b = nil
+				}
+			}
+			mu.Unlock()
+
+			if b == nil {
+				b = &built{id: id, cfg: cfg, h: next}
+				if mw := build(id, cfg); mw != nil {
+					b.h = mw(next)
+				}
+
+				mu.Lock()
+				if el, ok := cache[id]; ok {
+					order.Remove(el)
+				}
+				cache[id] = order.PushFront(b)
+				for order.Len() > max {
+					delete(cache, order.Remove(order.Back()).(*built).id)
+				}
+				mu.Unlock()
+			}
+
+			b.h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sameM reports whether a and b are the same map.
+func sameM(a, b M) bool {
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
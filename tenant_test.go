@@ -0,0 +1,174 @@
+package hmux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	tenantHandler := func(label string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(label + ":" + TenantFromContext(r.Context()) + ":" + r.URL.Path))
+		}
+	}
+
+	tests := []struct {
+		name    string
+		resolve TenantResolver
+		host    string
+		header  string
+		path    string
+		code    int
+		body    string
+	}{
+		{"subdomain shared", TenantFromSubdomain("example.com"), "globex.example.com:8080", "", "/invoices", http.StatusOK, "shared:globex:/invoices"},
+		{"subdomain own router", TenantFromSubdomain("example.com"), "ACME.example.com", "", "/invoices", http.StatusOK, "acme:acme:/invoices"},
+		{"subdomain bare domain", TenantFromSubdomain("example.com"), "example.com", "", "/invoices", http.StatusOK, "landing"},
+		{"subdomain too deep", TenantFromSubdomain("example.com"), "a.b.example.com", "", "/invoices", http.StatusOK, "landing"},
+		{"path", TenantFromPath(), "example.com", "", "/acme/invoices", http.StatusOK, "acme:acme:/invoices"},
+		{"path shared", TenantFromPath(), "example.com", "", "/globex/invoices", http.StatusOK, "shared:globex:/invoices"},
+		{"path missing", TenantFromPath(), "example.com", "", "/", http.StatusOK, "landing"},
+		{"header", TenantFromHeader("X-Tenant"), "example.com", "acme", "/invoices", http.StatusOK, "acme:acme:/invoices"},
+		{"isolated", TenantFromHeader("X-Tenant"), "example.com", "acme", "/reports", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := NewTenants(tt.resolve)
+			tenants.Shared().HandleFunc("GET /invoices", tenantHandler("shared"))
+			tenants.Shared().HandleFunc("GET /reports", tenantHandler("shared"))
+			tenants.Tenant("acme").HandleFunc("GET /invoices", tenantHandler("acme"))
+			tenants.Unresolved(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("landing"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set("X-Tenant", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tenants.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.code, tt.body, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenants_RemoveTenant(t *testing.T) {
+	tenants := NewTenants(TenantFromHeader("X-Tenant"))
+	tenants.Tenant("acme").HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	tenants.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 from the tenant router, got %d", rec.Code)
+	}
+
+	tenants.RemoveTenant("acme")
+	rec = httptest.NewRecorder()
+	tenants.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 from the empty shared router, got %d", rec.Code)
+	}
+}
+
+func TestTenants_Config(t *testing.T) {
+	configs := map[string]M{"acme": {"greeting": "hi"}, "globex": {"greeting": "hello"}}
+	tenants := NewTenants(TenantFromHeader("X-Tenant"))
+	tenants.SetConfig(TenantConfigFunc(func(ctx context.Context, tenant string) (M, error) {
+		if tenant == "broken" {
+			return nil, errors.New("config store down")
+		}
+		cfg, ok := configs[tenant]
+		if !ok {
+			return nil, ErrTenantNotFound
+		}
+		return cfg, nil
+	}))
+
+	builds := make(map[string]int)
+	tenants.Shared().Use(PerTenant(func(tenant string, cfg M) func(http.Handler) http.Handler {
+		builds[tenant]++
+		greeting, _ := cfg["greeting"].(string)
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(greeting + " "))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}))
+	tenants.Shared().HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(TenantConfigFromContext(r.Context())["greeting"].(string)))
+	})
+
+	serve := func(tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		rec := httptest.NewRecorder()
+		tenants.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		tenant string
+		code   int
+		body   string
+	}{
+		{"acme", http.StatusOK, "hi hi"},
+		{"acme", http.StatusOK, "hi hi"},
+		{"globex", http.StatusOK, "hello hello"},
+		{"initech", http.StatusNotFound, "404 page not found\n"},
+		{"broken", http.StatusServiceUnavailable, "Service Unavailable\n"},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.tenant); rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.tenant, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+	if builds["acme"] != 1 {
+		t.Errorf("expected middleware built once for acme, got %d", builds["acme"])
+	}
+
+	configs["acme"] = M{"greeting": "hey"}
+	if rec := serve("acme"); rec.Body.String() != "hey hey" {
+		t.Errorf("expected rebuilt middleware after a config change, got %q", rec.Body.String())
+	}
+	if builds["acme"] != 2 {
+		t.Errorf("expected middleware rebuilt for acme, got %d builds", builds["acme"])
+	}
+}
+
+func TestPerTenant_Evicts(t *testing.T) {
+	builds := make(map[string]int)
+	h := perTenant(func(tenant string, cfg M) func(http.Handler) http.Handler {
+		builds[tenant]++
+		return nil
+	}, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(tenant string, cfg M) {
+		ctx := context.WithValue(ContextWithTenant(context.Background(), tenant), tenantConfigKey, cfg)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	}
+
+	// Equal configurations returned as new maps do not rebuild.
+	serve("acme", M{"plan": "pro"})
+	serve("acme", M{"plan": "pro"})
+	serve("globex", nil)
+	serve("acme", M{"plan": "pro"})
+	serve("initech", nil) // evicts globex, the least recently served
+	serve("acme", M{"plan": "pro"})
+	serve("globex", nil)
+
+	expected := map[string]int{"acme": 1, "globex": 2, "initech": 1}
+	if !reflect.DeepEqual(builds, expected) {
+		t.Errorf("expected builds %v, got %v", expected, builds)
+	}
+}
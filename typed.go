@@ -0,0 +1,111 @@
+package hmux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// StatusCoder is implemented by responses of typed handlers that choose
+// their own status code, such as 201 Created.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// JSON returns a handler serving a JSON API endpoint with fn, a function
+// taking the decoded request and returning the response to encode, so
+// handlers hold only the business logic:
+//
+//	type CreateUser struct {
+//	    OrgID string `path:"org"`
+//	    Name  string `json:"name"`
+//	}
+//
+//	func (c CreateUser) Validate() error { ... }
+//
+//	mux.Handle("POST /orgs/{org}/users", hmux.JSON(func(ctx context.Context, req CreateUser) (*User, error) {
+//	    return users.Create(ctx, req.OrgID, req.Name)
+//	}))
+//
+// The request value is built from the path wildcards, bound to struct
+// fields by their "path" tag, the URL query, bound by "query" tags as by
+// BindQuery, and the body, if the request has one, decoded as by Bind.
+// If Req implements Validator, it is validated after decoding.
+//
+// The response is encoded as JSON with status 200 OK, or the status
+// returned by its StatusCode method if it implements StatusCoder. A
+// response of type struct{} is sent as 204 No Content.
+//
+// Decoding, validation and encoding errors, and errors returned by fn,
+// are passed to the route's error handler, which reports them with the
+// status code given by StatusCode, so fn signals a missing resource by
+// returning, for example, hmux.Errorf(http.StatusNotFound, "no user %s", id).
+// The handler must only be registered with hmux.
+func JSON[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error)) http.Handler {
+	if fn == nil {
+		panic("hmux: nil function passed to JSON")
+	}
+
+	isStruct := reflect.TypeFor[Req]().Kind() == reflect.Struct
+	noContent := reflect.TypeFor[Resp]() == reflect.TypeFor[struct{}]()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := decodeRequest(r, &req, isStruct); err != nil {
+			raise(err)
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			raise(err)
+		}
+
+		if noContent {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		status := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(resp); err != nil {
+			raise(NewError(http.StatusInternalServerError, err))
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+	})
+}
+
+// decodeRequest fills dst from the path wildcards, query and body of r,
+// and validates it. Path and query values are only bound into structs.
+func decodeRequest(r *http.Request, dst any, isStruct bool) error {
+	if isStruct {
+		path := make(url.Values)
+		for _, name := range wildcardNames(r.Pattern) {
+			path.Set(name, r.PathValue(name))
+		}
+		if err := bindValues(path, "path", dst); err != nil {
+			return err
+		}
+		if err := bindValues(r.URL.Query(), "query", dst); err != nil {
+			return err
+		}
+	}
+
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		// Bind validates dst.
+		return Bind(r, dst)
+	}
+
+	return validate(dst)
+}
@@ -0,0 +1,92 @@
+package hmux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createItem struct {
+	List  string `path:"list"`
+	Name  string `json:"name"`
+	Draft bool   `query:"draft"`
+}
+
+func (c createItem) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type item struct {
+	List  string `json:"list"`
+	Name  string `json:"name"`
+	Draft bool   `json:"draft"`
+}
+
+type created struct {
+	item
+}
+
+func (created) StatusCode() int { return http.StatusCreated }
+
+type listQuery struct {
+	Limit int `query:"limit" default:"10"`
+}
+
+func TestJSON(t *testing.T) {
+	m := New()
+	m.Handle("POST /lists/{list}/items", JSON(func(ctx context.Context, req createItem) (created, error) {
+		if req.Name == "taken" {
+			return created{}, Errorf(http.StatusConflict, "item %q exists", req.Name)
+		}
+		return created{item{req.List, req.Name, req.Draft}}, nil
+	}))
+	m.Handle("GET /items", JSON(func(ctx context.Context, req listQuery) ([]int, error) {
+		return make([]int, req.Limit), nil
+	}))
+	m.Handle("DELETE /items/{id}", JSON(func(ctx context.Context, req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	m.Handle("GET /broken", JSON(func(ctx context.Context, req struct{}) (any, error) {
+		return func() {}, nil
+	}))
+
+	tests := []struct {
+		method, target, body string
+		code                 int
+		response             string
+	}{
+		{http.MethodPost, "/lists/todo/items?draft=true", `{"name":"milk"}`, http.StatusCreated, `{"list":"todo","name":"milk","draft":true}` + "\n"},
+		{http.MethodPost, "/lists/todo/items", `{"name":""}`, http.StatusBadRequest, "name is required\n"},
+		{http.MethodPost, "/lists/todo/items", "", http.StatusBadRequest, "name is required\n"},
+		{http.MethodPost, "/lists/todo/items", `{"name":`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/lists/todo/items?draft=maybe", `{"name":"milk"}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/lists/todo/items", `{"name":"taken"}`, http.StatusConflict, "item \"taken\" exists\n"},
+		{http.MethodGet, "/items?limit=2", "", http.StatusOK, "[0,0]\n"},
+		{http.MethodGet, "/items", "", http.StatusOK, "[0,0,0,0,0,0,0,0,0,0]\n"},
+		{http.MethodDelete, "/items/1", "", http.StatusNoContent, ""},
+		{http.MethodGet, "/broken", "", http.StatusInternalServerError, "Internal Server Error\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+			if tt.response != "" && rec.Body.String() != tt.response {
+				t.Errorf("expected %q, got %q", tt.response, rec.Body.String())
+			}
+		})
+	}
+}
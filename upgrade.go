@@ -0,0 +1,112 @@
+package hmux
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// websocketGUID is the key suffix defined by RFC 6455, section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrNotUpgrade is returned by Upgrade for requests that do not ask for
+// the requested protocol upgrade.
+var ErrNotUpgrade = errors.New("hmux: request is not a protocol upgrade")
+
+// IsUpgrade reports whether r asks to switch protocols, such as a
+// WebSocket handshake: its Connection header lists the "upgrade" token
+// and its Upgrade header is set. Middleware that buffers or rewrites the
+// response, such as compression and timeouts, skips these requests.
+//
+// Example:
+//
+//	mux.Use(hmux.When(func(r *http.Request) bool { return !hmux.IsUpgrade(r) }, logging))
+func IsUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Upgrade completes a protocol upgrade to the given protocol, such as
+// "websocket". It hijacks the connection, writes a 101 Switching
+// Protocols response carrying header, and returns the connection for the
+// new protocol. Any data the client sent after the request is available
+// from the returned reader.
+//
+// Upgrade works through middleware whose response writer wrappers
+// implement Hijack or Unwrap, which all hmux wrappers do. It returns
+// ErrNotUpgrade if r does not ask for protocol, or the error from
+// hijacking; in both cases nothing has been written and the caller can
+// still respond normally.
+//
+// For WebSockets, the caller sets the Sec-WebSocket-Accept header with
+// WebSocketAccept:
+//
+//	h := http.Header{}
+//	h.Set("Sec-WebSocket-Accept", hmux.WebSocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+//	conn, rw, err := hmux.Upgrade(w, r, "websocket", h)
+func Upgrade(w http.ResponseWriter, r *http.Request, protocol string, header http.Header) (net.Conn, *bufio.ReadWriter, error) {
+	if !IsUpgrade(r) || !upgradeRequested(r, protocol) {
+		return nil, nil, ErrNotUpgrade
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp := http.Header{}
+	for k, v := range header {
+		resp[k] = v
+	}
+	resp.Set("Upgrade", protocol)
+	resp.Set("Connection", "Upgrade")
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.Write(rw)
+	rw.WriteString("\r\n")
+
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, rw, nil
+}
+
+// upgradeRequested reports whether protocol is listed in the Upgrade
+// header of r.
+func upgradeRequested(r *http.Request, protocol string) bool {
+	for _, v := range r.Header.Values("Upgrade") {
+		for _, p := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(p), protocol) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WebSocketAccept returns the Sec-WebSocket-Accept value for the given
+// Sec-WebSocket-Key, as defined by RFC 6455.
+func WebSocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
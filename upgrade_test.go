@@ -0,0 +1,118 @@
+package hmux
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsUpgrade(t *testing.T) {
+	tests := []struct {
+		name       string
+		connection string
+		upgrade    string
+		expected   bool
+	}{
+		{"websocket", "Upgrade", "websocket", true},
+		{"token list", "keep-alive, upgrade", "websocket", true},
+		{"no upgrade header", "Upgrade", "", false},
+		{"no connection token", "keep-alive", "websocket", false},
+		{"plain", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.connection != "" {
+				r.Header.Set("Connection", tt.connection)
+			}
+			if tt.upgrade != "" {
+				r.Header.Set("Upgrade", tt.upgrade)
+			}
+
+			if got := IsUpgrade(r); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// Example from RFC 6455, section 1.3.
+	if got := WebSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept value %q", got)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	status := make(chan int, 1)
+
+	m := New()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := NewStatusWriter(w)
+			next.ServeHTTP(sw, r)
+			status <- sw.Status()
+		})
+	})
+	m.HandleFunc("GET /echo", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := Upgrade(w, r, "echo", nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+
+		line, _ := rw.ReadString('\n')
+		rw.WriteString(line)
+		rw.Flush()
+	})
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET /echo HTTP/1.1\r\nHost: x\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\nhello\n"))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Upgrade") != "echo" {
+		t.Fatalf("expected 101 echo, got %d %q", resp.StatusCode, resp.Header.Get("Upgrade"))
+	}
+
+	line, err := br.ReadString('\n')
+	if err != nil || line != "hello\n" {
+		t.Errorf("expected echoed line, got %q, %v", line, err)
+	}
+
+	if got := <-status; got != http.StatusSwitchingProtocols {
+		t.Errorf("expected StatusWriter to record 101, got %d", got)
+	}
+}
+
+func TestUpgrade_NotRequested(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "h2c")
+
+	_, _, err := Upgrade(rec, r, "websocket", nil)
+	if !errors.Is(err, ErrNotUpgrade) {
+		t.Errorf("expected ErrNotUpgrade, got %v", err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || !strings.Contains(err.Error(), "upgrade") {
+		t.Error("expected nothing written")
+	}
+}
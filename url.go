@@ -0,0 +1,103 @@
+package hmux
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RouteName is the metadata key naming a route, so that URL can build
+// links to it:
+//
+//	mux.Meta(hmux.M{hmux.RouteName: "invoice"}).
+//	    HandleFunc("GET /invoices/{id}", getInvoice)
+const RouteName = "name"
+
+// ErrRouteNotFound is returned by URL when no route has the given name.
+var ErrRouteNotFound = errors.New("hmux: route not found")
+
+// URL returns the path of the route named name, with its wildcards
+// filled in from params, which alternate wildcard names and values:
+//
+//	u, err := mux.URL("invoice", "id", "42") // "/invoices/42"
+//
+// Values are escaped as path segments; the value of a remainder wildcard
+// such as {path...} may contain slashes. Routes of Host and Version
+// routers are included; the host of the pattern is not part of the
+// result. If several routes share the name, the first registered wins.
+//
+// URL returns ErrRouteNotFound if no route has the name, and an error if
+// params do not name each wildcard of its pattern exactly once.
+func (m *Mux) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("hmux: odd number of params for route %q", name)
+	}
+
+	for _, rt := range m.Routes() {
+		if n, _ := rt.Meta[RouteName].(string); n == name {
+			return buildPath(rt.Pattern, params)
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+}
+
+// buildPath fills the wildcards of the path of pattern from params.
+func buildPath(pattern string, params []string) (string, error) {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(rest, " \t")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(pattern[:start])
+		name := pattern[start+1 : end]
+		pattern = pattern[end+1:]
+		if name == "$" {
+			continue
+		}
+
+		name, remainder := strings.CutSuffix(name, "...")
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("hmux: missing value for wildcard %s", name)
+		}
+		delete(values, name)
+
+		if remainder {
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+	b.WriteString(pattern)
+
+	for name := range values {
+		return "", fmt.Errorf("hmux: unknown wildcard %s", name)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,51 @@
+package hmux
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	m := New()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	m.Meta(M{RouteName: "invoice"}).HandleFunc("GET /invoices/{id}", noop)
+	m.Meta(M{RouteName: "file"}).HandleFunc("GET /files/{owner}/{path...}", noop)
+	m.Meta(M{RouteName: "home"}).HandleFunc("GET /{$}", noop)
+	m.Group("/api").(ExtendedRouter).Meta(M{RouteName: "user"}).HandleFunc("/users/{id}", noop)
+	m.Host("{tenant}.example.com").Meta(M{RouteName: "dashboard"}).HandleFunc("GET /dashboard", noop)
+
+	tests := []struct {
+		name   string
+		route  string
+		params []string
+		path   string
+		err    bool
+	}{
+		{"wildcard", "invoice", []string{"id", "42"}, "/invoices/42", false},
+		{"escaped", "invoice", []string{"id", "a/b c"}, "/invoices/a%2Fb%20c", false},
+		{"remainder", "file", []string{"owner", "ada", "path", "docs/a b.txt"}, "/files/ada/docs/a%20b.txt", false},
+		{"end anchor", "home", nil, "/", false},
+		{"group", "user", []string{"id", "7"}, "/api/users/7", false},
+		{"host", "dashboard", nil, "/dashboard", false},
+		{"missing value", "invoice", nil, "", true},
+		{"unknown wildcard", "invoice", []string{"id", "1", "x", "2"}, "", true},
+		{"odd params", "invoice", []string{"id"}, "", true},
+		{"unknown route", "nope", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := m.URL(tt.route, tt.params...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if path != tt.path {
+				t.Errorf("expected %q, got %q", tt.path, path)
+			}
+		})
+	}
+
+	if _, err := m.URL("nope"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound, got %v", err)
+	}
+}
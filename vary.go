@@ -0,0 +1,54 @@
+package hmux
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// AddVary adds the given request header names to the Vary header of h,
+// merging them with the names already listed instead of overwriting or
+// repeating them, so that middleware branching on different request
+// headers, such as Accept-Encoding, Origin or Accept-Language, can each
+// declare theirs:
+//
+//	hmux.AddVary(w.Header(), "Accept-Encoding")
+//
+// The merged list is written as a single Vary value. Names are compared
+// case-insensitively, and nothing is added once Vary is "*".
+func AddVary(h http.Header, names ...string) {
+	var (
+		list []string
+		seen = make(map[string]struct{})
+	)
+	add := func(name string) {
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		list = append(list, name)
+	}
+
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			add(name)
+		}
+	}
+	if _, ok := seen["*"]; ok {
+		return
+	}
+
+	n := len(list)
+	for _, name := range names {
+		add(name)
+	}
+	if len(list) == n && len(h.Values("Vary")) <= 1 {
+		return
+	}
+
+	if _, ok := seen["*"]; ok {
+		list = []string{"*"}
+	}
+	h.Set("Vary", strings.Join(list, ", "))
+}
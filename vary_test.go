@@ -0,0 +1,38 @@
+package hmux
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		add      []string
+		expected []string
+	}{
+		{"empty", nil, []string{"Accept-Encoding"}, []string{"Accept-Encoding"}},
+		{"merge", []string{"Origin"}, []string{"accept-encoding"}, []string{"Origin, Accept-Encoding"}},
+		{"no duplicates", []string{"Accept-Encoding, Origin"}, []string{"origin", "Accept-Encoding"}, []string{"Accept-Encoding, Origin"}},
+		{"joins lines", []string{"Origin", "Cookie"}, []string{"Accept"}, []string{"Origin, Cookie, Accept"}},
+		{"star kept", []string{"*"}, []string{"Accept"}, []string{"*"}},
+		{"star added", []string{"Origin"}, []string{"*"}, []string{"*"}},
+		{"untouched", []string{"Origin"}, nil, []string{"Origin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for _, v := range tt.existing {
+				h.Add("Vary", v)
+			}
+
+			AddVary(h, tt.add...)
+
+			got := h.Values("Vary")
+			if len(got) != len(tt.expected) || (len(got) > 0 && got[0] != tt.expected[0]) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package hmux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VersionHeader is the request header selecting an API version, echoed
+// on responses served by a Version router.
+const VersionHeader = "API-Version"
+
+// versionRoute is an API version with the Mux serving its requests.
+type versionRoute struct {
+	version    string
+	mux        *Mux
+	deprecated bool
+	sunset     time.Time
+}
+
+// VersionOption configures an API version registered with Version.
+type VersionOption func(*versionRoute)
+
+// VersionDefault returns a VersionOption that makes the version serve
+// requests that do not ask for a version.
+func VersionDefault() VersionOption {
+	return func(v *versionRoute) {
+		v.mux.parent.defaultVersion = v.version
+	}
+}
+
+// VersionDeprecated returns a VersionOption that marks the version as
+// deprecated. Its responses carry a "Deprecation: true" header and, if
+// sunset is non-zero, a Sunset header with the date the version will be
+// removed.
+func VersionDeprecated(sunset time.Time) VersionOption {
+	return func(v *versionRoute) {
+		v.deprecated = true
+		v.sunset = sunset
+	}
+}
+
+// Version returns a router whose routes serve requests for the given API
+// version. Clients select a version with the API-Version header, or with
+// a "version" parameter in the Accept header:
+//
+//	API-Version: 2023-10
+//	Accept: application/json; version=2023-10
+//
+// Version dispatch runs before the regular routes. A request for a
+// registered version is served by that version's routes only; a request
+// for an unknown version is rejected with 400 Bad Request through the
+// error handler; a request without a version goes to the default version
+// if one was set with VersionDefault, and to the routes registered on
+// the Mux otherwise. Responses carry the API-Version header of the
+// version that served them. The router inherits a copy of the Mux's
+// current middleware, like Group.
+//
+// Example:
+//
+//	v1 := mux.Version("2023-10", hmux.VersionDeprecated(sunset))
+//	v1.HandleFunc("GET /users", listUsersV1)
+//
+//	v2 := mux.Version("2024-06", hmux.VersionDefault())
+//	v2.HandleFunc("GET /users", listUsersV2)
+//
+// Version panics if version is empty or already registered.
+func (m *Mux) Version(version string, opts ...VersionOption) ExtendedRouter {
+	if version == "" {
+		panic("hmux: empty API version")
+	}
+	for _, v := range m.versions {
+		if v.version == version {
+			panic("hmux: API version " + version + " already registered")
+		}
+	}
+
+	child := m.newChild()
+	v := &versionRoute{version: version, mux: child}
+	for _, opt := range opts {
+		opt(v)
+	}
+	m.versions = append(m.versions, v)
+
+	g := m.Group("").(*Group)
+	g.mux = child
+
+	return g
+}
+
+// serveVersion dispatches r to the version it asks for. It reports
+// whether the request was handled.
+func (m *Mux) serveVersion(w http.ResponseWriter, r *http.Request) bool {
+	requested := requestedVersion(r)
+	if requested == "" {
+		requested = m.defaultVersion
+	}
+	if requested == "" {
+		return false
+	}
+
+	for _, v := range m.versions {
+		if v.version != requested {
+			continue
+		}
+
+		h := w.Header()
+		h.Set(VersionHeader, v.version)
+		if v.deprecated {
+			h.Set("Deprecation", "true")
+			if !v.sunset.IsZero() {
+				h.Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+			}
+		}
+
+		v.mux.serve(w, r)
+
+		return true
+	}
+
+	m.errorHandlerFunc()(w, r, Errorf(http.StatusBadRequest, "unsupported API version %q", requested))
+
+	return true
+}
+
+// requestedVersion returns the API version asked for by r, from the
+// API-Version header or a "version" parameter of the Accept header.
+func requestedVersion(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get(VersionHeader)); v != "" {
+		return v
+	}
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && params["version"] != "" {
+				return params["version"]
+			}
+		}
+	}
+
+	return ""
+}
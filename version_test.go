@@ -0,0 +1,104 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMux_Version(t *testing.T) {
+	sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := New()
+	m.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("unversioned"))
+	})
+	m.Version("2023-10", VersionDeprecated(sunset)).HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1"))
+	})
+	m.Version("2024-06").HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v2"))
+	})
+
+	tests := []struct {
+		name        string
+		header      string
+		accept      string
+		path        string
+		status      int
+		body        string
+		version     string
+		deprecation string
+		sunset      string
+	}{
+		{"header", "2024-06", "", "/users", http.StatusOK, "v2", "2024-06", "", ""},
+		{"accept parameter", "", "application/json; version=2023-10", "/users", http.StatusOK, "v1", "2023-10", "true", "Wed, 01 Jan 2025 00:00:00 GMT"},
+		{"unknown version", "1999-01", "", "/users", http.StatusBadRequest, "unsupported API version \"1999-01\"\n", "", "", ""},
+		{"no version", "", "", "/status", http.StatusOK, "unversioned", "", "", ""},
+		{"no version no route", "", "", "/users", http.StatusNotFound, "404 page not found\n", "", "", ""},
+		{"version without route", "2024-06", "", "/status", http.StatusNotFound, "404 page not found\n", "2024-06", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(VersionHeader, tt.header)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.body, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get(VersionHeader); got != tt.version {
+				t.Errorf("expected API-Version %q, got %q", tt.version, got)
+			}
+			if got := rec.Header().Get("Deprecation"); got != tt.deprecation {
+				t.Errorf("expected Deprecation %q, got %q", tt.deprecation, got)
+			}
+			if got := rec.Header().Get("Sunset"); got != tt.sunset {
+				t.Errorf("expected Sunset %q, got %q", tt.sunset, got)
+			}
+		})
+	}
+}
+
+func TestMux_Version_Default(t *testing.T) {
+	m := New()
+	m.Version("2024-06", VersionDefault()).HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v2"))
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Body.String() != "v2" || rec.Header().Get(VersionHeader) != "2024-06" {
+		t.Errorf("expected default version, got %q %q", rec.Body.String(), rec.Header().Get(VersionHeader))
+	}
+}
+
+func TestMux_Version_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(m *Mux)
+	}{
+		{"empty", func(m *Mux) { m.Version("") }},
+		{"duplicate", func(m *Mux) { m.Version("v1"); m.Version("v1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn(New())
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package hmux
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+)
+
+// BufferedWriter is an http.ResponseWriter that holds the response in
+// memory so middleware can inspect or replace it before anything reaches
+// the client. It is the building block for middleware that needs the
+// complete response, such as ETag computation or response caching.
+//
+// Buffering is bounded: once the body grows beyond the configured limit,
+// or the handler flushes, the writer commits the status and the buffered
+// bytes to the underlying writer and streams the remainder straight
+// through. Callers check Buffered after the handler returns to learn
+// whether the response is still theirs to inspect.
+//
+// Headers are shared with the underlying writer, so header changes made
+// by middleware before Commit are sent with the response.
+//
+// Example:
+//
+//	bw := hmux.NewBufferedWriter(w, 1<<20)
+//	next.ServeHTTP(bw, r)
+//	if bw.Buffered() {
+//	    // inspect bw.Status() and bw.Bytes()
+//	}
+//	bw.Commit()
+type BufferedWriter struct {
+	w         http.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	status    int
+	committed bool
+}
+
+// NewBufferedWriter returns a BufferedWriter wrapping w that buffers at
+// most limit bytes of body. A limit of zero or less buffers without
+// bound.
+func NewBufferedWriter(w http.ResponseWriter, limit int) *BufferedWriter {
+	return &BufferedWriter{w: w, limit: limit}
+}
+
+// Header returns the header map of the underlying writer.
+func (bw *BufferedWriter) Header() http.Header {
+	return bw.w.Header()
+}
+
+// WriteHeader records the status code. Only the first call has effect.
+func (bw *BufferedWriter) WriteHeader(status int) {
+	if bw.status != 0 {
+		return
+	}
+
+	bw.status = status
+	if bw.committed {
+		bw.w.WriteHeader(status)
+	}
+}
+
+// Write buffers p, or writes it through once the writer has committed.
+// Exceeding the buffer limit commits the response.
+func (bw *BufferedWriter) Write(p []byte) (int, error) {
+	if bw.status == 0 {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if bw.committed {
+		return bw.w.Write(p)
+	}
+
+	if bw.limit > 0 && bw.buf.Len()+len(p) > bw.limit {
+		if err := bw.Commit(); err != nil {
+			return 0, err
+		}
+		return bw.w.Write(p)
+	}
+
+	return bw.buf.Write(p)
+}
+
+// Flush commits the response and flushes the underlying writer. A handler
+// that flushes is streaming, so buffering stops.
+func (bw *BufferedWriter) Flush() {
+	_ = bw.Commit()
+	_ = http.NewResponseController(bw.w).Flush()
+}
+
+// Status returns the recorded status code, or 200 if the handler wrote a
+// body without calling WriteHeader. It returns 0 if nothing was written.
+func (bw *BufferedWriter) Status() int {
+	return bw.status
+}
+
+// Bytes returns the buffered body. The slice is only valid until the next
+// write and is empty once the writer has committed.
+func (bw *BufferedWriter) Bytes() []byte {
+	return bw.buf.Bytes()
+}
+
+// Buffered reports whether the response is still held in memory.
+func (bw *BufferedWriter) Buffered() bool {
+	return !bw.committed
+}
+
+// Reset discards the buffered body and status so the caller can write a
+// different response. It has no effect once the writer has committed.
+func (bw *BufferedWriter) Reset() {
+	if bw.committed {
+		return
+	}
+
+	bw.buf.Reset()
+	bw.status = 0
+}
+
+// Commit writes the recorded status and buffered body to the underlying
+// writer and switches to pass-through mode. If nothing was written, the
+// underlying writer is left untouched so its own defaults apply. Calling
+// Commit more than once is harmless.
+func (bw *BufferedWriter) Commit() error {
+	if bw.committed {
+		return nil
+	}
+	bw.committed = true
+
+	if bw.status == 0 {
+		return nil
+	}
+
+	bw.w.WriteHeader(bw.status)
+	_, err := bw.w.Write(bw.buf.Bytes())
+	bw.buf.Reset()
+
+	return err
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (bw *BufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(bw.w).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (bw *BufferedWriter) Unwrap() http.ResponseWriter {
+	return bw.w
+}
+
+// StatusWriter is an http.ResponseWriter that passes everything through
+// while recording the status code and the number of body bytes written.
+// Logging and metrics middleware use it to observe responses without
+// altering them.
+//
+// Example:
+//
+//	sw := hmux.NewStatusWriter(w)
+//	next.ServeHTTP(sw, r)
+//	log.Printf("%s %s %d %dB", r.Method, r.URL.Path, sw.Status(), sw.BytesWritten())
+type StatusWriter struct {
+	w      http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewStatusWriter returns a StatusWriter wrapping w.
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{w: w}
+}
+
+// Header returns the header map of the underlying writer.
+func (sw *StatusWriter) Header() http.Header {
+	return sw.w.Header()
+}
+
+// WriteHeader records the first status code and forwards the call.
+func (sw *StatusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+
+	sw.w.WriteHeader(status)
+}
+
+// Write forwards p and counts the bytes written.
+func (sw *StatusWriter) Write(p []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	n, err := sw.w.Write(p)
+	sw.bytes += int64(n)
+
+	return n, err
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (sw *StatusWriter) Flush() {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	_ = http.NewResponseController(sw.w).Flush()
+}
+
+// Status returns the status code sent, or 0 if the handler has written
+// nothing yet. A handler that returns without writing produces an
+// implicit 200.
+func (sw *StatusWriter) Status() int {
+	return sw.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (sw *StatusWriter) BytesWritten() int64 {
+	return sw.bytes
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (sw *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusSwitchingProtocols
+	}
+
+	return http.NewResponseController(sw.w).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (sw *StatusWriter) Unwrap() http.ResponseWriter {
+	return sw.w
+}
+
+// CaptureWriter is an http.ResponseWriter that passes the response
+// through to the client, like StatusWriter, while keeping a copy of it:
+// the status code, the header as sent, and the body. Unlike
+// BufferedWriter it never holds the response back, so it suits
+// middleware that must record responses for later replay, including
+// streamed ones, such as idempotency or audit middleware.
+//
+// The copy of the body is bounded; Complete reports whether the whole
+// response was captured.
+//
+// Example:
+//
+//	cw := hmux.NewCaptureWriter(w, 1<<20)
+//	next.ServeHTTP(cw, r)
+//	if cw.Complete() {
+//	    store(cw.Status(), cw.SentHeader(), cw.Bytes())
+//	}
+type CaptureWriter struct {
+	w         http.ResponseWriter
+	limit     int
+	status    int
+	header    http.Header
+	body      bytes.Buffer
+	truncated bool
+	hijacked  bool
+}
+
+// NewCaptureWriter returns a CaptureWriter wrapping w that keeps a copy
+// of at most limit bytes of body. A limit of zero or less copies the body
+// without bound.
+func NewCaptureWriter(w http.ResponseWriter, limit int) *CaptureWriter {
+	return &CaptureWriter{w: w, limit: limit}
+}
+
+// Header returns the header map of the underlying writer.
+func (cw *CaptureWriter) Header() http.Header {
+	return cw.w.Header()
+}
+
+// WriteHeader records the first status code and a copy of the header,
+// and forwards the call.
+func (cw *CaptureWriter) WriteHeader(status int) {
+	if cw.status == 0 && status >= 200 {
+		cw.status = status
+		cw.header = cw.w.Header().Clone()
+	}
+
+	cw.w.WriteHeader(status)
+}
+
+// Write forwards p and copies it while the copy is within the limit.
+func (cw *CaptureWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	n, err := cw.w.Write(p)
+	if !cw.truncated {
+		if cw.limit > 0 && cw.body.Len()+n > cw.limit {
+			cw.truncated = true
+			cw.body.Reset()
+		} else {
+			cw.body.Write(p[:n])
+		}
+	}
+
+	return n, err
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (cw *CaptureWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	_ = http.NewResponseController(cw.w).Flush()
+}
+
+// Status returns the status code sent, or 0 if the handler has written
+// nothing yet. Informational 1xx responses are not recorded.
+func (cw *CaptureWriter) Status() int {
+	return cw.status
+}
+
+// SentHeader returns a copy of the header as it was when the status was
+// sent, or nil if nothing was written.
+func (cw *CaptureWriter) SentHeader() http.Header {
+	return cw.header
+}
+
+// Bytes returns the captured body. It is empty once the body has
+// exceeded the limit.
+func (cw *CaptureWriter) Bytes() []byte {
+	return cw.body.Bytes()
+}
+
+// Complete reports whether the captured copy holds the whole response:
+// the body stayed within the limit and the connection was not hijacked.
+func (cw *CaptureWriter) Complete() bool {
+	return !cw.truncated && !cw.hijacked
+}
+
+// Hijack lets the caller take over the connection, as for an upgrade.
+// It returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (cw *CaptureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	cw.hijacked = true
+	return http.NewResponseController(cw.w).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for use by
+// http.ResponseController.
+func (cw *CaptureWriter) Unwrap() http.ResponseWriter {
+	return cw.w
+}
@@ -0,0 +1,149 @@
+package hmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBufferedWriter_Buffers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	bw := NewBufferedWriter(rec, 0)
+
+	bw.Header().Set("X-Test", "yes")
+	bw.WriteHeader(http.StatusCreated)
+	bw.Write([]byte("hello"))
+
+	if rec.Body.Len() != 0 {
+		t.Fatal("expected nothing written before Commit")
+	}
+	if !bw.Buffered() {
+		t.Error("expected writer to be buffered")
+	}
+	if bw.Status() != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", bw.Status())
+	}
+	if string(bw.Bytes()) != "hello" {
+		t.Errorf("expected buffered %q, got %q", "hello", bw.Bytes())
+	}
+
+	if err := bw.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != "hello" {
+		t.Errorf("expected 201 hello, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Test") != "yes" {
+		t.Error("expected shared headers")
+	}
+}
+
+func TestBufferedWriter_OverflowCommits(t *testing.T) {
+	rec := httptest.NewRecorder()
+	bw := NewBufferedWriter(rec, 4)
+
+	bw.Write([]byte("abc"))
+	bw.Write([]byte("defg"))
+
+	if bw.Buffered() {
+		t.Error("expected writer to commit after exceeding limit")
+	}
+	if rec.Body.String() != "abcdefg" {
+		t.Errorf("expected %q, got %q", "abcdefg", rec.Body.String())
+	}
+
+	bw.Write([]byte("h"))
+	if rec.Body.String() != "abcdefgh" {
+		t.Errorf("expected pass-through write, got %q", rec.Body.String())
+	}
+}
+
+func TestBufferedWriter_FlushCommits(t *testing.T) {
+	rec := httptest.NewRecorder()
+	bw := NewBufferedWriter(rec, 0)
+
+	bw.Write([]byte("chunk"))
+	bw.Flush()
+
+	if bw.Buffered() {
+		t.Error("expected Flush to commit")
+	}
+	if !rec.Flushed || rec.Body.String() != "chunk" {
+		t.Errorf("expected flushed chunk, got flushed=%v %q", rec.Flushed, rec.Body.String())
+	}
+}
+
+func TestBufferedWriter_Reset(t *testing.T) {
+	rec := httptest.NewRecorder()
+	bw := NewBufferedWriter(rec, 0)
+
+	bw.WriteHeader(http.StatusOK)
+	bw.Write([]byte("original"))
+	bw.Reset()
+	bw.WriteHeader(http.StatusNotModified)
+	bw.Commit()
+
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatusWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := NewStatusWriter(rec)
+
+	if sw.Status() != 0 {
+		t.Errorf("expected status 0 before writing, got %d", sw.Status())
+	}
+
+	sw.WriteHeader(http.StatusAccepted)
+	sw.Write([]byte("hello"))
+	sw.Write([]byte(" world"))
+
+	if sw.Status() != http.StatusAccepted {
+		t.Errorf("expected status 202, got %d", sw.Status())
+	}
+	if sw.BytesWritten() != 11 {
+		t.Errorf("expected 11 bytes, got %d", sw.BytesWritten())
+	}
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "hello world" {
+		t.Errorf("expected pass-through, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatusWriter_ImplicitOK(t *testing.T) {
+	sw := NewStatusWriter(httptest.NewRecorder())
+	sw.Write([]byte("x"))
+
+	if sw.Status() != http.StatusOK {
+		t.Errorf("expected implicit 200, got %d", sw.Status())
+	}
+}
+
+func TestCaptureWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	cw := NewCaptureWriter(rec, 8)
+
+	cw.Header().Set("X-Test", "yes")
+	cw.WriteHeader(http.StatusCreated)
+	cw.Header().Set("X-Late", "ignored")
+	cw.Write([]byte("hello"))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "hello" {
+		t.Errorf("expected pass-through, got %d %q", rec.Code, rec.Body.String())
+	}
+	if cw.Status() != http.StatusCreated || string(cw.Bytes()) != "hello" || !cw.Complete() {
+		t.Errorf("expected captured 201 hello, got %d %q complete=%v", cw.Status(), cw.Bytes(), cw.Complete())
+	}
+	if cw.SentHeader().Get("X-Test") != "yes" || cw.SentHeader().Get("X-Late") != "" {
+		t.Errorf("expected header as sent, got %v", cw.SentHeader())
+	}
+
+	cw.Write([]byte(" world"))
+	if cw.Complete() || len(cw.Bytes()) != 0 {
+		t.Errorf("expected truncated capture, got %q complete=%v", cw.Bytes(), cw.Complete())
+	}
+	if rec.Body.String() != "hello world" {
+		t.Errorf("expected pass-through past the limit, got %q", rec.Body.String())
+	}
+}